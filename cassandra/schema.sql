@@ -4,6 +4,7 @@ USE janus;
 CREATE TABLE IF NOT EXISTS janus.user (
     username text,
     password text,
+    groups set<text>,
     PRIMARY KEY (username));
 
 CREATE TABLE IF NOT EXISTS janus.api_definition (
@@ -20,11 +21,30 @@ CREATE TABLE IF NOT EXISTS janus.organization (
     username text,
     password text,
     organization text,
+    groups set<text>,
     PRIMARY KEY (username));
 
 CREATE TABLE IF NOT EXISTS janus.organization_config (
     organization text,
     priority int,
     content_per_day int,
+    content_per_month int,
     config text,
     PRIMARY KEY (organization));
+
+CREATE TABLE IF NOT EXISTS janus.quota_usage (
+    organization text,
+    period text,
+    units counter,
+    PRIMARY KEY ((organization), period));
+
+CREATE TABLE IF NOT EXISTS janus.consumer (
+    username text,
+    consumer text,
+    PRIMARY KEY (username));
+
+CREATE TABLE IF NOT EXISTS janus.consumer_credential (
+    type text,
+    key text,
+    username text,
+    PRIMARY KEY ((type, key)));
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/openapi"
+)
+
+// ImportOptions are the options of the import commands
+type ImportOptions struct {
+	openapi.Options
+	// Output is the directory the definitions are written to, instead of the configured repository
+	Output string
+	// DryRun prints the definitions instead of writing them
+	DryRun bool
+	// Force replaces the stored definitions with the same names, and the file of the definitions in the output directory
+	Force bool
+}
+
+// NewImportCmd creates a new import command
+func NewImportCmd(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Generates API definitions from other formats",
+	}
+
+	cmd.AddCommand(NewImportOpenAPICmd(ctx))
+
+	return cmd
+}
+
+// NewImportOpenAPICmd creates a new import openapi command
+func NewImportOpenAPICmd(ctx context.Context) *cobra.Command {
+	opts := &ImportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "openapi <spec>",
+		Short: "Generates an API definition for every path of an OpenAPI 3 document",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunImportOpenAPI(cmd, args[0], opts)
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVar(&opts.Name, "name", "", "Beginning of the names of the definitions (default is the title of the document)")
+	fs.StringVar(&opts.ListenPathPrefix, "listen-path-prefix", "", "Prefix of the listen paths, removed before proxying the requests")
+	fs.StringVar(&opts.Upstream, "upstream", "", "Upstream target (default is the first server of the document)")
+	fs.BoolVar(&opts.Validate, "validate", false, "Validate the requests against the document with the openapi_validator plugin")
+	fs.BoolVar(&opts.Auth, "auth", false, "Add the auth plugins of the security schemes of the document")
+	fs.StringVar(&opts.OAuthServer, "oauth-server", "", "OAuth server of the oauth2 plugins (default is the name of the security scheme)")
+	fs.StringVarP(&opts.Output, "output", "o", "", "Directory to write the definitions to, instead of the configured repository")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Print the definitions instead of writing them")
+	fs.BoolVar(&opts.Force, "force", false, "Replace the definitions with the same names")
+
+	return cmd
+}
+
+// RunImportOpenAPI generates the definitions of an OpenAPI document and writes them
+func RunImportOpenAPI(cmd *cobra.Command, spec string, opts *ImportOptions) error {
+	data, err := ioutil.ReadFile(spec)
+	if err != nil {
+		return err
+	}
+
+	swagger, err := openapi.Load(data)
+	if err != nil {
+		return err
+	}
+
+	var dsnURL *url.URL
+	if !opts.DryRun && opts.Output == "" {
+		initConfig()
+		if globalConfig == nil {
+			return fmt.Errorf("could not load the configuration")
+		}
+
+		if dsnURL, err = url.Parse(globalConfig.Database.DSN); err != nil {
+			return fmt.Errorf("error parsing the DSN: %w", err)
+		}
+	}
+
+	// The definitions written to files next to the document read it from there, the definitions stored in a
+	// database are shared by gateways that don't have the file, they get their part of the document inline
+	if opts.Validate && (opts.Output != "" || (dsnURL != nil && dsnURL.Scheme == "file")) {
+		if opts.SpecFile, err = filepath.Abs(spec); err != nil {
+			return err
+		}
+	}
+
+	defs, warnings, err := openapi.Import(swagger, opts.Options)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		cmd.PrintErrln("Warning:", warning)
+	}
+
+	switch {
+	case opts.DryRun:
+		out, err := json.MarshalIndent(defs, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(out))
+		return nil
+	case opts.Output != "":
+		return writeDefinitionsFile(cmd, opts.Output, spec, defs, opts.Force)
+	}
+
+	if dsnURL.Scheme == "file" {
+		if err := os.MkdirAll(filepath.Join(dsnURL.Path, "apis"), 0755); err != nil {
+			return err
+		}
+	}
+
+	repo, err := api.BuildRepository(globalConfig.Database.DSN, globalConfig.Cluster.UpdateFrequency)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	stored, err := repo.FindAll()
+	if err != nil {
+		return err
+	}
+	if err := checkConflicts(stored, defs, opts.Force); err != nil {
+		return err
+	}
+
+	// The file system repository is read only, the definitions are written next to the other ones
+	if dsnURL.Scheme == "file" {
+		return writeDefinitionsFile(cmd, filepath.Join(dsnURL.Path, "apis"), spec, defs, true)
+	}
+
+	writer, ok := repo.(api.Writer)
+	if !ok {
+		return fmt.Errorf("the repository of %s does not support writing definitions", dsnURL.Scheme)
+	}
+	for _, def := range defs {
+		if err := writer.Add(def); err != nil {
+			return fmt.Errorf("could not add the definition %s: %w", def.Name, err)
+		}
+	}
+
+	cmd.Printf("Imported %d API definitions\n", len(defs))
+	return nil
+}
+
+// checkConflicts runs the checks of the admin API against the stored definitions, the definitions taking
+// the name or the route of a stored one are not imported. With force, the ones with the same names are replaced.
+func checkConflicts(stored, defs []*api.Definition, force bool) error {
+	replaced := make(map[string]bool, len(defs))
+	if force {
+		for _, def := range defs {
+			replaced[def.Name] = true
+		}
+	}
+
+	cfg := &api.Configuration{}
+	for _, def := range stored {
+		if !replaced[def.Name] {
+			cfg.Definitions = append(cfg.Definitions, def)
+		}
+	}
+
+	for _, def := range defs {
+		err := cfg.Conflict(def)
+		switch {
+		case err == api.ErrAPINameExists:
+			return fmt.Errorf("could not import the definition %s: %w, use --force to replace it", def.Name, err)
+		case err != nil:
+			return fmt.Errorf("could not import the definition %s: %w", def.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// writeDefinitionsFile writes the definitions to a single file, named after the document
+func writeDefinitionsFile(cmd *cobra.Command, dir, spec string, defs []*api.Definition, force bool) error {
+	if len(defs) == 0 {
+		return fmt.Errorf("the document has no operations")
+	}
+
+	out, err := json.MarshalIndent(defs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	name := filepath.Base(spec)
+	path := filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".json")
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists, use --force to replace it", path)
+	}
+	if err := ioutil.WriteFile(path, out, 0644); err != nil {
+		return err
+	}
+
+	cmd.Printf("Wrote %d API definitions to %s\n", len(defs), path)
+	return nil
+}
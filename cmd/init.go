@@ -12,6 +12,7 @@ import (
 	"contrib.go.opencensus.io/exporter/prometheus"
 	"github.com/hellofresh/janus/pkg/config"
 	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/plugin/basic/encrypt"
 	"github.com/hellofresh/logging-go"
 	_trace "github.com/hellofresh/opencensus-go-extras/trace"
 	"github.com/hellofresh/stats-go"
@@ -105,6 +106,23 @@ func initStatsClient() {
 	log.AddHook(hooks.NewLogrusHook(statsClient, globalConfig.Stats.ErrorsSection))
 }
 
+func initPasswordHasher() {
+	cfg := globalConfig.PasswordHash
+	hasher, err := encrypt.NewHasher(encrypt.Options{
+		Algorithm:     cfg.Algorithm,
+		Argon2Time:    cfg.Argon2Time,
+		Argon2Memory:  cfg.Argon2Memory,
+		Argon2Threads: cfg.Argon2Threads,
+		ScryptCost:    cfg.ScryptCost,
+		BcryptCost:    cfg.BcryptCost,
+	})
+	if err != nil {
+		log.WithError(err).WithField("algorithm", cfg.Algorithm).Fatal("Error initializing password hasher")
+	}
+
+	encrypt.SetDefaultHasher(hasher)
+}
+
 func initStatsExporter() {
 	var err error
 	logger := log.WithField("stats.exporter", globalConfig.Stats.Exporter)
@@ -28,6 +28,7 @@ Complete documentation is available at https://hellofresh.gitbooks.io/janus`,
 
 	cmd.AddCommand(NewCheckCmd(ctx))
 	cmd.AddCommand(NewServerStartCmd(ctx, version))
+	cmd.AddCommand(NewImportCmd(ctx))
 
 	return cmd
 }
@@ -10,14 +10,21 @@ import (
 	"github.com/spf13/cobra"
 
 	// this is needed to call the init function on each plugin
+	_ "github.com/hellofresh/janus/pkg/plugin/acl"
 	_ "github.com/hellofresh/janus/pkg/plugin/basic"
 	_ "github.com/hellofresh/janus/pkg/plugin/bodylmt"
 	_ "github.com/hellofresh/janus/pkg/plugin/cb"
 	_ "github.com/hellofresh/janus/pkg/plugin/compression"
+	_ "github.com/hellofresh/janus/pkg/plugin/consumer"
 	_ "github.com/hellofresh/janus/pkg/plugin/cors"
+	_ "github.com/hellofresh/janus/pkg/plugin/graphql"
+	_ "github.com/hellofresh/janus/pkg/plugin/hmacauth"
 	_ "github.com/hellofresh/janus/pkg/plugin/oauth2"
+	_ "github.com/hellofresh/janus/pkg/plugin/openapivalidator"
 	_ "github.com/hellofresh/janus/pkg/plugin/organization"
+	_ "github.com/hellofresh/janus/pkg/plugin/quota"
 	_ "github.com/hellofresh/janus/pkg/plugin/rate"
+	_ "github.com/hellofresh/janus/pkg/plugin/redirect"
 	_ "github.com/hellofresh/janus/pkg/plugin/requesttransformer"
 	_ "github.com/hellofresh/janus/pkg/plugin/responsetransformer"
 	_ "github.com/hellofresh/janus/pkg/plugin/retry"
@@ -64,6 +71,7 @@ func RunServerStart(ctx context.Context, opts *ServerStartOptions, version strin
 	initLog()
 	initStatsClient()
 	initStatsExporter()
+	initPasswordHasher()
 	initTracingExporter()
 
 	defer statsClient.Close()
@@ -8,7 +8,8 @@ require (
 	contrib.go.opencensus.io/exporter/prometheus v0.3.0
 	github.com/DataDog/datadog-go v0.0.0-20180330214955-e67964b4021a // indirect
 	github.com/Knetic/govaluate v3.0.1-0.20171022003610-9aa49832a739+incompatible
-	github.com/afex/hystrix-go v0.0.0-20180502004556-fa1af6a1f4f5
+	github.com/alicebob/miniredis/v2 v2.14.3
+	github.com/andybalholm/brotli v1.0.1
 	github.com/asaskevich/govalidator v0.0.0-20171111151018-521b25f4b05f
 	github.com/bshuster-repo/logrus-logstash-hook v0.4.1 // indirect
 	github.com/cactus/go-statsd-client v3.1.1+incompatible // indirect
@@ -18,6 +19,7 @@ require (
 	github.com/felixge/httpsnoop v1.0.0
 	github.com/fiam/gounidecode v0.0.0-20150629112515-8deddbd03fec // indirect
 	github.com/fsnotify/fsnotify v1.4.9
+	github.com/getkin/kin-openapi v0.26.0
 	github.com/go-chi/chi v3.3.2+incompatible
 	github.com/go-redis/redis/v7 v7.4.0
 	github.com/gocql/gocql v0.0.0-20200624222514-34081eda590e
@@ -29,13 +31,12 @@ require (
 	github.com/hellofresh/opencensus-go-extras v0.0.0-20191004131501-7bd94f603dcf
 	github.com/hellofresh/stats-go v0.8.0
 	github.com/kelseyhightower/envconfig v1.3.0
-	github.com/klauspost/compress v1.10.10 // indirect
+	github.com/klauspost/compress v1.10.10
 	github.com/magiconair/properties v1.8.1
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mitchellh/mapstructure v1.1.2
 	github.com/onsi/ginkgo v1.13.0 // indirect
 	github.com/opentracing/opentracing-go v1.2.0
-	github.com/rafaeljesus/retry-go v0.0.0-20171214204623-5981a380a879
 	github.com/rs/cors v1.4.0
 	github.com/sirupsen/logrus v1.7.0
 	github.com/spf13/cobra v1.0.0
@@ -45,6 +46,7 @@ require (
 	github.com/tidwall/gjson v1.1.0
 	github.com/tidwall/match v1.0.0 // indirect
 	github.com/ulule/limiter/v3 v3.5.0
+	github.com/vektah/gqlparser/v2 v2.1.0
 	go.mongodb.org/mongo-driver v1.4.1
 	go.opencensus.io v0.23.0
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
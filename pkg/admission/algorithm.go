@@ -0,0 +1,115 @@
+package admission
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// Gradient adapts the limit to the ratio between the long term and the recent latencies
+	Gradient = "gradient"
+	// AIMD increases the limit additively while the latency is under a threshold, and decreases it
+	// multiplicatively otherwise
+	AIMD = "aimd"
+)
+
+// Sample is the outcome of a request admitted by the limiter
+type Sample struct {
+	Latency time.Duration
+	// Inflight is the number of requests in flight when the request started
+	Inflight int64
+	// Dropped is set when the upstream failed or timed out
+	Dropped bool
+}
+
+// Algorithm computes the concurrency limit from the samples of the admitted requests
+type Algorithm interface {
+	// Update returns the new limit after the sample
+	Update(limit float64, sample Sample) float64
+}
+
+// aimd backs off on dropped or slow requests, and grows by one per limit of requests otherwise
+type aimd struct {
+	threshold time.Duration
+	backoff   float64
+}
+
+// NewAIMD creates an AIMD algorithm decreasing the limit when the latency goes over the threshold
+func NewAIMD(threshold time.Duration) Algorithm {
+	return &aimd{threshold: threshold, backoff: 0.9}
+}
+
+func (a *aimd) Update(limit float64, sample Sample) float64 {
+	if sample.Dropped || sample.Latency > a.threshold {
+		return limit * a.backoff
+	}
+
+	// the limit only grows while it is used, otherwise it would grow unbounded on low traffic
+	if float64(sample.Inflight)*2 >= limit {
+		return limit + 1/limit
+	}
+	return limit
+}
+
+// gradient compares the recent latency to the long term one: when the recent latency grows,
+// requests are queueing somewhere and the limit shrinks accordingly
+type gradient struct {
+	short     *ewma
+	long      *ewma
+	tolerance float64
+	smoothing float64
+}
+
+// NewGradient creates a gradient algorithm
+func NewGradient() Algorithm {
+	return &gradient{
+		short:     newEWMA(10),
+		long:      newEWMA(600),
+		tolerance: 1.5,
+		smoothing: 0.2,
+	}
+}
+
+func (g *gradient) Update(limit float64, sample Sample) float64 {
+	latency := float64(sample.Latency)
+	if latency <= 0 {
+		return limit
+	}
+
+	short := g.short.add(latency)
+	long := g.long.add(latency)
+
+	// the long term latency recovers faster once the load went away
+	if long/short > 2 {
+		g.long.value *= 0.95
+	}
+
+	ratio := math.Max(0.5, math.Min(1, g.tolerance*long/short))
+	newLimit := limit*ratio + math.Sqrt(limit)
+
+	// the limit only grows while it is used, otherwise it would grow unbounded on low traffic
+	if newLimit > limit && float64(sample.Inflight) < limit/2 {
+		return limit
+	}
+
+	return limit*(1-g.smoothing) + newLimit*g.smoothing
+}
+
+// ewma is an exponentially weighted moving average, the plain average of the first samples
+type ewma struct {
+	window float64
+	count  float64
+	value  float64
+}
+
+func newEWMA(window int) *ewma {
+	return &ewma{window: float64(window)}
+}
+
+func (e *ewma) add(v float64) float64 {
+	if e.count < e.window {
+		e.count++
+	}
+	e.value += (v - e.value) / e.count
+	return e.value
+}
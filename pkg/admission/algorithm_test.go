@@ -0,0 +1,44 @@
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAIMD(t *testing.T) {
+	a := NewAIMD(100 * time.Millisecond)
+
+	assert.Equal(t, 90.0, a.Update(100, Sample{Latency: time.Second, Inflight: 100}))
+	assert.Equal(t, 90.0, a.Update(100, Sample{Latency: time.Millisecond, Inflight: 100, Dropped: true}))
+	assert.Equal(t, 100.01, a.Update(100, Sample{Latency: time.Millisecond, Inflight: 60}))
+	// not enough requests in flight to know whether more would be fine
+	assert.Equal(t, 100.0, a.Update(100, Sample{Latency: time.Millisecond, Inflight: 10}))
+}
+
+func TestGradientShrinksWhenLatencyGrows(t *testing.T) {
+	g := NewGradient()
+
+	limit := 100.0
+	for i := 0; i < 100; i++ {
+		limit = g.Update(limit, Sample{Latency: 10 * time.Millisecond, Inflight: 100})
+	}
+	assert.True(t, limit > 100, "limit %f should grow while the latency is steady", limit)
+
+	grown := limit
+	for i := 0; i < 20; i++ {
+		limit = g.Update(limit, Sample{Latency: 100 * time.Millisecond, Inflight: 100})
+	}
+	assert.True(t, limit < grown, "limit %f should shrink once the latency grows", limit)
+}
+
+func TestGradientDoesNotGrowUnused(t *testing.T) {
+	g := NewGradient()
+
+	limit := 100.0
+	for i := 0; i < 100; i++ {
+		limit = g.Update(limit, Sample{Latency: 10 * time.Millisecond, Inflight: 1})
+	}
+	assert.Equal(t, 100.0, limit)
+}
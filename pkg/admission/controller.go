@@ -0,0 +1,181 @@
+package admission
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin/organization"
+)
+
+var (
+	// ErrOverloaded is used when a request is shed
+	ErrOverloaded = errors.New(http.StatusServiceUnavailable, "service is overloaded, please retry later")
+	// ErrInvalidAlgorithm is used when an unknown algorithm was configured
+	ErrInvalidAlgorithm = errors.New(http.StatusBadRequest, "admission algorithm is not supported")
+)
+
+type ticketKeyType int
+
+const ticketKey ticketKeyType = iota
+
+// ticket tracks a request from its API to its admission
+type ticket struct {
+	controller *Controller
+	tier       Tier
+	inflight   int64
+	admitted   bool
+}
+
+// Controller limits the number of requests in flight in Janus. The limit adapts to the latency
+// of the admitted requests, and every priority tier can use a share of it, so that the lowest
+// tiers are rejected first as the requests in flight get closer to the limit.
+type Controller struct {
+	mu         sync.Mutex
+	algorithm  Algorithm
+	limit      float64
+	minLimit   float64
+	maxLimit   float64
+	retryAfter time.Duration
+	inflight   int64
+}
+
+// NewController creates a new instance of Controller
+func NewController(cfg config.Admission) (*Controller, error) {
+	var algorithm Algorithm
+	switch cfg.Algorithm {
+	case Gradient, "":
+		algorithm = NewGradient()
+	case AIMD:
+		algorithm = NewAIMD(cfg.LatencyThreshold)
+	default:
+		return nil, ErrInvalidAlgorithm
+	}
+
+	minLimit := math.Max(1, float64(cfg.MinLimit))
+	maxLimit := math.Max(minLimit, float64(cfg.MaxLimit))
+
+	return &Controller{
+		algorithm:  algorithm,
+		limit:      math.Min(maxLimit, math.Max(minLimit, float64(cfg.InitialLimit))),
+		minLimit:   minLimit,
+		maxLimit:   maxLimit,
+		retryAfter: cfg.RetryAfter,
+	}, nil
+}
+
+// Limit returns the current concurrency limit
+func (c *Controller) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return int(c.limit)
+}
+
+// Inflight returns the number of requests in flight
+func (c *Controller) Inflight() int64 {
+	return atomic.LoadInt64(&c.inflight)
+}
+
+// Handler is the first middleware of the APIs of the tier, counting their requests in flight and shedding
+// them before any plugin runs. When raisable, the requests of the API can get a higher tier from the
+// organization of the authenticated user, so the ones that would be shed are deferred to the Raise middleware.
+func (c *Controller) Handler(tier Tier, raisable bool) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t := &ticket{controller: c, tier: tier, inflight: atomic.AddInt64(&c.inflight, 1)}
+			defer atomic.AddInt64(&c.inflight, -1)
+
+			if c.admit(tier) {
+				t.admitted = true
+			} else if !raisable {
+				c.shed(w, r, tier)
+				return
+			}
+
+			m := httpsnoop.CaptureMetrics(handler, w, r.WithContext(context.WithValue(r.Context(), ticketKey, t)))
+
+			if t.admitted {
+				c.observe(Sample{
+					Latency:  m.Duration,
+					Inflight: t.inflight,
+					Dropped:  m.Code == http.StatusBadGateway || m.Code == http.StatusServiceUnavailable || m.Code == http.StatusGatewayTimeout,
+				})
+			}
+		})
+	}
+}
+
+// admit checks whether a request of the tier fits in its share of the limit
+func (c *Controller) admit(tier Tier) bool {
+	if tier >= Critical {
+		return true
+	}
+
+	c.mu.Lock()
+	limit := c.limit
+	c.mu.Unlock()
+
+	return float64(c.Inflight()) <= limit*tier.share()
+}
+
+func (c *Controller) observe(sample Sample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.limit = math.Min(c.maxLimit, math.Max(c.minLimit, c.algorithm.Update(c.limit, sample)))
+}
+
+// shed rejects the request, telling the client when to retry
+func (c *Controller) shed(w http.ResponseWriter, r *http.Request, tier Tier) {
+	log.WithFields(log.Fields{
+		"path":     r.URL.Path,
+		"priority": tier.String(),
+		"inflight": c.Inflight(),
+		"limit":    c.Limit(),
+	}).Debug("Request shed")
+
+	retryAfter := int64(math.Ceil(c.retryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	errors.Handler(w, r, ErrOverloaded)
+}
+
+// Raise is the middleware deciding on the requests deferred by the Handler, once the organization of the
+// authenticated user is known, so it has to be after the auth plugins. The tier of the organization only
+// applies when it is higher than the one of the API. The other requests are let through.
+func Raise(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t, ok := r.Context().Value(ticketKey).(*ticket)
+		if !ok || t.admitted {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		tier := t.tier
+		if org, ok := organization.FromContext(r.Context()); ok {
+			if orgTier, ok := TierFromPriority(org.Priority); ok && orgTier > tier {
+				tier = orgTier
+			}
+		}
+
+		if !t.controller.admit(tier) {
+			t.controller.shed(w, r, tier)
+			return
+		}
+
+		t.admitted = true
+		handler.ServeHTTP(w, r)
+	})
+}
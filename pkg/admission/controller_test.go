@@ -0,0 +1,187 @@
+package admission
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/plugin/organization"
+	"github.com/hellofresh/janus/pkg/test"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func newFixedController(t *testing.T, limit int) *Controller {
+	t.Helper()
+
+	c, err := NewController(config.Admission{
+		Algorithm:    AIMD,
+		InitialLimit: limit,
+		MinLimit:     limit,
+		MaxLimit:     limit,
+		RetryAfter:   1500 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func serve(c *Controller, tier Tier, raisable bool, org *organization.OrganizationConfig) *httptest.ResponseRecorder {
+	var handler http.Handler = Raise(http.HandlerFunc(test.Ping))
+	if org != nil {
+		next := handler
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(organization.NewContext(r.Context(), org)))
+		})
+	}
+	if c != nil {
+		handler = c.Handler(tier, raisable)(handler)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestAdmitShedsLowestTierFirst(t *testing.T) {
+	tests := []struct {
+		inflight int64
+		tier     Tier
+		expected int
+	}{
+		{inflight: 7, tier: Low, expected: http.StatusOK},
+		{inflight: 8, tier: Low, expected: http.StatusServiceUnavailable},
+		{inflight: 8, tier: Normal, expected: http.StatusOK},
+		{inflight: 9, tier: Normal, expected: http.StatusServiceUnavailable},
+		{inflight: 9, tier: High, expected: http.StatusOK},
+		{inflight: 10, tier: High, expected: http.StatusServiceUnavailable},
+		{inflight: 100, tier: Critical, expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		c := newFixedController(t, 10)
+		atomic.StoreInt64(&c.inflight, tt.inflight)
+
+		w := serve(c, tt.tier, false, nil)
+		assert.Equal(t, tt.expected, w.Code, "tier %s with %d requests in flight", tt.tier, tt.inflight)
+		assert.Equal(t, tt.inflight, c.Inflight())
+	}
+}
+
+func TestAdmitRejectedRequest(t *testing.T) {
+	c := newFixedController(t, 10)
+	atomic.StoreInt64(&c.inflight, 10)
+
+	w := serve(c, Normal, false, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestAdmitOrganizationPriority(t *testing.T) {
+	c := newFixedController(t, 10)
+	atomic.StoreInt64(&c.inflight, 9)
+
+	w := serve(c, Low, true, &organization.OrganizationConfig{Priority: 3})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(c, Low, true, &organization.OrganizationConfig{})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = serve(c, High, true, &organization.OrganizationConfig{Priority: 1})
+	assert.Equal(t, http.StatusOK, w.Code, "the organization priority only raises the tier of the API")
+
+	w = serve(c, Low, false, &organization.OrganizationConfig{Priority: 3})
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "the API does not use the organizations")
+}
+
+func TestAdmitShedsBeforeThePlugins(t *testing.T) {
+	c := newFixedController(t, 10)
+	atomic.StoreInt64(&c.inflight, 10)
+
+	var plugins int
+	handler := c.Handler(Normal, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plugins++
+		Raise(http.HandlerFunc(test.Ping)).ServeHTTP(w, r)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, 0, plugins)
+
+	atomic.StoreInt64(&c.inflight, 0)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, plugins)
+}
+
+func TestAdmitWithoutController(t *testing.T) {
+	w := serve(nil, Low, false, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestControllerAdaptsToAdmittedRequests(t *testing.T) {
+	c, err := NewController(config.Admission{
+		Algorithm:        AIMD,
+		InitialLimit:     20,
+		MinLimit:         10,
+		MaxLimit:         100,
+		LatencyThreshold: time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	slow := c.Handler(Normal, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	slow.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 18, c.Limit())
+
+	// rejected requests are not samples of the latency
+	atomic.StoreInt64(&c.inflight, 100)
+	slow.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 18, c.Limit())
+}
+
+func TestNewControllerInvalidAlgorithm(t *testing.T) {
+	_, err := NewController(config.Admission{Algorithm: "vegas"})
+	assert.Equal(t, ErrInvalidAlgorithm, err)
+}
+
+func TestParseTier(t *testing.T) {
+	tier, err := ParseTier("")
+	assert.NoError(t, err)
+	assert.Equal(t, Normal, tier)
+
+	tier, err = ParseTier("High")
+	assert.NoError(t, err)
+	assert.Equal(t, High, tier)
+
+	_, err = ParseTier("urgent")
+	assert.Error(t, err)
+}
+
+func TestTierFromPriority(t *testing.T) {
+	_, ok := TierFromPriority(0)
+	assert.False(t, ok)
+
+	tier, ok := TierFromPriority(1)
+	assert.True(t, ok)
+	assert.Equal(t, Low, tier)
+
+	tier, ok = TierFromPriority(10)
+	assert.True(t, ok)
+	assert.Equal(t, Critical, tier)
+}
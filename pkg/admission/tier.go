@@ -0,0 +1,75 @@
+package admission
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tier is the priority of a request, the lower tiers are shed first when Janus is saturated
+type Tier int
+
+const (
+	// Low tier requests are shed first
+	Low Tier = iota + 1
+	// Normal is the tier of the requests without priority
+	Normal
+	// High tier requests are shed only once the limit is reached
+	High
+	// Critical tier requests are never shed
+	Critical
+)
+
+// tierNames are the names of the tiers used in the API definitions
+var tierNames = map[string]Tier{
+	"low":      Low,
+	"normal":   Normal,
+	"high":     High,
+	"critical": Critical,
+}
+
+// ParseTier parses the name of a tier, an empty name being the Normal tier
+func ParseTier(name string) (Tier, error) {
+	if name == "" {
+		return Normal, nil
+	}
+
+	tier, ok := tierNames[strings.ToLower(name)]
+	if !ok {
+		return Normal, fmt.Errorf("unknown priority %q", name)
+	}
+	return tier, nil
+}
+
+// TierFromPriority converts the priority of an organization to a tier,
+// 1 being Low and 4 or more Critical. Zero means the organization has no priority.
+func TierFromPriority(priority int) (Tier, bool) {
+	switch {
+	case priority <= 0:
+		return 0, false
+	case priority > int(Critical):
+		return Critical, true
+	default:
+		return Tier(priority), true
+	}
+}
+
+// share is the part of the concurrency limit the requests of the tier can use
+func (t Tier) share() float64 {
+	switch t {
+	case Low:
+		return 0.8
+	case Normal:
+		return 0.9
+	default:
+		return 1
+	}
+}
+
+func (t Tier) String() string {
+	for name, tier := range tierNames {
+		if tier == t {
+			return name
+		}
+	}
+	return "unknown"
+}
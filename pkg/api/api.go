@@ -19,6 +19,7 @@ type Plugin struct {
 type Definition struct {
 	Name        string            `bson:"name" json:"name" valid:"required~name is required,matches(^[A-Za-z0-9]+(?:-[A-Za-z0-9]+)*$)~name cannot contain non-URL friendly characters"`
 	Active      bool              `bson:"active" json:"active"`
+	Priority    string            `bson:"priority" json:"priority" valid:"in(low|normal|high|critical)~priority must be one of low|normal|high|critical"`
 	Proxy       *proxy.Definition `bson:"proxy" json:"proxy" valid:"required"`
 	Plugins     []Plugin          `bson:"plugins" json:"plugins"`
 	HealthCheck HealthCheck       `bson:"health_check" json:"health_check"`
@@ -40,6 +41,28 @@ func (c *Configuration) EqualsTo(c1 *Configuration) bool {
 	return reflect.DeepEqual(c, c1)
 }
 
+// Conflict returns the error of a definition that can't be added next to the ones of the configuration,
+// because its name or its route is already taken
+func (c *Configuration) Conflict(def *Definition) error {
+	for _, stored := range c.Definitions {
+		if stored.Name == def.Name {
+			return ErrAPINameExists
+		}
+
+		if SameRoute(stored, def) {
+			return ErrAPIListenPathExists
+		}
+	}
+
+	return nil
+}
+
+// SameRoute checks whether the definitions share a listen path and a host without match rules telling them apart
+func SameRoute(a, b *Definition) bool {
+	return a.Proxy.ListenPath == b.Proxy.ListenPath && proxy.ShareHost(a.Proxy.Hosts, b.Proxy.Hosts) &&
+		a.Proxy.Match.Equal(b.Proxy.Match)
+}
+
 // ConfigurationChanged is the message that is sent when a database configuration has changed
 type ConfigurationChanged struct {
 	Configurations *Configuration
@@ -74,7 +97,11 @@ func NewDefinition() *Definition {
 
 // Validate validates proxy data
 func (d *Definition) Validate() (bool, error) {
-	return govalidator.ValidateStruct(d)
+	if ok, err := govalidator.ValidateStruct(d); !ok {
+		return ok, err
+	}
+
+	return d.Proxy.Validate()
 }
 
 // UnmarshalJSON api.Definition JSON.Unmarshaller implementation
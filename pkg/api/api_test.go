@@ -67,6 +67,35 @@ func TestNameValidation(t *testing.T) {
 	require.False(t, isValid)
 }
 
+func TestConfiguration_Conflict(t *testing.T) {
+	stored := api.NewDefinition()
+	stored.Name = "recipes"
+	stored.Proxy.ListenPath = "/recipes"
+	stored.Proxy.Hosts = []string{"api.example.com"}
+	cfg := &api.Configuration{Definitions: []*api.Definition{stored}}
+
+	def := api.NewDefinition()
+	def.Name = "recipes"
+	def.Proxy.ListenPath = "/menus"
+	assert.Equal(t, api.ErrAPINameExists, cfg.Conflict(def))
+
+	def.Name = "menus"
+	assert.NoError(t, cfg.Conflict(def))
+
+	def.Proxy.ListenPath = "/recipes"
+	assert.NoError(t, cfg.Conflict(def), "the hosts tell the routes apart")
+
+	def.Proxy.Hosts = []string{"API.example.com"}
+	assert.Equal(t, api.ErrAPIListenPathExists, cfg.Conflict(def))
+
+	stored.Proxy.Match = proxy.Match{Headers: []proxy.MatchRule{{Name: "X-Beta"}, {Name: "Accept", Value: "application/json"}}}
+	def.Proxy.Match = proxy.Match{Headers: []proxy.MatchRule{{Name: "accept", Value: "application/json"}, {Name: "X-Beta"}}, Priority: 1}
+	assert.Equal(t, api.ErrAPIListenPathExists, cfg.Conflict(def), "the order and priority of the rules don't tell the routes apart")
+
+	def.Proxy.Match.Headers = def.Proxy.Match.Headers[:1]
+	assert.NoError(t, cfg.Conflict(def))
+}
+
 func TestConfiguration_EqualsTo(t *testing.T) {
 	def11 := api.NewDefinition()
 	def12 := api.NewDefinition()
@@ -1208,3 +1237,19 @@ const (
     }
 }`
 )
+
+func TestPriorityValidation(t *testing.T) {
+	instance := api.NewDefinition()
+	instance.Name = "priority"
+	instance.Proxy.ListenPath = "/"
+
+	instance.Priority = "high"
+	isValid, err := instance.Validate()
+	require.NoError(t, err)
+	assert.True(t, isValid)
+
+	instance.Priority = "urgent"
+	isValid, err = instance.Validate()
+	assert.Error(t, err)
+	assert.False(t, isValid)
+}
@@ -153,7 +153,11 @@ func (r *CassandraRepository) FindAll() ([]*Definition, error) {
 	return results, err
 }
 
-// Add adds an API definition to the repository
+// Add adds an API definition to the repository, or updates the one with the same name
+func (r *CassandraRepository) Add(definition *Definition) error {
+	return r.add(definition)
+}
+
 func (r *CassandraRepository) add(definition *Definition) error {
 	log.Debugf("adding: %s", definition.Name)
 
@@ -148,7 +148,11 @@ func (r *MongoRepository) FindAll() ([]*Definition, error) {
 	return result, cur.Err()
 }
 
-// Add adds an API definition to the repository
+// Add adds an API definition to the repository, or updates the one with the same name
+func (r *MongoRepository) Add(definition *Definition) error {
+	return r.add(definition)
+}
+
 func (r *MongoRepository) add(definition *Definition) error {
 	isValid, err := definition.Validate()
 	if false == isValid && err != nil {
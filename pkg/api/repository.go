@@ -23,6 +23,11 @@ type Repository interface {
 	FindAll() ([]*Definition, error)
 }
 
+// Writer defines how definitions are stored in a repository
+type Writer interface {
+	Add(definition *Definition) error
+}
+
 // Watcher defines how a provider should watch for changes on configurations
 type Watcher interface {
 	Watch(ctx context.Context, cfgChan chan<- ConfigurationChanged)
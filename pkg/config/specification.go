@@ -27,6 +27,9 @@ type Specification struct {
 	TLS                  TLS
 	Cluster              Cluster
 	RespondingTimeouts   RespondingTimeouts
+	PasswordHash         PasswordHash
+	Quota                Quota
+	Admission            Admission
 }
 
 // Cluster represents the cluster configuration
@@ -41,6 +44,45 @@ type RespondingTimeouts struct {
 	IdleTimeout  time.Duration `envconfig:"RESPONDING_TIMEOUTS_IDLE_TIMEOUT"`
 }
 
+// PasswordHash represents the hashing configuration for the credentials of the auth plugins
+type PasswordHash struct {
+	// Algorithm used for new hashes, one of argon2id, scrypt or bcrypt.
+	// Hashes generated with other algorithms or parameters are upgraded on successful login.
+	Algorithm     string `envconfig:"PASSWORD_HASH_ALGORITHM"`
+	Argon2Time    uint32 `envconfig:"PASSWORD_HASH_ARGON2_TIME"`
+	Argon2Memory  uint32 `envconfig:"PASSWORD_HASH_ARGON2_MEMORY"`
+	Argon2Threads uint8  `envconfig:"PASSWORD_HASH_ARGON2_THREADS"`
+	ScryptCost    int    `envconfig:"PASSWORD_HASH_SCRYPT_COST"`
+	BcryptCost    int    `envconfig:"PASSWORD_HASH_BCRYPT_COST"`
+}
+
+// Quota represents the configuration of the organization quotas
+type Quota struct {
+	// Policy is where the usage is counted, one of cassandra, redis or local.
+	// Defaults to cassandra when the Cassandra database is used, local otherwise.
+	Policy      string `envconfig:"QUOTA_POLICY"`
+	RedisDSN    string `envconfig:"QUOTA_REDIS_DSN"`
+	RedisPrefix string `envconfig:"QUOTA_REDIS_PREFIX"`
+	// Timezone of the calendar days and months
+	Timezone string `envconfig:"QUOTA_TIMEZONE"`
+	// WarningThreshold is the percentage of a quota an organization has to use for a warning to be emitted
+	WarningThreshold int `envconfig:"QUOTA_WARNING_THRESHOLD"`
+}
+
+// Admission represents the configuration of the global concurrency limiter shedding low priority traffic
+type Admission struct {
+	Enabled bool `envconfig:"ADMISSION_ENABLED"`
+	// Algorithm adapting the concurrency limit, one of gradient or aimd
+	Algorithm    string `envconfig:"ADMISSION_ALGORITHM"`
+	InitialLimit int    `envconfig:"ADMISSION_INITIAL_LIMIT"`
+	MinLimit     int    `envconfig:"ADMISSION_MIN_LIMIT"`
+	MaxLimit     int    `envconfig:"ADMISSION_MAX_LIMIT"`
+	// LatencyThreshold is the latency above which the aimd algorithm decreases the limit
+	LatencyThreshold time.Duration `envconfig:"ADMISSION_LATENCY_THRESHOLD"`
+	// RetryAfter is sent to the clients of the rejected requests
+	RetryAfter time.Duration `envconfig:"ADMISSION_RETRY_AFTER"`
+}
+
 // Web represents the API configurations
 type Web struct {
 	Port        int `envconfig:"API_PORT"`
@@ -156,6 +198,19 @@ func init() {
 	viper.SetDefault("tracing.debugTraceKey", "")
 	viper.SetDefault("tracing.isPublicEndpoint", true)
 
+	viper.SetDefault("passwordHash.algorithm", "argon2id")
+
+	viper.SetDefault("quota.redisPrefix", "quota")
+	viper.SetDefault("quota.timezone", "UTC")
+	viper.SetDefault("quota.warningThreshold", 80)
+
+	viper.SetDefault("admission.algorithm", "gradient")
+	viper.SetDefault("admission.initialLimit", 100)
+	viper.SetDefault("admission.minLimit", 10)
+	viper.SetDefault("admission.maxLimit", 1000)
+	viper.SetDefault("admission.latencyThreshold", time.Second)
+	viper.SetDefault("admission.retryAfter", time.Second)
+
 	logging.InitDefaults(viper.GetViper(), "log")
 }
 
@@ -3,6 +3,7 @@ Package errors provides a nice way of handling http errors
 
 Examples:
 To create an error:
+
 	err := errors.New(http.StatusBadRequest, "Something went wrong")
 */
 package errors
@@ -30,11 +31,13 @@ var (
 type Error struct {
 	Code    int    `json:"-"`
 	Message string `json:"error"`
+	// Details describe what went wrong to the client, such as the invalid fields of a request
+	Details interface{} `json:"details,omitempty"`
 }
 
 // New creates a new instance of Error
 func New(code int, message string) *Error {
-	return &Error{code, message}
+	return &Error{Code: code, Message: message}
 }
 
 func (e *Error) Error() string {
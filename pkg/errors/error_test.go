@@ -19,6 +19,18 @@ func TestErrorWithCustomError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestErrorWithDetails(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/hello/test", nil)
+
+	Handler(w, r, New(http.StatusBadRequest, "invalid request"))
+	assert.JSONEq(t, `{"error": "invalid request"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	Handler(w, r, &Error{Code: http.StatusBadRequest, Message: "invalid request", Details: []string{"name is required"}})
+	assert.JSONEq(t, `{"error": "invalid request", "details": ["name is required"]}`, w.Body.String())
+}
+
 func TestErrorWithDefaultError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest(http.MethodGet, "/hello/test", nil)
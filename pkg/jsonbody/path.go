@@ -0,0 +1,180 @@
+// Package jsonbody provides the transformations of JSON bodies, with the fields addressed by paths
+// such as `data.items[0].name`, `items[*].id` or `$` for the whole body.
+package jsonbody
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	keySegment segmentKind = iota
+	indexSegment
+	wildcardSegment
+)
+
+type segment struct {
+	kind  segmentKind
+	key   string
+	index int
+}
+
+// Path is a parsed path to values of a JSON document
+type Path []segment
+
+// UpdateFunc receives the value at a path, and whether it exists.
+// It returns the new value, and false to delete it.
+type UpdateFunc func(value interface{}, exists bool) (interface{}, bool)
+
+// ParsePath parses a path made of object keys separated by dots, array indexes such as `[0]`
+// and wildcards `[*]` that match all the elements of an array
+func ParsePath(s string) (Path, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "$"), ".")
+	if s == "" {
+		return Path{}, nil
+	}
+
+	var path Path
+	for _, part := range strings.Split(s, ".") {
+		key := part
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			key = part[:i]
+		}
+		if key != "" {
+			path = append(path, segment{kind: keySegment, key: key})
+		}
+
+		rest := part[len(key):]
+		if key == "" && rest == "" {
+			return nil, fmt.Errorf("empty key in path %q", s)
+		}
+
+		for rest != "" {
+			end := strings.IndexByte(rest, ']')
+			if rest[0] != '[' || end < 0 {
+				return nil, fmt.Errorf("invalid array index in path %q", s)
+			}
+
+			index := rest[1:end]
+			if index == "*" {
+				path = append(path, segment{kind: wildcardSegment})
+			} else {
+				n, err := strconv.Atoi(index)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid array index %q in path %q", index, s)
+				}
+				path = append(path, segment{kind: indexSegment, index: n})
+			}
+			rest = rest[end+1:]
+		}
+	}
+
+	return path, nil
+}
+
+// Get returns the value at the path, or the values matched by the wildcards of the path
+func (p Path) Get(doc interface{}) (interface{}, bool) {
+	var values []interface{}
+	p.Update(doc, false, func(value interface{}, exists bool) (interface{}, bool) {
+		if exists {
+			values = append(values, value)
+		}
+		return value, exists
+	})
+
+	if !p.hasWildcard() {
+		if len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	}
+	return values, len(values) > 0
+}
+
+// Update calls fn with the values at the path and replaces them with the values it returns.
+// The missing objects on the way are created when create is true, fn is called at the end of the
+// existing ones otherwise. It returns the updated document, which is nil when the whole document was deleted.
+func (p Path) Update(doc interface{}, create bool, fn UpdateFunc) interface{} {
+	value, keep := update(p, doc, true, create, fn)
+	if !keep {
+		return nil
+	}
+	return value
+}
+
+// Parent splits the path into the path of the object holding the value and the key of the value
+func (p Path) Parent() (Path, string, error) {
+	if len(p) == 0 || p[len(p)-1].kind != keySegment {
+		return nil, "", errors.New("path does not end with an object key")
+	}
+	return p[:len(p)-1], p[len(p)-1].key, nil
+}
+
+func (p Path) hasWildcard() bool {
+	for _, seg := range p {
+		if seg.kind == wildcardSegment {
+			return true
+		}
+	}
+	return false
+}
+
+func update(path Path, node interface{}, exists bool, create bool, fn UpdateFunc) (interface{}, bool) {
+	if len(path) == 0 {
+		return fn(node, exists)
+	}
+
+	seg := path[0]
+	switch seg.kind {
+	case keySegment:
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			if !create || (exists && node != nil) {
+				return node, exists
+			}
+			obj = make(map[string]interface{})
+		}
+
+		child, childExists := obj[seg.key]
+		if !childExists && !create && len(path) > 1 {
+			return obj, true
+		}
+
+		value, keep := update(path[1:], child, childExists, create, fn)
+		if keep {
+			obj[seg.key] = value
+		} else {
+			delete(obj, seg.key)
+		}
+		return obj, true
+	case indexSegment:
+		arr, ok := node.([]interface{})
+		if !ok || seg.index >= len(arr) {
+			return node, exists
+		}
+
+		value, keep := update(path[1:], arr[seg.index], true, create, fn)
+		if keep {
+			arr[seg.index] = value
+			return arr, true
+		}
+		return append(arr[:seg.index:seg.index], arr[seg.index+1:]...), true
+	default:
+		arr, ok := node.([]interface{})
+		if !ok {
+			return node, exists
+		}
+
+		result := make([]interface{}, 0, len(arr))
+		for _, elem := range arr {
+			if value, keep := update(path[1:], elem, true, create, fn); keep {
+				result = append(result, value)
+			}
+		}
+		return result, true
+	}
+}
@@ -0,0 +1,94 @@
+package jsonbody
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+
+	var doc interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &doc))
+	return doc
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath("data.items[0].tags[*]")
+	require.NoError(t, err)
+	assert.Equal(t, Path{
+		{kind: keySegment, key: "data"},
+		{kind: keySegment, key: "items"},
+		{kind: indexSegment, index: 0},
+		{kind: keySegment, key: "tags"},
+		{kind: wildcardSegment},
+	}, path)
+
+	path, err = ParsePath("$")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	for _, invalid := range []string{"a..b", "items[", "items[-1]", "items[x]", "items]0["} {
+		_, err := ParsePath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPathGet(t *testing.T) {
+	doc := decode(t, `{"data":{"items":[{"id":1},{"id":2},{"name":"c"}]}}`)
+
+	tests := []struct {
+		path     string
+		expected interface{}
+		found    bool
+	}{
+		{path: "data.items[1].id", expected: float64(2), found: true},
+		{path: "data.items[*].id", expected: []interface{}{float64(1), float64(2)}, found: true},
+		{path: "data.items[5].id", found: false},
+		{path: "data.missing.id", found: false},
+	}
+
+	for _, tt := range tests {
+		path, err := ParsePath(tt.path)
+		require.NoError(t, err)
+
+		value, found := path.Get(doc)
+		assert.Equal(t, tt.found, found, tt.path)
+		if tt.found {
+			assert.Equal(t, tt.expected, value, tt.path)
+		}
+	}
+}
+
+func TestPathUpdate(t *testing.T) {
+	set := func(interface{}, bool) (interface{}, bool) { return "x", true }
+	remove := func(interface{}, bool) (interface{}, bool) { return nil, false }
+
+	tests := []struct {
+		doc      string
+		path     string
+		create   bool
+		fn       UpdateFunc
+		expected string
+	}{
+		{doc: `{}`, path: "a.b", create: true, fn: set, expected: `{"a":{"b":"x"}}`},
+		{doc: `{}`, path: "a.b", create: false, fn: set, expected: `{}`},
+		{doc: `{"a":[{"b":1},{"b":2}]}`, path: "a[*].b", fn: set, expected: `{"a":[{"b":"x"},{"b":"x"}]}`},
+		{doc: `{"a":[1,2,3]}`, path: "a[1]", fn: remove, expected: `{"a":[1,3]}`},
+		{doc: `{"a":{"b":1,"c":2}}`, path: "a.b", fn: remove, expected: `{"a":{"c":2}}`},
+		{doc: `{"a":"scalar"}`, path: "a.b", create: true, fn: set, expected: `{"a":"scalar"}`},
+		{doc: `[1,2]`, path: "$", fn: set, expected: `"x"`},
+	}
+
+	for _, tt := range tests {
+		path, err := ParsePath(tt.path)
+		require.NoError(t, err)
+
+		result, err := json.Marshal(path.Update(decode(t, tt.doc), tt.create, tt.fn))
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.expected, string(result), "%s on %s", tt.path, tt.doc)
+	}
+}
@@ -0,0 +1,294 @@
+package jsonbody
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/Knetic/govaluate"
+	log "github.com/sirupsen/logrus"
+)
+
+// Operations are the transformations of a JSON body, applied in the order
+// remove, rename, replace, add, append, filter and envelope
+type Operations struct {
+	// Remove are the paths of the fields to remove
+	Remove []string
+	// Rename maps the paths of the fields to their new key
+	Rename map[string]string
+	// Replace sets the fields at the paths, only if they exist
+	Replace map[string]interface{}
+	// Add sets the fields at the paths, only if they don't exist
+	Add map[string]interface{}
+	// Append adds the values to the arrays at the paths, creating them when missing
+	Append map[string]interface{}
+	Filter []Filter
+	// Envelope is the key of the object the body is wrapped in
+	Envelope string
+}
+
+// Filter keeps the elements of the array at the path for which the predicate is true.
+// The predicate has access to the fields of the elements, and to the elements themselves as `value`.
+type Filter struct {
+	Path      string `json:"path"`
+	Predicate string `json:"predicate"`
+}
+
+type pathValue struct {
+	path  Path
+	value interface{}
+}
+
+type rename struct {
+	parent Path
+	from   string
+	to     string
+}
+
+type filter struct {
+	path       Path
+	expression *govaluate.EvaluableExpression
+}
+
+// Resolver returns the value set in the body for a value of the operations,
+// such as the result of a template for the current request
+type Resolver func(value interface{}) interface{}
+
+// Transformer applies the operations to JSON bodies
+type Transformer struct {
+	remove   []Path
+	rename   []rename
+	replace  []pathValue
+	add      []pathValue
+	append   []pathValue
+	filter   []filter
+	envelope string
+}
+
+// NewTransformer parses the paths and the predicates of the operations
+func NewTransformer(ops Operations) (*Transformer, error) {
+	t := &Transformer{envelope: ops.Envelope}
+
+	for _, s := range ops.Remove {
+		path, err := ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		t.remove = append(t.remove, path)
+	}
+
+	for _, s := range sortedKeys(ops.Rename) {
+		path, err := ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		parent, key, err := path.Parent()
+		if err != nil {
+			return nil, fmt.Errorf("could not rename %q: %w", s, err)
+		}
+		t.rename = append(t.rename, rename{parent: parent, from: key, to: ops.Rename[s]})
+	}
+
+	var err error
+	if t.replace, err = pathValues(ops.Replace); err != nil {
+		return nil, err
+	}
+	if t.add, err = pathValues(ops.Add); err != nil {
+		return nil, err
+	}
+	if t.append, err = pathValues(ops.Append); err != nil {
+		return nil, err
+	}
+
+	for _, f := range ops.Filter {
+		path, err := ParsePath(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := govaluate.NewEvaluableExpression(f.Predicate)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the predicate of the %q filter: %w", f.Path, err)
+		}
+		t.filter = append(t.filter, filter{path: path, expression: expression})
+	}
+
+	return t, nil
+}
+
+// Empty checks whether there is no operation to apply
+func (t *Transformer) Empty() bool {
+	return len(t.remove) == 0 && len(t.rename) == 0 && len(t.replace) == 0 && len(t.add) == 0 &&
+		len(t.append) == 0 && len(t.filter) == 0 && t.envelope == ""
+}
+
+// Transform applies the operations to the JSON body
+func (t *Transformer) Transform(body []byte) ([]byte, error) {
+	return t.TransformWith(body, nil)
+}
+
+// TransformWith applies the operations to the JSON body, with the values of the operations resolved by resolve
+func (t *Transformer) TransformWith(body []byte, resolve Resolver) ([]byte, error) {
+	doc, err := decodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(t.TransformValue(doc, resolve))
+}
+
+// decodeBody keeps the numbers of the body as json.Number, so that the integers too big for a float64 are left untouched
+func decodeBody(body []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after the top-level JSON value")
+	}
+	return doc, nil
+}
+
+// TransformValue applies the operations to a decoded JSON document, resolve can be nil to use the values as they are
+func (t *Transformer) TransformValue(doc interface{}, resolve Resolver) interface{} {
+	if resolve == nil {
+		resolve = func(value interface{}) interface{} { return value }
+	}
+
+	for _, path := range t.remove {
+		doc = path.Update(doc, false, func(interface{}, bool) (interface{}, bool) {
+			return nil, false
+		})
+	}
+
+	for _, r := range t.rename {
+		doc = r.parent.Update(doc, false, func(value interface{}, exists bool) (interface{}, bool) {
+			if obj, ok := value.(map[string]interface{}); ok {
+				if v, found := obj[r.from]; found {
+					delete(obj, r.from)
+					obj[r.to] = v
+				}
+			}
+			return value, exists
+		})
+	}
+
+	for _, pv := range t.replace {
+		value := resolve(pv.value)
+		doc = pv.path.Update(doc, false, func(old interface{}, exists bool) (interface{}, bool) {
+			if !exists {
+				return old, false
+			}
+			return value, true
+		})
+	}
+
+	for _, pv := range t.add {
+		value := resolve(pv.value)
+		doc = pv.path.Update(doc, true, func(old interface{}, exists bool) (interface{}, bool) {
+			if exists {
+				return old, true
+			}
+			return value, true
+		})
+	}
+
+	for _, pv := range t.append {
+		value := resolve(pv.value)
+		doc = pv.path.Update(doc, true, func(old interface{}, exists bool) (interface{}, bool) {
+			switch arr := old.(type) {
+			case []interface{}:
+				return append(arr, value), true
+			case nil:
+				return []interface{}{value}, true
+			default:
+				return []interface{}{arr, value}, true
+			}
+		})
+	}
+
+	for _, f := range t.filter {
+		doc = f.path.Update(doc, false, func(value interface{}, exists bool) (interface{}, bool) {
+			arr, ok := value.([]interface{})
+			if !ok {
+				return value, exists
+			}
+
+			result := make([]interface{}, 0, len(arr))
+			for _, elem := range arr {
+				if f.matches(elem) {
+					result = append(result, elem)
+				}
+			}
+			return result, true
+		})
+	}
+
+	if t.envelope != "" {
+		doc = map[string]interface{}{t.envelope: doc}
+	}
+
+	return doc
+}
+
+func (f filter) matches(elem interface{}) bool {
+	params := make(map[string]interface{})
+	if obj, ok := elem.(map[string]interface{}); ok {
+		for k, v := range obj {
+			params[k] = predicateValue(v)
+		}
+	}
+	params["value"] = predicateValue(elem)
+
+	result, err := f.expression.Evaluate(params)
+	if err != nil {
+		log.WithError(err).Debug("Could not evaluate the filter predicate, dropping the element")
+		return false
+	}
+
+	matches, _ := result.(bool)
+	return matches
+}
+
+// predicateValue converts the numbers to float64, the only numbers the predicates can compare
+func predicateValue(value interface{}) interface{} {
+	if n, ok := value.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+func pathValues(values map[string]interface{}) ([]pathValue, error) {
+	result := make([]pathValue, 0, len(values))
+	for _, s := range sortedKeys(values) {
+		path, err := ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, pathValue{path: path, value: values[s]})
+	}
+	return result, nil
+}
+
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]string:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]interface{}:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
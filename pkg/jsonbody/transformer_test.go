@@ -0,0 +1,111 @@
+package jsonbody
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformer(t *testing.T) {
+	tests := []struct {
+		scenario string
+		ops      Operations
+		body     string
+		expected string
+	}{
+		{
+			scenario: "remove",
+			ops:      Operations{Remove: []string{"password", "items[*].internal"}},
+			body:     `{"name":"a","password":"b","items":[{"id":1,"internal":true}]}`,
+			expected: `{"name":"a","items":[{"id":1}]}`,
+		},
+		{
+			scenario: "rename",
+			ops:      Operations{Rename: map[string]string{"items[*].ID": "id", "missing": "other"}},
+			body:     `{"items":[{"ID":1},{"ID":2}]}`,
+			expected: `{"items":[{"id":1},{"id":2}]}`,
+		},
+		{
+			scenario: "replace only existing fields",
+			ops:      Operations{Replace: map[string]interface{}{"status": "ok", "missing": "value"}},
+			body:     `{"status":"up"}`,
+			expected: `{"status":"ok"}`,
+		},
+		{
+			scenario: "add only missing fields",
+			ops:      Operations{Add: map[string]interface{}{"status": "ok", "meta.version": 2}},
+			body:     `{"status":"up"}`,
+			expected: `{"status":"up","meta":{"version":2}}`,
+		},
+		{
+			scenario: "append",
+			ops:      Operations{Append: map[string]interface{}{"tags": "new", "links": "self", "name": "b"}},
+			body:     `{"tags":["old"],"name":"a"}`,
+			expected: `{"tags":["old","new"],"links":["self"],"name":["a","b"]}`,
+		},
+		{
+			scenario: "filter",
+			ops:      Operations{Filter: []Filter{{Path: "$", Predicate: "price > 10 && active"}}},
+			body:     `[{"price":5,"active":true},{"price":20,"active":true},{"price":30,"active":false},{"name":"no price"}]`,
+			expected: `[{"price":20,"active":true}]`,
+		},
+		{
+			scenario: "filter scalars",
+			ops:      Operations{Filter: []Filter{{Path: "ids", Predicate: "value >= 2"}}},
+			body:     `{"ids":[1,2,3]}`,
+			expected: `{"ids":[2,3]}`,
+		},
+		{
+			scenario: "envelope",
+			ops:      Operations{Remove: []string{"a"}, Envelope: "data"},
+			body:     `{"a":1,"b":2}`,
+			expected: `{"data":{"b":2}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			transformer, err := NewTransformer(tt.ops)
+			require.NoError(t, err)
+			assert.False(t, transformer.Empty())
+
+			result, err := transformer.Transform([]byte(tt.body))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+func TestTransformerKeepsNumbers(t *testing.T) {
+	transformer, err := NewTransformer(Operations{
+		Remove: []string{"internal"},
+		Filter: []Filter{{Path: "ids", Predicate: "value > 1"}},
+	})
+	require.NoError(t, err)
+
+	result, err := transformer.Transform([]byte(`{"id":9223372036854775807,"price":1.10,"ids":[1,9007199254740993],"internal":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":9223372036854775807,"ids":[9007199254740993],"price":1.10}`, string(result))
+}
+
+func TestTransformerErrors(t *testing.T) {
+	transformer, err := NewTransformer(Operations{})
+	require.NoError(t, err)
+	assert.True(t, transformer.Empty())
+
+	_, err = transformer.Transform([]byte(`{"a":`))
+	assert.Error(t, err)
+	_, err = transformer.Transform([]byte(`{"a":1} {"b":2}`))
+	assert.Error(t, err)
+
+	for _, ops := range []Operations{
+		{Remove: []string{"a["}},
+		{Rename: map[string]string{"items[0]": "id"}},
+		{Add: map[string]interface{}{"a..b": 1}},
+		{Filter: []Filter{{Path: "items", Predicate: "(("}}},
+	} {
+		_, err := NewTransformer(ops)
+		assert.Error(t, err)
+	}
+}
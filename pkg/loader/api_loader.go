@@ -1,6 +1,9 @@
 package loader
 
 import (
+	"time"
+
+	"github.com/hellofresh/janus/pkg/admission"
 	"github.com/hellofresh/janus/pkg/api"
 	"github.com/hellofresh/janus/pkg/middleware"
 	obs "github.com/hellofresh/janus/pkg/observability"
@@ -10,14 +13,18 @@ import (
 	"go.opencensus.io/tag"
 )
 
+// organizationAuthPlugin is the plugin setting the organization of the requests, which may raise their priority
+const organizationAuthPlugin = "organization_auth"
+
 // APILoader is responsible for loading all apis form a datastore and configure them in a register
 type APILoader struct {
-	register *proxy.Register
+	register  *proxy.Register
+	admission *admission.Controller
 }
 
-// NewAPILoader creates a new instance of the api manager
-func NewAPILoader(register *proxy.Register) *APILoader {
-	return &APILoader{register: register}
+// NewAPILoader creates a new instance of the api manager, the admission controller is nil when load shedding is disabled
+func NewAPILoader(register *proxy.Register, controller *admission.Controller) *APILoader {
+	return &APILoader{register: register, admission: controller}
 }
 
 // RegisterAPIs load application middleware
@@ -44,6 +51,21 @@ func (m *APILoader) RegisterAPI(def *api.Definition) {
 
 	if active {
 		routerDefinition := proxy.NewRouterDefinition(def.Proxy)
+		routerDefinition.Name = def.Name
+
+		// Admission goes first, so that the requests are shed before the plugins run
+		raisable := false
+		if m.admission != nil {
+			tier, err := admission.ParseTier(def.Priority)
+			if err != nil {
+				logger.WithError(err).Error("Invalid priority, using the normal one")
+			}
+			raisable = usesPlugin(def, organizationAuthPlugin)
+			routerDefinition.AddMiddleware(m.admission.Handler(tier, raisable))
+		}
+
+		// The deadline goes next, so that it covers the retries of the plugins
+		routerDefinition.AddMiddleware(proxy.NewDeadlineMiddleware(time.Duration(def.Proxy.ForwardingTimeouts.RequestTimeout)))
 
 		for _, plg := range def.Plugins {
 			l := logger.WithField("name", plg.Name)
@@ -71,19 +93,34 @@ func (m *APILoader) RegisterAPI(def *api.Definition) {
 			}
 		}
 
-		if len(def.Proxy.Hosts) > 0 {
-			routerDefinition.AddMiddleware(middleware.NewHostMatcher(def.Proxy.Hosts).Handler)
-		}
-
 		// Add middleware to insert tags to context
 		tags := []tag.Mutator{
 			tag.Insert(obs.KeyListenPath, def.Proxy.ListenPath),
 		}
 		routerDefinition.AddMiddleware(middleware.NewStatsTagger(tags).Handler)
 
+		// The deferred requests are admitted once the organization priority is known from the auth plugins
+		if raisable {
+			routerDefinition.AddMiddleware(admission.Raise)
+		}
+
+		if bulkhead := def.Proxy.Bulkhead; bulkhead.MaxConcurrent > 0 {
+			routerDefinition.AddMiddleware(middleware.NewBulkhead(bulkhead.MaxConcurrent, bulkhead.MaxQueue, time.Duration(bulkhead.QueueTimeout)).Handler)
+		}
+
 		m.register.Add(routerDefinition)
 		logger.Debug("API registered")
 	} else {
 		logger.WithError(err).Warn("API URI is invalid or not active, skipping...")
 	}
 }
+
+// usesPlugin checks whether the plugin is enabled for the API
+func usesPlugin(def *api.Definition, name string) bool {
+	for _, plg := range def.Plugins {
+		if plg.Name == name && plg.Enabled {
+			return true
+		}
+	}
+	return false
+}
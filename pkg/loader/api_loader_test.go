@@ -94,7 +94,7 @@ func createRegisterAndRouter(t *testing.T) router.Router {
 	defs, err := proxyRepo.FindAll()
 	require.NoError(t, err)
 
-	loader := NewAPILoader(register)
+	loader := NewAPILoader(register, nil)
 	loader.RegisterAPIs(defs)
 
 	return r
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	obs "github.com/hellofresh/janus/pkg/observability"
+)
+
+const (
+	bulkheadQueueFull    = "queue_full"
+	bulkheadQueueTimeout = "queue_timeout"
+)
+
+// ErrBulkheadFull is used when a request can't get in the bulkhead of its route
+var ErrBulkheadFull = errors.New(http.StatusServiceUnavailable, "too many concurrent requests to the upstream")
+
+// Bulkhead is a middleware capping the number of concurrent requests of a route,
+// so that one slow upstream can't use up all the connections of Janus.
+// The requests over the cap wait in a bounded queue for a request in flight to finish.
+type Bulkhead struct {
+	slots        chan struct{}
+	maxQueue     int64
+	queued       int64
+	queueTimeout time.Duration
+}
+
+// NewBulkhead creates a new instance of Bulkhead
+func NewBulkhead(maxConcurrent int, maxQueue int, queueTimeout time.Duration) *Bulkhead {
+	return &Bulkhead{
+		slots:        make(chan struct{}, maxConcurrent),
+		maxQueue:     int64(maxQueue),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Handler is the middleware function
+func (b *Bulkhead) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case b.slots <- struct{}{}:
+			defer b.release()
+			handler.ServeHTTP(w, r)
+			return
+		default:
+		}
+
+		if reason, ok := b.wait(r.Context()); !ok {
+			if reason != "" {
+				b.reject(w, r, reason)
+			}
+			return
+		}
+
+		defer b.release()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// wait queues the request until it gets a slot, returning the reason of the rejection otherwise.
+// No reason is returned when the client went away.
+func (b *Bulkhead) wait(ctx context.Context) (string, bool) {
+	queued := atomic.AddInt64(&b.queued, 1)
+	defer func() {
+		stats.Record(ctx, obs.MBulkheadQueueDepth.M(atomic.AddInt64(&b.queued, -1)))
+	}()
+
+	if queued > b.maxQueue {
+		return bulkheadQueueFull, false
+	}
+	stats.Record(ctx, obs.MBulkheadQueueDepth.M(queued))
+
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		timer := time.NewTimer(b.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return "", true
+	case <-timeout:
+		return bulkheadQueueTimeout, false
+	case <-ctx.Done():
+		return "", false
+	}
+}
+
+func (b *Bulkhead) release() {
+	<-b.slots
+}
+
+func (b *Bulkhead) reject(w http.ResponseWriter, r *http.Request, reason string) {
+	ctx, err := tag.New(r.Context(), tag.Insert(obs.KeyBulkheadRejection, reason))
+	if err != nil {
+		ctx = r.Context()
+	}
+	stats.Record(ctx, obs.MBulkheadRejected.M(1))
+
+	log.WithFields(log.Fields{
+		"path":   r.URL.Path,
+		"reason": reason,
+	}).Debug("Request rejected by the bulkhead")
+	errors.Handler(w, r, ErrBulkheadFull)
+}
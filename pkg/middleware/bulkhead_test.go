@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingHandler blocks the requests until released
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{started: make(chan struct{}, 10), release: make(chan struct{})}
+}
+
+func (h *blockingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.started <- struct{}{}
+	<-h.release
+	w.WriteHeader(http.StatusOK)
+}
+
+func serveAsync(handler http.Handler, r *http.Request, wg *sync.WaitGroup) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		handler.ServeHTTP(w, r)
+	}()
+	return w
+}
+
+func TestBulkheadRejectsWhenQueueIsFull(t *testing.T) {
+	backend := newBlockingHandler()
+	handler := NewBulkhead(1, 1, 0).Handler(backend)
+
+	var wg sync.WaitGroup
+	first := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/", nil), &wg)
+	<-backend.started
+
+	queued := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/", nil), &wg)
+	time.Sleep(10 * time.Millisecond)
+
+	rejected := httptest.NewRecorder()
+	handler.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Code)
+
+	close(backend.release)
+	wg.Wait()
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, queued.Code)
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	backend := newBlockingHandler()
+	handler := NewBulkhead(1, 1, 10*time.Millisecond).Handler(backend)
+
+	var wg sync.WaitGroup
+	first := serveAsync(handler, httptest.NewRequest(http.MethodGet, "/", nil), &wg)
+	<-backend.started
+
+	timedOut := httptest.NewRecorder()
+	handler.ServeHTTP(timedOut, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, timedOut.Code)
+
+	close(backend.release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+}
+
+func TestBulkheadWithoutQueue(t *testing.T) {
+	backend := newBlockingHandler()
+	handler := NewBulkhead(1, 0, time.Second).Handler(backend)
+
+	var wg sync.WaitGroup
+	serveAsync(handler, httptest.NewRequest(http.MethodGet, "/", nil), &wg)
+	<-backend.started
+
+	rejected := httptest.NewRecorder()
+	handler.ServeHTTP(rejected, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Code)
+
+	close(backend.release)
+	wg.Wait()
+
+	// the slot is given back once the request finished
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestBulkheadClientGoesAway(t *testing.T) {
+	backend := newBlockingHandler()
+	bulkhead := NewBulkhead(1, 1, 0)
+	handler := bulkhead.Handler(backend)
+
+	var wg sync.WaitGroup
+	serveAsync(handler, httptest.NewRequest(http.MethodGet, "/", nil), &wg)
+	<-backend.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	assert.Equal(t, int64(0), bulkhead.queued)
+
+	close(backend.release)
+	wg.Wait()
+}
@@ -32,6 +32,11 @@ var (
 	KeyListenPath, _             = tag.NewKey("path")
 	KeyUpstreamPath, _           = tag.NewKey("upstream_path")
 	KeyJWTValidationErrorType, _ = tag.NewKey("error")
+	KeyConsumer, _               = tag.NewKey("consumer")
+	KeyBulkheadRejection, _      = tag.NewKey("reason")
+	KeyProxyError, _             = tag.NewKey("error")
+	KeyGraphQLOperation, _       = tag.NewKey("operation")
+	KeyGraphQLRejection, _       = tag.NewKey("reason")
 )
 
 // Metrics
@@ -41,6 +46,12 @@ var (
 	MOAuth2MalformedHeader      = stats.Int64("plugin_oauth2_malformed_header_total", "Number of failed oauth2 authentication due to malformed bearer header", dimensionless)
 	MOAuth2Authorized           = stats.Int64("plugin_oauth2_authorized_request_total", "Number of successful and authorized oauth2 authentication", dimensionless)
 	MOAuth2Unauthorized         = stats.Int64("plugin_oauth2_unauthorized_request_total", "Number of successful but unauthorized oauth2 authentication", dimensionless)
+	MBulkheadQueueDepth         = stats.Int64("http_proxy_bulkhead_queue_depth", "Number of requests waiting for the bulkhead of a route", dimensionless)
+	MProxyErrors                = stats.Int64("http_proxy_error_total", "Number of requests that failed to reach the upstreams by error type", dimensionless)
+	MCircuitBreakerState        = stats.Int64("circuit_breaker_state", "State of the circuit breaker of an upstream target, 0 closed, 1 open and 2 half-open", dimensionless)
+	MCircuitBreakerRejected     = stats.Int64("circuit_breaker_rejected_total", "Number of requests rejected by open circuit breakers", dimensionless)
+	MBulkheadRejected           = stats.Int64("http_proxy_bulkhead_rejected_total", "Number of requests rejected by the bulkhead of a route", dimensionless)
+	MGraphQLRejected            = stats.Int64("plugin_graphql_rejected_total", "Number of GraphQL operations rejected by the graphql plugin by reason", dimensionless)
 )
 
 // AllViews aggregates the metrics
@@ -71,6 +82,42 @@ var AllViews = []*view.View{
 		Measure:     MOAuth2Unauthorized,
 		Aggregation: view.Count(),
 	},
+	{
+		Name:        "http_proxy_error_total",
+		TagKeys:     []tag.Key{KeyListenPath, KeyProxyError},
+		Measure:     MProxyErrors,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "circuit_breaker_state",
+		TagKeys:     []tag.Key{KeyListenPath, KeyUpstreamPath},
+		Measure:     MCircuitBreakerState,
+		Aggregation: view.LastValue(),
+	},
+	{
+		Name:        "circuit_breaker_rejected_total",
+		TagKeys:     []tag.Key{KeyListenPath},
+		Measure:     MCircuitBreakerRejected,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "http_proxy_bulkhead_queue_depth",
+		TagKeys:     []tag.Key{KeyListenPath},
+		Measure:     MBulkheadQueueDepth,
+		Aggregation: view.LastValue(),
+	},
+	{
+		Name:        "http_proxy_bulkhead_rejected_total",
+		TagKeys:     []tag.Key{KeyListenPath, KeyBulkheadRejection},
+		Measure:     MBulkheadRejected,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "plugin_graphql_rejected_total",
+		TagKeys:     []tag.Key{KeyListenPath, KeyGraphQLOperation, KeyGraphQLRejection},
+		Measure:     MGraphQLRejected,
+		Aggregation: view.Count(),
+	},
 	{
 		Name:        "http_server_response_count_by_path_code_and_method",
 		TagKeys:     []tag.Key{KeyListenPath, ochttp.StatusCode, ochttp.Method},
@@ -100,4 +147,22 @@ var AllViews = []*view.View{
 		Measure:     ochttp.ClientLatency,
 		Aggregation: ochttp.DefaultLatencyDistribution,
 	},
+	{
+		Name:        "http_proxy_request_count_by_consumer",
+		TagKeys:     []tag.Key{KeyConsumer},
+		Measure:     ochttp.ClientRequestCount,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "http_proxy_request_count_by_graphql_operation",
+		TagKeys:     []tag.Key{KeyListenPath, KeyGraphQLOperation},
+		Measure:     ochttp.ClientRequestCount,
+		Aggregation: view.Count(),
+	},
+	{
+		Name:        "http_proxy_request_latency_by_graphql_operation",
+		TagKeys:     []tag.Key{KeyListenPath, KeyGraphQLOperation},
+		Measure:     ochttp.ClientLatency,
+		Aggregation: ochttp.DefaultLatencyDistribution,
+	},
 }
@@ -0,0 +1,269 @@
+// Package openapi generates API definitions from OpenAPI 3 documents
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+const (
+	pluginBasicAuth = "basic_auth"
+	pluginOAuth2    = "oauth2"
+	pluginValidator = "openapi_validator"
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Options tune the generated definitions
+type Options struct {
+	// Name is the beginning of the names of the definitions, the title of the document by default
+	Name string
+	// ListenPathPrefix is added before the paths of the document, and removed before proxying the requests
+	ListenPathPrefix string
+	// Upstream is the target of the definitions, the first server of the document by default
+	Upstream string
+	// Validate adds the openapi_validator plugin, with the document read from SpecFile. Without SpecFile, every
+	// definition gets the part of the document it needs inline: its path and the components.
+	Validate bool
+	SpecFile string
+	// Auth adds the auth plugins of the security schemes of the operations
+	Auth bool
+	// OAuthServer is the OAuth server of the oauth2 plugins, the name of the security scheme by default
+	OAuthServer string
+}
+
+// Load parses an OpenAPI 3 document, in JSON or YAML, and validates it
+func Load(data []byte) (*openapi3.Swagger, error) {
+	swagger, err := openapi3.NewSwaggerLoader().LoadSwaggerFromData(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the OpenAPI document: %w", err)
+	}
+
+	if err := swagger.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	return swagger, nil
+}
+
+// Import generates a definition for every path of the document, listening on the path with the methods of its operations.
+// The warnings are the parts of the document that could not be converted.
+func Import(swagger *openapi3.Swagger, opts Options) ([]*api.Definition, []string, error) {
+	name := slug(opts.Name)
+	if name == "" && swagger.Info != nil {
+		name = slug(swagger.Info.Title)
+	}
+	if name == "" {
+		return nil, nil, fmt.Errorf("a name is required when the document has no title")
+	}
+
+	upstream, err := upstream(swagger, opts.Upstream)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefix := strings.TrimRight(opts.ListenPathPrefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		return nil, nil, fmt.Errorf("the listen path prefix must begin with '/'")
+	}
+
+	paths := make([]string, 0, len(swagger.Paths))
+	for path := range swagger.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var (
+		definitions []*api.Definition
+		warnings    []string
+		imported    = &api.Configuration{}
+		used        = make(map[string]bool)
+	)
+	for _, path := range paths {
+		operations := swagger.Paths[path].Operations()
+		if len(operations) == 0 {
+			continue
+		}
+
+		def := api.NewDefinition()
+		def.Name = uniqueName(used, name, path)
+		def.Proxy.ListenPath = prefix + path
+		def.Proxy.Upstreams = &proxy.Upstreams{
+			Balancing: "roundrobin",
+			Targets:   proxy.Targets{{Target: upstream}},
+		}
+		if prefix != "" {
+			def.Proxy.Rewrite = []proxy.RewriteRule{{Prefix: prefix, To: "/"}}
+		}
+
+		def.Proxy.Methods = make([]string, 0, len(operations))
+		for method := range operations {
+			def.Proxy.Methods = append(def.Proxy.Methods, method)
+		}
+		sort.Strings(def.Proxy.Methods)
+
+		if opts.Validate {
+			validator, err := validatorPlugin(swagger, path, opts.SpecFile, prefix)
+			if err != nil {
+				return nil, nil, err
+			}
+			def.Plugins = append(def.Plugins, validator)
+		}
+		if opts.Auth {
+			plugins, pathWarnings := authPlugins(swagger, operations, opts.OAuthServer)
+			def.Plugins = append(def.Plugins, plugins...)
+			for _, warning := range pathWarnings {
+				warnings = append(warnings, fmt.Sprintf("%s: %s", path, warning))
+			}
+		}
+
+		if ok, err := def.Validate(); !ok {
+			return nil, nil, fmt.Errorf("invalid definition for %s: %w", path, err)
+		}
+		if err := imported.Conflict(def); err != nil {
+			return nil, nil, fmt.Errorf("conflicting definition for %s: %w", path, err)
+		}
+		imported.Definitions = append(imported.Definitions, def)
+		definitions = append(definitions, def)
+	}
+
+	return definitions, warnings, nil
+}
+
+// upstream returns the URL of the first server, with the default values of its variables
+func upstream(swagger *openapi3.Swagger, override string) (string, error) {
+	target := override
+	if target == "" {
+		if len(swagger.Servers) == 0 {
+			return "", fmt.Errorf("an upstream is required when the document has no servers")
+		}
+
+		server := swagger.Servers[0]
+		target = server.URL
+		for name, variable := range server.Variables {
+			target = strings.Replace(target, "{"+name+"}", fmt.Sprint(variable.Default), -1)
+		}
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("the upstream %q must be an absolute URL", target)
+	}
+
+	return target, nil
+}
+
+// validatorPlugin returns the openapi_validator plugin of the path, reading the document from the file when given.
+// Otherwise the document is inline, with only the path, so that the definitions don't all carry the whole of it.
+func validatorPlugin(swagger *openapi3.Swagger, path, file, prefix string) (api.Plugin, error) {
+	config := map[string]interface{}{}
+	if file != "" {
+		config["file"] = file
+	} else {
+		doc := *swagger
+		doc.Paths = openapi3.Paths{path: swagger.Paths[path]}
+		spec, err := json.Marshal(&doc)
+		if err != nil {
+			return api.Plugin{}, fmt.Errorf("could not write the document of %s: %w", path, err)
+		}
+		config["spec"] = string(spec)
+	}
+	if prefix != "" {
+		config["base_path"] = prefix
+	}
+
+	return api.Plugin{Name: pluginValidator, Enabled: true, Config: config}, nil
+}
+
+// authPlugins returns the plugins of the security schemes the operations require,
+// the ones of the document when the operations don't have any
+func authPlugins(swagger *openapi3.Swagger, operations map[string]*openapi3.Operation, oauthServer string) ([]api.Plugin, []string) {
+	schemes := make(map[string]bool)
+	for _, operation := range operations {
+		requirements := swagger.Security
+		if operation.Security != nil {
+			requirements = *operation.Security
+		}
+		for _, requirement := range requirements {
+			for scheme := range requirement {
+				schemes[scheme] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(schemes))
+	for scheme := range schemes {
+		names = append(names, scheme)
+	}
+	sort.Strings(names)
+
+	var (
+		plugins  []api.Plugin
+		warnings []string
+		added    = make(map[string]bool)
+	)
+	for _, name := range names {
+		ref := swagger.Components.SecuritySchemes[name]
+		if ref == nil || ref.Value == nil {
+			warnings = append(warnings, fmt.Sprintf("security scheme %q is not defined", name))
+			continue
+		}
+
+		var plugin api.Plugin
+		switch scheme := ref.Value; {
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			plugin = api.Plugin{Name: pluginBasicAuth, Enabled: true, Config: map[string]interface{}{}}
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"), scheme.Type == "oauth2", scheme.Type == "openIdConnect":
+			server := oauthServer
+			if server == "" {
+				server = name
+			}
+			plugin = api.Plugin{Name: pluginOAuth2, Enabled: true, Config: map[string]interface{}{"server_name": server}}
+		default:
+			warnings = append(warnings, fmt.Sprintf("security scheme %q of type %q has no matching plugin", name, scheme.Type))
+			continue
+		}
+
+		// Janus runs every plugin of a definition, a request can't satisfy one scheme or another
+		if added[plugin.Name] {
+			continue
+		}
+		if len(added) > 0 {
+			warnings = append(warnings, fmt.Sprintf("security scheme %q is required along with the other ones", name))
+		}
+		added[plugin.Name] = true
+		plugins = append(plugins, plugin)
+	}
+
+	return plugins, warnings
+}
+
+// uniqueName returns the name of the definition of a path, numbered when it is already used by another path
+func uniqueName(used map[string]bool, prefix, path string) string {
+	base := prefix
+	if s := slug(path); s != "" {
+		base += "-" + s
+	}
+
+	name := base
+	for n := 2; used[name]; n++ {
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	used[name] = true
+
+	return name
+}
+
+func slug(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
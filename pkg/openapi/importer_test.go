@@ -0,0 +1,133 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+func loadMenus(t *testing.T) *openapi3.Swagger {
+	data, err := ioutil.ReadFile("testdata/menus.yaml")
+	require.NoError(t, err)
+
+	swagger, err := Load(data)
+	require.NoError(t, err)
+	return swagger
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load([]byte("not: [an, openapi, document"))
+	assert.Error(t, err)
+
+	_, err = Load([]byte(`{"openapi": "3.0.0", "info": {"title": "Missing version"}, "paths": {}}`))
+	assert.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	defs, warnings, err := Import(loadMenus(t), Options{})
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.Name)
+		assert.Equal(t, "https://eu.menus.example.com/v2", def.Proxy.Upstreams.Targets[0].Target)
+		assert.Empty(t, def.Proxy.Rewrite)
+		assert.Empty(t, def.Plugins)
+	}
+	assert.Equal(t, []string{"weekly-menus-menus", "weekly-menus-menus-list", "weekly-menus-menus-list-2", "weekly-menus-menus-id"}, names)
+
+	assert.Equal(t, "/menus", defs[0].Proxy.ListenPath)
+	assert.Equal(t, []string{"GET", "POST"}, defs[0].Proxy.Methods)
+	assert.Equal(t, "/menus/{id}", defs[3].Proxy.ListenPath)
+	assert.Equal(t, []string{"GET"}, defs[3].Proxy.Methods)
+}
+
+func TestImportNumberedNames(t *testing.T) {
+	swagger, err := Load([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Numbered", "version": "1.0"},
+		"servers": [{"url": "http://numbered.internal"}],
+		"paths": {
+			"/a": {"get": {"responses": {"200": {"description": "OK"}}}},
+			"/a/": {"get": {"responses": {"200": {"description": "OK"}}}},
+			"/a-2": {"get": {"responses": {"200": {"description": "OK"}}}}
+		}
+	}`))
+	require.NoError(t, err)
+
+	defs, _, err := Import(swagger, Options{})
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.Name)
+	}
+	assert.Equal(t, []string{"numbered-a", "numbered-a-2", "numbered-a-3"}, names)
+}
+
+func TestImportOptions(t *testing.T) {
+	defs, _, err := Import(loadMenus(t), Options{
+		Name:             "Menus",
+		ListenPathPrefix: "/menus-api/",
+		Upstream:         "http://menus.internal",
+		Validate:         true,
+		SpecFile:         "/etc/janus/menus.yaml",
+	})
+	require.NoError(t, err)
+	require.Len(t, defs, 4)
+
+	def := defs[0]
+	assert.Equal(t, "menus-menus", def.Name)
+	assert.Equal(t, "/menus-api/menus", def.Proxy.ListenPath)
+	assert.Equal(t, []proxy.RewriteRule{{Prefix: "/menus-api", To: "/"}}, def.Proxy.Rewrite)
+	assert.Equal(t, "http://menus.internal", def.Proxy.Upstreams.Targets[0].Target)
+	assert.Equal(t, []api.Plugin{{
+		Name:    pluginValidator,
+		Enabled: true,
+		Config:  map[string]interface{}{"file": "/etc/janus/menus.yaml", "base_path": "/menus-api"},
+	}}, def.Plugins)
+
+	// Without a file, every definition gets its path of the document inline
+	defs, _, err = Import(loadMenus(t), Options{Validate: true})
+	require.NoError(t, err)
+	for _, def := range defs {
+		require.Len(t, def.Plugins, 1)
+		spec, err := Load([]byte(def.Plugins[0].Config["spec"].(string)))
+		require.NoError(t, err)
+		assert.Len(t, spec.Paths, 1)
+		assert.NotNil(t, spec.Paths.Find(def.Proxy.ListenPath))
+		assert.Len(t, spec.Components.SecuritySchemes, 3)
+	}
+
+	_, _, err = Import(loadMenus(t), Options{Upstream: "menus.internal"})
+	assert.Error(t, err)
+
+	_, _, err = Import(loadMenus(t), Options{ListenPathPrefix: "menus"})
+	assert.Error(t, err)
+}
+
+func TestImportAuth(t *testing.T) {
+	defs, warnings, err := Import(loadMenus(t), Options{Auth: true, OAuthServer: "accounts"})
+	require.NoError(t, err)
+
+	// The operations of /menus require the basic scheme of the document or their token scheme
+	assert.Equal(t, []api.Plugin{
+		{Name: pluginBasicAuth, Enabled: true, Config: map[string]interface{}{}},
+		{Name: pluginOAuth2, Enabled: true, Config: map[string]interface{}{"server_name": "accounts"}},
+	}, defs[0].Plugins)
+	assert.Empty(t, defs[1].Plugins)
+	assert.Empty(t, defs[2].Plugins)
+	assert.Empty(t, defs[3].Plugins)
+
+	assert.Equal(t, []string{
+		`/menus: security scheme "token" is required along with the other ones`,
+		`/menus/{id}: security scheme "key" of type "apiKey" has no matching plugin`,
+	}, warnings)
+}
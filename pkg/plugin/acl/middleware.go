@@ -0,0 +1,109 @@
+package acl
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrAccessDenied is used when the consumer is not allowed to access the API
+	ErrAccessDenied = errors.New(http.StatusForbidden, "you cannot consume this service")
+	// ErrEmptyGroups is used when neither allow nor deny groups are configured
+	ErrEmptyGroups = errors.New(http.StatusBadRequest, "at least one allow or deny group is required")
+)
+
+// NewACLMiddleware creates a middleware that checks the group membership of the authenticated consumer.
+// Deny groups take precedence over allow groups, and when allow groups are set the consumer must be member
+// of at least one of them.
+func NewACLMiddleware(config Config) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := log.WithFields(log.Fields{
+				"path":   r.RequestURI,
+				"origin": r.RemoteAddr,
+			})
+			logger.Debug("Starting ACL middleware")
+
+			// a verified JWT without subject has no consumer, but its claim groups still apply
+			_, verified := consumer.ClaimsFromContext(r.Context())
+			c, ok := consumer.FromContext(r.Context())
+			if !ok && !verified {
+				logger.Debug("No authenticated consumer, make sure an auth plugin is enabled before the acl plugin")
+				errors.Handler(w, r, ErrAccessDenied)
+				return
+			}
+
+			groups := claimGroups(r, config.JWTClaim)
+			if ok {
+				groups = append(groups, c.Groups...)
+				logger = logger.WithField("consumer", c.Username)
+			}
+
+			if !isAllowed(config, groups) {
+				logger.Debug("Consumer is not allowed by the ACL")
+				errors.Handler(w, r, ErrAccessDenied)
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAllowed(config Config, groups []string) bool {
+	if intersects(config.Deny, groups) {
+		return false
+	}
+
+	if len(config.Allow) > 0 {
+		return intersects(config.Allow, groups)
+	}
+
+	return true
+}
+
+// claimGroups reads the groups from the verified JWT claims, the claim may be
+// either a list of strings or a space separated string
+func claimGroups(r *http.Request, claim string) []string {
+	if claim == "" {
+		return nil
+	}
+
+	claims, ok := consumer.ClaimsFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+
+	switch value := claims[claim].(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		groups := make([]string, 0, len(value))
+		for _, v := range value {
+			if group, ok := v.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	case []string:
+		return value
+	}
+
+	return nil
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,91 @@
+package acl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	"github.com/hellofresh/janus/pkg/test"
+)
+
+func serve(config Config, r *http.Request) int {
+	w := httptest.NewRecorder()
+	NewACLMiddleware(config)(http.HandlerFunc(test.Ping)).ServeHTTP(w, r)
+
+	return w.Code
+}
+
+func identified(groups ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	return consumer.Identify(r, consumer.NewCredential(consumer.BasicCredential, "john"), groups...)
+}
+
+func TestACLWithoutConsumer(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	assert.Equal(t, http.StatusForbidden, serve(Config{Allow: []string{"admin"}}, r))
+}
+
+func TestACLWithClaimsWithoutConsumer(t *testing.T) {
+	config := Config{Allow: []string{"admin"}, JWTClaim: "groups"}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(consumer.NewClaimsContext(r.Context(), map[string]interface{}{
+		"groups": []interface{}{"admin"},
+	}))
+	assert.Equal(t, http.StatusOK, serve(config, r))
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(consumer.NewClaimsContext(r.Context(), map[string]interface{}{
+		"groups": []interface{}{"dev"},
+	}))
+	assert.Equal(t, http.StatusForbidden, serve(config, r))
+	assert.Equal(t, http.StatusOK, serve(Config{Deny: []string{"banned"}, JWTClaim: "groups"}, r))
+}
+
+func TestACLAllow(t *testing.T) {
+	config := Config{Allow: []string{"admin", "ops"}}
+
+	assert.Equal(t, http.StatusOK, serve(config, identified("ops")))
+	assert.Equal(t, http.StatusForbidden, serve(config, identified("dev")))
+	assert.Equal(t, http.StatusForbidden, serve(config, identified()))
+}
+
+func TestACLDeny(t *testing.T) {
+	config := Config{Deny: []string{"banned"}}
+
+	assert.Equal(t, http.StatusOK, serve(config, identified("dev")))
+	assert.Equal(t, http.StatusOK, serve(config, identified()))
+	assert.Equal(t, http.StatusForbidden, serve(config, identified("dev", "banned")))
+}
+
+func TestACLDenyTakesPrecedence(t *testing.T) {
+	config := Config{Allow: []string{"admin"}, Deny: []string{"banned"}}
+
+	assert.Equal(t, http.StatusForbidden, serve(config, identified("admin", "banned")))
+}
+
+func TestACLGroupsFromJWTClaim(t *testing.T) {
+	config := Config{Allow: []string{"admin"}, JWTClaim: "groups"}
+
+	r := identified()
+	r = r.WithContext(consumer.NewClaimsContext(r.Context(), map[string]interface{}{
+		"groups": []interface{}{"dev", "admin"},
+	}))
+	assert.Equal(t, http.StatusOK, serve(config, r))
+
+	r = identified()
+	r = r.WithContext(consumer.NewClaimsContext(r.Context(), map[string]interface{}{
+		"groups": "dev admin",
+	}))
+	assert.Equal(t, http.StatusOK, serve(config, r))
+
+	r = identified()
+	r = r.WithContext(consumer.NewClaimsContext(r.Context(), map[string]interface{}{
+		"roles": []interface{}{"admin"},
+	}))
+	assert.Equal(t, http.StatusForbidden, serve(config, r))
+}
@@ -0,0 +1,46 @@
+package acl
+
+import (
+	"github.com/asaskevich/govalidator"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+// Config represents the ACL configuration
+type Config struct {
+	Allow    []string `json:"allow"`
+	Deny     []string `json:"deny"`
+	JWTClaim string   `json:"jwt_claim"`
+}
+
+func init() {
+	plugin.RegisterPlugin("acl", plugin.Plugin{
+		Action:   setupACL,
+		Validate: validateConfig,
+	})
+}
+
+func setupACL(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(NewACLMiddleware(config))
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if len(config.Allow) == 0 && len(config.Deny) == 0 {
+		return false, ErrEmptyGroups
+	}
+
+	return govalidator.ValidateStruct(config)
+}
@@ -0,0 +1,44 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+func TestConfig(t *testing.T) {
+	var config Config
+	rawConfig := map[string]interface{}{
+		"allow":     []string{"admin"},
+		"deny":      []string{"banned"},
+		"jwt_claim": "groups",
+	}
+
+	err := plugin.Decode(rawConfig, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"admin"}, config.Allow)
+	assert.Equal(t, []string{"banned"}, config.Deny)
+	assert.Equal(t, "groups", config.JWTClaim)
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid, err := validateConfig(map[string]interface{}{"allow": []string{"admin"}})
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = validateConfig(map[string]interface{}{"jwt_claim": "groups"})
+	assert.Equal(t, ErrEmptyGroups, err)
+	assert.False(t, valid)
+}
+
+func TestSetup(t *testing.T) {
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+
+	err := setupACL(def, map[string]interface{}{"allow": []string{"admin"}})
+	assert.NoError(t, err)
+	assert.Len(t, def.Middleware(), 1)
+}
@@ -24,18 +24,20 @@ func (r *CassandraRepository) FindAll() ([]*User, error) {
 
 	var results []*User
 
-	iter := r.session.GetSession().Query("SELECT username, password FROM user").Iter()
+	iter := r.session.GetSession().Query("SELECT username, password, groups FROM user").Iter()
 
 	var username string
 	var password string
+	var groups []string
 
 	err := iter.ScanAndClose(func() bool {
 		var user User
 		user.Username = username
 		user.Password = password
+		user.Groups = groups
 		results = append(results, &user)
 		return true
-	}, &username, &password)
+	}, &username, &password, &groups)
 
 	if err != nil {
 		log.Errorf("error getting all oauths: %v", err)
@@ -52,21 +54,22 @@ func (r *CassandraRepository) FindByUsername(username string) (*User, error) {
 	var user User
 
 	err := r.session.GetSession().Query(
-		"SELECT username, password " +
+		"SELECT username, password, groups " +
 			"FROM user " +
 			"WHERE username = ?",
-		username).Scan(&user.Username, &user.Password)
+		username).Scan(&user.Username, &user.Password, &user.Groups)
 
-	if err.Error() == "not found"{
-		log.Debugf("user not found")
-		err = ErrUserNotFound
-	} else if err != nil {
+	if err != nil {
+		if err.Error() == "not found" {
+			log.Debugf("user not found")
+			return nil, ErrUserNotFound
+		}
 		log.Errorf("error selecting user %s: %v", username, err)
-	} else {
-		log.Debugf("successfully found user %s", username)
+		return nil, err
 	}
 
-	return &user, err
+	log.Debugf("successfully found user %s", username)
+	return &user, nil
 }
 
 // Add adds an user to the repository
@@ -81,9 +84,10 @@ func (r *CassandraRepository) Add(user *User) error {
 
 	err = r.session.GetSession().Query(
 		"UPDATE user " +
-			"SET password = ? " +
+			"SET password = ?, " +
+			"groups = ? " +
 			"WHERE username = ?",
-		hash, user.Username).Exec()
+		hash, user.Groups, user.Username).Exec()
 
 	if err != nil {
 		log.Errorf("error saving user %s: %v", user.Username, err)
@@ -94,6 +98,48 @@ func (r *CassandraRepository) Add(user *User) error {
 	return err
 }
 
+// SetGroups replaces the groups the user is member of
+func (r *CassandraRepository) SetGroups(username string, groups []string) error {
+	log.Debugf("setting groups of: %s", username)
+
+	if _, err := r.FindByUsername(username); err != nil {
+		return err
+	}
+
+	err := r.session.GetSession().Query(
+		"UPDATE user " +
+			"SET groups = ? " +
+			"WHERE username = ?",
+		groups, username).Exec()
+
+	if err != nil {
+		log.Errorf("error saving groups of user %s: %v", username, err)
+	} else {
+		log.Debugf("successfully saved groups of user %s", username)
+	}
+
+	return err
+}
+
+// UpdatePasswordHash replaces the password hash of the user with an already hashed one
+func (r *CassandraRepository) UpdatePasswordHash(username string, hash string) error {
+	log.Debugf("updating password hash of: %s", username)
+
+	err := r.session.GetSession().Query(
+		"UPDATE user " +
+			"SET password = ? " +
+			"WHERE username = ? IF EXISTS",
+		hash, username).Exec()
+
+	if err != nil {
+		log.Errorf("error saving password hash of user %s: %v", username, err)
+	} else {
+		log.Debugf("successfully saved password hash of user %s", username)
+	}
+
+	return err
+}
+
 // Remove an user from the repository
 func (r *CassandraRepository) Remove(username string) error {
 	log.Debugf("removing: %s", username)
@@ -0,0 +1,117 @@
+package basic
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultCredentialCacheTTL is the time a verified credential is trusted without comparing the password hash again
+	DefaultCredentialCacheTTL = 30 * time.Second
+	// DefaultCredentialCacheSize is the maximum number of verified credentials kept in the cache
+	DefaultCredentialCacheSize = 10000
+)
+
+type cachedCredential struct {
+	hash    string
+	expires time.Time
+}
+
+// CredentialCache keeps credentials that were recently verified so the expensive password
+// hash comparison is not done on every request. Credentials are never stored in clear text,
+// entries are keyed by an HMAC of the username and password with a random per-process key.
+// An entry is only valid for the password hash it was verified against, so changing
+// the password of a user invalidates it.
+type CredentialCache struct {
+	sync.Mutex
+	key     []byte
+	ttl     time.Duration
+	size    int
+	entries map[string]cachedCredential
+}
+
+// NewCredentialCache creates a new credential cache
+func NewCredentialCache(ttl time.Duration, size int) *CredentialCache {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		log.WithError(err).Error("Could not generate the credential cache key, caching is disabled")
+		key = nil
+	}
+
+	return &CredentialCache{
+		key:     key,
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]cachedCredential),
+	}
+}
+
+// Verified checks if the credentials were recently verified against the given password hash
+func (c *CredentialCache) Verified(username, password, hash string) bool {
+	if c.key == nil {
+		return false
+	}
+
+	key := c.keyFor(username, password)
+
+	c.Lock()
+	defer c.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return false
+	}
+
+	return hmac.Equal([]byte(entry.hash), []byte(hash))
+}
+
+// Add marks the credentials as verified against the given password hash
+func (c *CredentialCache) Add(username, password, hash string) {
+	if c.key == nil {
+		return
+	}
+
+	key := c.keyFor(username, password)
+
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.entries) >= c.size {
+		c.purgeExpired()
+	}
+
+	if len(c.entries) >= c.size {
+		return
+	}
+
+	c.entries[key] = cachedCredential{hash: hash, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *CredentialCache) purgeExpired() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *CredentialCache) keyFor(username, password string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(username))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
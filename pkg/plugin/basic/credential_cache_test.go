@@ -0,0 +1,48 @@
+package basic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialCache(t *testing.T) {
+	cache := NewCredentialCache(time.Minute, 10)
+
+	assert.False(t, cache.Verified("test", "test", "hash"))
+
+	cache.Add("test", "test", "hash")
+	assert.True(t, cache.Verified("test", "test", "hash"))
+	assert.False(t, cache.Verified("test", "wrong", "hash"))
+	assert.False(t, cache.Verified("test", "test", "changed-hash"))
+}
+
+func TestCredentialCacheExpires(t *testing.T) {
+	cache := NewCredentialCache(time.Millisecond, 10)
+
+	cache.Add("test", "test", "hash")
+	time.Sleep(2 * time.Millisecond)
+
+	assert.False(t, cache.Verified("test", "test", "hash"))
+}
+
+func TestCredentialCacheIsBounded(t *testing.T) {
+	cache := NewCredentialCache(time.Minute, 1)
+
+	cache.Add("test1", "test1", "hash")
+	cache.Add("test2", "test2", "hash")
+
+	assert.True(t, cache.Verified("test1", "test1", "hash"))
+	assert.False(t, cache.Verified("test2", "test2", "hash"))
+}
+
+func TestCredentialCacheDoesNotStoreCredentials(t *testing.T) {
+	cache := NewCredentialCache(time.Minute, 10)
+	cache.Add("test", "secret", "hash")
+
+	for key := range cache.entries {
+		assert.NotContains(t, key, "secret")
+		assert.NotContains(t, key, "test")
+	}
+}
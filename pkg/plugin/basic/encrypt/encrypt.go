@@ -1,24 +1,170 @@
 package encrypt
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"sync"
 
-//Hash implements root.Hash
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Default hashing parameters
+const (
+	DefaultAlgorithm     = Argon2id
+	DefaultArgon2Time    = 2
+	DefaultArgon2Memory  = 19 * 1024
+	DefaultArgon2Threads = 1
+	DefaultScryptCost    = 15
+	DefaultBcryptCost    = bcrypt.DefaultCost
+)
+
+var (
+	defaultHasher Hasher = NewArgon2idHasher(DefaultArgon2Time, DefaultArgon2Memory, DefaultArgon2Threads)
+	dummyHash     string
+	mu            sync.RWMutex
+)
+
+// Options represents the hasher configuration
+type Options struct {
+	Algorithm     string
+	Argon2Time    uint32
+	Argon2Memory  uint32
+	Argon2Threads uint8
+	ScryptCost    int
+	BcryptCost    int
+}
+
+// NewHasher creates the hasher for the configured algorithm, falling back to the default parameters
+// for the ones that are not set
+func NewHasher(opts Options) (Hasher, error) {
+	switch opts.Algorithm {
+	case Argon2id, "":
+		if opts.Argon2Time == 0 {
+			opts.Argon2Time = DefaultArgon2Time
+		}
+		if opts.Argon2Memory == 0 {
+			opts.Argon2Memory = DefaultArgon2Memory
+		}
+		if opts.Argon2Threads == 0 {
+			opts.Argon2Threads = DefaultArgon2Threads
+		}
+		return NewArgon2idHasher(opts.Argon2Time, opts.Argon2Memory, opts.Argon2Threads), nil
+	case Scrypt:
+		if opts.ScryptCost == 0 {
+			opts.ScryptCost = DefaultScryptCost
+		}
+		return NewScryptHasher(opts.ScryptCost), nil
+	case Bcrypt:
+		if opts.BcryptCost == 0 {
+			opts.BcryptCost = DefaultBcryptCost
+		}
+		return NewBcryptHasher(opts.BcryptCost), nil
+	}
+
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// SetDefaultHasher sets the hasher used to generate new hashes
+func SetDefaultHasher(hasher Hasher) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	defaultHasher = hasher
+	dummyHash = ""
+}
+
+// Hash generates password hashes with the default hasher and verifies hashes
+// generated by any of the supported algorithms
 type Hash struct{}
 
-//Generate a salted hash for the input string
+// Generate a salted hash for the input string
 func (c *Hash) Generate(s string) (string, error) {
-	saltedBytes := []byte(s)
-	hashedBytes, err := bcrypt.GenerateFromPassword(saltedBytes, bcrypt.DefaultCost)
+	return current().Generate(s)
+}
+
+// Compare the hash with the input string
+func (c *Hash) Compare(hash string, s string) error {
+	hasher, err := hasherFor(hash)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	hash := string(hashedBytes[:])
-	return hash, nil
+	return hasher.Compare(hash, s)
 }
 
-func (c *Hash) Compare(hash string, s string) error {
-	incoming := []byte(s)
-	existing := []byte(hash)
-	return bcrypt.CompareHashAndPassword(existing, incoming)
+// NeedsRehash checks if the hash was generated with a different algorithm or parameters than the default hasher ones
+func (c *Hash) NeedsRehash(hash string) bool {
+	hasher := current()
+	return algorithmOf(hash) != hasher.Algorithm() || hasher.NeedsRehash(hash)
+}
+
+// PasswordUpdater stores the password hashes of the users
+type PasswordUpdater interface {
+	UpdatePasswordHash(username string, hash string) error
+}
+
+// Rehash stores a hash of the password generated with the current hashing algorithm and parameters.
+// Failing to do so is logged but does not fail the request, the old hash is returned instead.
+func (c *Hash) Rehash(repo PasswordUpdater, username, password, oldHash string) string {
+	newHash, err := c.Generate(password)
+	if err != nil {
+		log.WithError(err).WithField("username", username).Error("Could not rehash the password")
+		return oldHash
+	}
+
+	if err := repo.UpdatePasswordHash(username, newHash); err != nil {
+		log.WithError(err).WithField("username", username).Error("Could not store the rehashed password")
+		return oldHash
+	}
+
+	log.WithField("username", username).Debug("Password rehashed with the current hashing parameters")
+	return newHash
+}
+
+// CompareMissing does the same amount of work as Compare when there is no hash to compare with,
+// i.e. the user does not exist, so the response time does not reveal which users exist
+func (c *Hash) CompareMissing(s string) {
+	c.Compare(dummy(), s)
+}
+
+func current() Hasher {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return defaultHasher
+}
+
+func dummy() string {
+	mu.RLock()
+	hash := dummyHash
+	mu.RUnlock()
+
+	if hash != "" {
+		return hash
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if dummyHash == "" {
+		dummyHash, _ = defaultHasher.Generate("janus-dummy-password")
+	}
+
+	return dummyHash
+}
+
+func hasherFor(hash string) (Hasher, error) {
+	if hasher := current(); hasher.Algorithm() == algorithmOf(hash) {
+		return hasher, nil
+	}
+
+	switch algorithmOf(hash) {
+	case Argon2id:
+		return &Argon2idHasher{}, nil
+	case Scrypt:
+		return &ScryptHasher{}, nil
+	case Bcrypt:
+		return &BcryptHasher{}, nil
+	}
+
+	return nil, ErrUnsupportedAlgorithm
 }
@@ -0,0 +1,142 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashers(t *testing.T) {
+	hashers := []Hasher{
+		NewArgon2idHasher(1, 1024, 1),
+		NewScryptHasher(4),
+		NewBcryptHasher(4),
+	}
+
+	for _, hasher := range hashers {
+		t.Run(hasher.Algorithm(), func(t *testing.T) {
+			hash, err := hasher.Generate("secret")
+			require.NoError(t, err)
+
+			assert.Equal(t, hasher.Algorithm(), algorithmOf(hash))
+			assert.NoError(t, hasher.Compare(hash, "secret"))
+			assert.Equal(t, ErrMismatchedHashAndPassword, hasher.Compare(hash, "wrong"))
+			assert.False(t, hasher.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestArgon2idPHCFormat(t *testing.T) {
+	hash, err := NewArgon2idHasher(1, 1024, 2).Generate("secret")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=2$"))
+	assert.True(t, NewArgon2idHasher(2, 1024, 2).NeedsRehash(hash))
+}
+
+func TestScryptPHCFormat(t *testing.T) {
+	hash, err := NewScryptHasher(4).Generate("secret")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(hash, "$scrypt$ln=4,r=8,p=1$"))
+	assert.True(t, NewScryptHasher(5).NeedsRehash(hash))
+}
+
+func TestInvalidHashes(t *testing.T) {
+	salt := base64.RawStdEncoding.EncodeToString(make([]byte, saltLength))
+	key := base64.RawStdEncoding.EncodeToString(make([]byte, keyLength))
+
+	tests := []struct {
+		scenario string
+		hasher   Hasher
+		hash     string
+	}{
+		{scenario: "argon2id without time", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key},
+		{scenario: "argon2id without threads", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key},
+		{scenario: "argon2id with too many threads", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=1024,t=1,p=256$" + salt + "$" + key},
+		{scenario: "argon2id without memory", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key},
+		{scenario: "argon2id with too much memory", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=4194304,t=1,p=1$" + salt + "$" + key},
+		{scenario: "argon2id without hash", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$"},
+		{scenario: "argon2id with a short hash", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$" + key[:10]},
+		{scenario: "argon2id with a short salt", hasher: NewArgon2idHasher(1, 1024, 1), hash: "$argon2id$v=19$m=1024,t=1,p=1$" + salt[:4] + "$" + key},
+		{scenario: "scrypt without cost", hasher: NewScryptHasher(4), hash: "$scrypt$ln=0,r=8,p=1$" + salt + "$" + key},
+		{scenario: "scrypt with too much memory", hasher: NewScryptHasher(4), hash: "$scrypt$ln=20,r=8,p=1$" + salt + "$" + key},
+		{scenario: "scrypt without block size", hasher: NewScryptHasher(4), hash: "$scrypt$ln=4,r=0,p=1$" + salt + "$" + key},
+		{scenario: "scrypt without parallelism", hasher: NewScryptHasher(4), hash: "$scrypt$ln=4,r=8,p=0$" + salt + "$" + key},
+		{scenario: "scrypt without hash", hasher: NewScryptHasher(4), hash: "$scrypt$ln=4,r=8,p=1$" + salt + "$"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			assert.Equal(t, ErrInvalidHash, tt.hasher.Compare(tt.hash, "secret"))
+		})
+	}
+}
+
+func TestHashComparesAnySupportedAlgorithm(t *testing.T) {
+	defer SetDefaultHasher(NewArgon2idHasher(DefaultArgon2Time, DefaultArgon2Memory, DefaultArgon2Threads))
+	SetDefaultHasher(NewArgon2idHasher(1, 1024, 1))
+
+	bcryptHash, err := NewBcryptHasher(4).Generate("secret")
+	require.NoError(t, err)
+	scryptHash, err := NewScryptHasher(4).Generate("secret")
+	require.NoError(t, err)
+
+	hash := Hash{}
+	for _, h := range []string{bcryptHash, scryptHash} {
+		assert.NoError(t, hash.Compare(h, "secret"))
+		assert.Error(t, hash.Compare(h, "wrong"))
+		assert.True(t, hash.NeedsRehash(h))
+	}
+
+	argon2Hash, err := hash.Generate("secret")
+	require.NoError(t, err)
+	assert.NoError(t, hash.Compare(argon2Hash, "secret"))
+	assert.False(t, hash.NeedsRehash(argon2Hash))
+
+	assert.Equal(t, ErrUnsupportedAlgorithm, hash.Compare("plain", "plain"))
+}
+
+func TestNewHasher(t *testing.T) {
+	hasher, err := NewHasher(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, &Argon2idHasher{Time: DefaultArgon2Time, Memory: DefaultArgon2Memory, Threads: DefaultArgon2Threads}, hasher)
+
+	hasher, err = NewHasher(Options{Algorithm: Bcrypt, BcryptCost: 12})
+	require.NoError(t, err)
+	assert.Equal(t, NewBcryptHasher(12), hasher)
+
+	_, err = NewHasher(Options{Algorithm: "md5"})
+	assert.Equal(t, ErrUnsupportedAlgorithm, err)
+}
+
+type updaterFunc func(username string, hash string) error
+
+func (f updaterFunc) UpdatePasswordHash(username string, hash string) error {
+	return f(username, hash)
+}
+
+func TestRehash(t *testing.T) {
+	hash := Hash{}
+	oldHash, err := NewBcryptHasher(4).Generate("secret")
+	require.NoError(t, err)
+
+	var stored string
+	newHash := hash.Rehash(updaterFunc(func(username string, h string) error {
+		assert.Equal(t, "john", username)
+		stored = h
+		return nil
+	}), "john", "secret", oldHash)
+
+	assert.Equal(t, stored, newHash)
+	assert.NoError(t, hash.Compare(newHash, "secret"))
+	assert.False(t, hash.NeedsRehash(newHash))
+
+	failed := hash.Rehash(updaterFunc(func(string, string) error {
+		return ErrMismatchedHashAndPassword
+	}), "john", "secret", oldHash)
+	assert.Equal(t, oldHash, failed)
+}
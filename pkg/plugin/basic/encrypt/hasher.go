@@ -0,0 +1,280 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported algorithms
+const (
+	Argon2id = "argon2id"
+	Scrypt   = "scrypt"
+	Bcrypt   = "bcrypt"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+
+	// The stored hashes with a shorter salt or hash, or requiring more memory, are not valid
+	minSaltLength = 8
+	minKeyLength  = 16
+	maxMemory     = 256 * 1024 * 1024
+)
+
+var (
+	// ErrMismatchedHashAndPassword is used when a password does not match the hash
+	ErrMismatchedHashAndPassword = errors.New("hash is not the hash of the given password")
+	// ErrUnsupportedAlgorithm is used when the hashing algorithm is not supported
+	ErrUnsupportedAlgorithm = errors.New("hashing algorithm is not supported")
+)
+
+// Hasher generates and verifies password hashes with one algorithm
+type Hasher interface {
+	// Algorithm returns the name of the hashing algorithm
+	Algorithm() string
+	// Generate a salted hash for the input string
+	Generate(s string) (string, error)
+	// Compare the hash with the input string, the hash parameters are read from the hash itself
+	Compare(hash string, s string) error
+	// NeedsRehash checks if the hash was generated with different parameters than the hasher ones
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idHasher hashes passwords with argon2id, encoded as PHC strings
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// NewArgon2idHasher creates an argon2id hasher, memory is expressed in KiB
+func NewArgon2idHasher(time, memory uint32, threads uint8) *Argon2idHasher {
+	return &Argon2idHasher{Time: time, Memory: memory, Threads: threads}
+}
+
+// Algorithm returns the name of the hashing algorithm
+func (h *Argon2idHasher) Algorithm() string {
+	return Argon2id
+}
+
+// Generate a salted hash for the input string
+func (h *Argon2idHasher) Generate(s string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+
+	return (&phc{
+		id:      Argon2id,
+		version: argon2.Version,
+		params: []phcParam{
+			{name: "m", value: int(h.Memory)},
+			{name: "t", value: int(h.Time)},
+			{name: "p", value: int(h.Threads)},
+		},
+		salt: salt,
+		hash: argon2.IDKey([]byte(s), salt, h.Time, h.Memory, h.Threads, keyLength),
+	}).String(), nil
+}
+
+// Compare the hash with the input string
+func (h *Argon2idHasher) Compare(hash string, s string) error {
+	p, err := parsePHC(hash)
+	if err != nil {
+		return err
+	}
+	if p.id != Argon2id || p.version != argon2.Version {
+		return ErrUnsupportedAlgorithm
+	}
+
+	memory, okM := p.param("m")
+	time, okT := p.param("t")
+	threads, okP := p.param("p")
+	if !okM || !okT || !okP {
+		return ErrInvalidHash
+	}
+	if time < 1 || threads < 1 || threads > 255 || memory < 1 || memory > maxMemory/1024 {
+		return ErrInvalidHash
+	}
+
+	key := argon2.IDKey([]byte(s), p.salt, uint32(time), uint32(memory), uint8(threads), uint32(len(p.hash)))
+	return compareKeys(p.hash, key)
+}
+
+// NeedsRehash checks if the hash was generated with different parameters than the hasher ones
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	p, err := parsePHC(hash)
+	if err != nil || p.id != Argon2id || p.version != argon2.Version {
+		return true
+	}
+
+	memory, _ := p.param("m")
+	time, _ := p.param("t")
+	threads, _ := p.param("p")
+
+	return uint32(memory) != h.Memory || uint32(time) != h.Time || uint8(threads) != h.Threads
+}
+
+// ScryptHasher hashes passwords with scrypt, encoded as PHC strings
+type ScryptHasher struct {
+	// Cost is the base 2 logarithm of the scrypt N parameter
+	Cost int
+	R    int
+	P    int
+}
+
+// NewScryptHasher creates a scrypt hasher, cost is the base 2 logarithm of the scrypt N parameter
+func NewScryptHasher(cost int) *ScryptHasher {
+	return &ScryptHasher{Cost: cost, R: 8, P: 1}
+}
+
+// Algorithm returns the name of the hashing algorithm
+func (h *ScryptHasher) Algorithm() string {
+	return Scrypt
+}
+
+// Generate a salted hash for the input string
+func (h *ScryptHasher) Generate(s string) (string, error) {
+	salt, err := newSalt()
+	if err != nil {
+		return "", err
+	}
+
+	key, err := scrypt.Key([]byte(s), salt, 1<<uint(h.Cost), h.R, h.P, keyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return (&phc{
+		id: Scrypt,
+		params: []phcParam{
+			{name: "ln", value: h.Cost},
+			{name: "r", value: h.R},
+			{name: "p", value: h.P},
+		},
+		salt: salt,
+		hash: key,
+	}).String(), nil
+}
+
+// Compare the hash with the input string
+func (h *ScryptHasher) Compare(hash string, s string) error {
+	p, err := parsePHC(hash)
+	if err != nil {
+		return err
+	}
+	if p.id != Scrypt {
+		return ErrUnsupportedAlgorithm
+	}
+
+	cost, okLN := p.param("ln")
+	r, okR := p.param("r")
+	parallel, okP := p.param("p")
+	if !okLN || !okR || !okP {
+		return ErrInvalidHash
+	}
+	// scrypt uses 128 * N * r bytes of memory
+	if cost < 1 || cost > 30 || r < 1 || parallel < 1 || 128*r > maxMemory>>uint(cost) {
+		return ErrInvalidHash
+	}
+
+	key, err := scrypt.Key([]byte(s), p.salt, 1<<uint(cost), r, parallel, len(p.hash))
+	if err != nil {
+		return err
+	}
+
+	return compareKeys(p.hash, key)
+}
+
+// NeedsRehash checks if the hash was generated with different parameters than the hasher ones
+func (h *ScryptHasher) NeedsRehash(hash string) bool {
+	p, err := parsePHC(hash)
+	if err != nil || p.id != Scrypt {
+		return true
+	}
+
+	cost, _ := p.param("ln")
+	r, _ := p.param("r")
+	parallel, _ := p.param("p")
+
+	return cost != h.Cost || r != h.R || parallel != h.P
+}
+
+// BcryptHasher hashes passwords with bcrypt, using the bcrypt modular crypt format
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher creates a bcrypt hasher
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{Cost: cost}
+}
+
+// Algorithm returns the name of the hashing algorithm
+func (h *BcryptHasher) Algorithm() string {
+	return Bcrypt
+}
+
+// Generate a salted hash for the input string
+func (h *BcryptHasher) Generate(s string) (string, error) {
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(s), h.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashedBytes), nil
+}
+
+// Compare the hash with the input string
+func (h *BcryptHasher) Compare(hash string, s string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(s))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return err
+}
+
+// NeedsRehash checks if the hash was generated with different parameters than the hasher ones
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// algorithmOf detects the algorithm a hash was generated with
+func algorithmOf(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return Argon2id
+	case strings.HasPrefix(hash, "$scrypt$"):
+		return Scrypt
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return Bcrypt
+	}
+
+	return ""
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
+
+func compareKeys(expected, actual []byte) error {
+	if subtle.ConstantTimeCompare(expected, actual) != 1 {
+		return ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidHash is used when a hash can not be parsed
+var ErrInvalidHash = errors.New("the encoded hash is not in the correct format")
+
+// phc represents a hash encoded in the PHC string format:
+// $<id>[$v=<version>]$<param>=<value>(,<param>=<value>)*$<salt>$<hash>
+type phc struct {
+	id      string
+	version int
+	params  []phcParam
+	salt    []byte
+	hash    []byte
+}
+
+type phcParam struct {
+	name  string
+	value int
+}
+
+func parsePHC(encoded string) (*phc, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 5 || parts[0] != "" {
+		return nil, ErrInvalidHash
+	}
+
+	p := &phc{id: parts[1]}
+	parts = parts[2:]
+
+	if strings.HasPrefix(parts[0], "v=") {
+		version, err := strconv.Atoi(strings.TrimPrefix(parts[0], "v="))
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+		p.version = version
+		parts = parts[1:]
+	}
+
+	if len(parts) != 3 {
+		return nil, ErrInvalidHash
+	}
+
+	for _, param := range strings.Split(parts[0], ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return nil, ErrInvalidHash
+		}
+
+		value, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, ErrInvalidHash
+		}
+		p.params = append(p.params, phcParam{name: kv[0], value: value})
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if p.hash, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if len(p.salt) < minSaltLength || len(p.hash) < minKeyLength {
+		return nil, ErrInvalidHash
+	}
+
+	return p, nil
+}
+
+func (p *phc) param(name string) (int, bool) {
+	for _, param := range p.params {
+		if param.name == name {
+			return param.value, true
+		}
+	}
+
+	return 0, false
+}
+
+func (p *phc) String() string {
+	var b strings.Builder
+
+	b.WriteString("$" + p.id)
+	if p.version > 0 {
+		fmt.Fprintf(&b, "$v=%d", p.version)
+	}
+
+	b.WriteString("$")
+	for i, param := range p.params {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "%s=%d", param.name, param.value)
+	}
+
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.salt))
+	b.WriteString("$" + base64.RawStdEncoding.EncodeToString(p.hash))
+
+	return b.String()
+}
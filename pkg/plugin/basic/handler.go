@@ -146,3 +146,49 @@ func (c *Handler) Delete() http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// Groups is the find groups handler
+func (c *Handler) Groups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+		_, span := trace.StartSpan(r.Context(), "repo.FindByUsername")
+		user, err := c.repo.FindByUsername(username)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		groups := user.Groups
+		if groups == nil {
+			groups = make([]string, 0)
+		}
+
+		render.JSON(w, http.StatusOK, groups)
+	}
+}
+
+// UpdateGroups is the replace groups handler
+func (c *Handler) UpdateGroups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+
+		groups := make([]string, 0)
+		if err := json.NewDecoder(r.Body).Decode(&groups); err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		_, span := trace.StartSpan(r.Context(), "repo.SetGroups")
+		err := c.repo.SetGroups(username, groups)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -55,6 +55,34 @@ func (r *InMemoryRepository) Add(user *User) error {
 	return nil
 }
 
+// SetGroups replaces the groups the user is member of
+func (r *InMemoryRepository) SetGroups(username string, groups []string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	user, err := r.findByUsername(username)
+	if err != nil {
+		return err
+	}
+	user.Groups = groups
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the password hash of the user with an already hashed one
+func (r *InMemoryRepository) UpdatePasswordHash(username string, hash string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	user, err := r.findByUsername(username)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+
+	return nil
+}
+
 // Remove removes an user from the repository
 func (r *InMemoryRepository) Remove(username string) error {
 	r.Lock()
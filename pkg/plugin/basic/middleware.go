@@ -5,11 +5,15 @@ import (
 	"net/http"
 
 	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
 	log "github.com/sirupsen/logrus"
 )
 
 // NewBasicAuth is a HTTP basic auth middleware
 func NewBasicAuth(repo Repository) func(http.Handler) http.Handler {
+	cache := NewCredentialCache(DefaultCredentialCacheTTL, DefaultCredentialCacheSize)
+	hash := encrypt.Hash{}
+
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log.Debug("Starting basic auth middleware")
@@ -24,31 +28,35 @@ func NewBasicAuth(repo Repository) func(http.Handler) http.Handler {
 				return
 			}
 
-			var found bool
-			users, err := repo.FindAll()
+			user, err := repo.FindByUsername(username)
+			if err == ErrUserNotFound {
+				hash.CompareMissing(password)
+				logger.Debug("Invalid user/password provided.")
+				errors.Handler(w, r, ErrNotAuthorized)
+				return
+			}
 			if err != nil {
-				log.WithError(err).Error("Error when getting all users")
+				log.WithError(err).Error("Error when looking for the user")
 				errors.Handler(w, r, errors.New(http.StatusInternalServerError, "there was an error when looking for users"))
 				return
 			}
 
-			hash := encrypt.Hash{}
+			passwordHash := user.Password
+			if !cache.Verified(username, password, passwordHash) {
+				if hash.Compare(passwordHash, password) != nil {
+					logger.Debug("Invalid user/password provided.")
+					errors.Handler(w, r, ErrNotAuthorized)
+					return
+				}
 
-			for _, u := range users {
-				//if username == u.Username && (subtle.ConstantTimeCompare([]byte(password), []byte(u.Password)) == 1) {
-				if username == u.Username && (hash.Compare(u.Password, password) == nil) {
-					found = true
-					break
+				if hash.NeedsRehash(passwordHash) {
+					passwordHash = hash.Rehash(repo, username, password, passwordHash)
 				}
-			}
 
-			if !found {
-				logger.Debug("Invalid user/password provided.")
-				errors.Handler(w, r, ErrNotAuthorized)
-				return
+				cache.Add(username, password, passwordHash)
 			}
 
-			handler.ServeHTTP(w, r)
+			handler.ServeHTTP(w, consumer.Identify(r, consumer.NewCredential(consumer.BasicCredential, username), user.Groups...))
 		})
 	}
 }
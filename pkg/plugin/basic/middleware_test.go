@@ -3,10 +3,14 @@ package basic
 import (
 	"encoding/base64"
 	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/hellofresh/janus/pkg/plugin/basic/encrypt"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
 	"github.com/hellofresh/janus/pkg/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthorizedAccess(t *testing.T) {
@@ -27,6 +31,53 @@ func TestAuthorizedAccess(t *testing.T) {
 	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 }
 
+func TestAuthorizedAccessIdentifiesConsumer(t *testing.T) {
+	repo := setupRepo()
+	require.NoError(t, repo.SetGroups("test", []string{"admin"}))
+
+	var identified *consumer.Consumer
+	handler := NewBasicAuth(repo)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identified, _ = consumer.FromContext(r.Context())
+	}))
+
+	_, err := test.Record(
+		"GET",
+		"/",
+		map[string]string{
+			"Authorization": "Basic " + basicAuth("test", "test"),
+		},
+		handler,
+	)
+	require.NoError(t, err)
+
+	require.NotNil(t, identified)
+	assert.Equal(t, "basic:test", identified.Username)
+	assert.Equal(t, []string{"admin"}, identified.Groups)
+}
+
+func TestAuthorizedAccessRehashesPassword(t *testing.T) {
+	repo := setupRepo()
+
+	bcryptHash, err := encrypt.NewBcryptHasher(4).Generate("test")
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdatePasswordHash("test", bcryptHash))
+
+	w, err := test.Record(
+		"GET",
+		"/",
+		map[string]string{
+			"Authorization": "Basic " + basicAuth("test", "test"),
+		},
+		NewBasicAuth(repo)(http.HandlerFunc(test.Ping)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	user, err := repo.FindByUsername("test")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(user.Password, "$argon2id$"))
+}
+
 func TestInvalidBasicHeader(t *testing.T) {
 	mw := NewBasicAuth(setupRepo())
 
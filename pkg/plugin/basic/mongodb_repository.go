@@ -19,8 +19,9 @@ const (
 
 // User represents an user
 type User struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Groups   []string `json:"groups"`
 }
 
 // Repository represents an user repository
@@ -28,6 +29,8 @@ type Repository interface {
 	FindAll() ([]*User, error)
 	FindByUsername(username string) (*User, error)
 	Add(user *User) error
+	SetGroups(username string, groups []string) error
+	UpdatePasswordHash(username string, hash string) error
 	Remove(username string) error
 }
 
@@ -113,6 +116,42 @@ func (r *MongoRepository) Add(user *User) error {
 	return nil
 }
 
+// SetGroups replaces the groups the user is member of
+func (r *MongoRepository) SetGroups(username string, groups []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"username": username}, bson.M{"$set": bson.M{"groups": groups}})
+	if err != nil {
+		log.WithField("username", username).Error("There was an error updating the user groups")
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the password hash of the user with an already hashed one
+func (r *MongoRepository) UpdatePasswordHash(username string, hash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"username": username}, bson.M{"$set": bson.M{"password": hash}})
+	if err != nil {
+		log.WithField("username", username).Error("There was an error updating the user password")
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
 // Remove an user from the repository
 func (r *MongoRepository) Remove(username string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
@@ -1,14 +1,22 @@
 package basic
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/janus/pkg/router"
 	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
+const mongoIdxTimeout = 10 * time.Second
+
 var (
 	repo        Repository
 	adminRouter router.Router
@@ -57,6 +65,21 @@ func onStartup(event interface{}) error {
 		if err != nil {
 			return err
 		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), mongoIdxTimeout)
+		defer cancel()
+
+		if _, err := e.MongoDB.Collection(collectionName).Indexes().CreateOne(
+			ctx,
+			mongo.IndexModel{
+				Keys: bsonx.Doc{
+					{Key: "username", Value: bsonx.Int32(1)},
+				},
+				Options: options.Index().SetUnique(true).SetBackground(true),
+			},
+		); err != nil {
+			return fmt.Errorf("failed to create indexes for basic auth repository: %w", err)
+		}
 	} else if e.Cassandra != nil {
 		log.Debugf("Cassandra is set, using cassandra repository for basic auth plugin")
 
@@ -83,6 +106,8 @@ func onStartup(event interface{}) error {
 		group.GET("/{username}", handlers.Show())
 		group.PUT("/{username}", handlers.Update())
 		group.DELETE("/{username}", handlers.Delete())
+		group.GET("/{username}/groups", handlers.Groups())
+		group.PUT("/{username}/groups", handlers.UpdateGroups())
 	}
 
 	return nil
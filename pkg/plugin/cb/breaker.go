@@ -0,0 +1,232 @@
+package cb
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// Closed lets the requests through, counting their failures
+	Closed State = iota
+	// Open rejects the requests until the sleep window is over
+	Open
+	// HalfOpen lets a few probe requests through to decide whether to close the circuit again
+	HalfOpen
+)
+
+const windowBuckets = 10
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Settings are the thresholds of a circuit breaker
+type Settings struct {
+	// ConsecutiveFailures opens the circuit after this number of failures in a row, zero disables it
+	ConsecutiveFailures int
+	// ErrorPercentThreshold opens the circuit once this percentage of the requests in the rolling window failed
+	ErrorPercentThreshold int
+	// RequestVolumeThreshold is the number of requests in the rolling window needed to open the circuit on the error percentage
+	RequestVolumeThreshold int
+	RollingWindow          time.Duration
+	// SleepWindow is how long the circuit stays open before letting probe requests through
+	SleepWindow time.Duration
+	// HalfOpenRequests is the number of successful probe requests needed to close the circuit
+	HalfOpenRequests int
+}
+
+type bucket struct {
+	requests int
+	failures int
+}
+
+// Breaker is a circuit breaker counting the failures of the requests to one upstream target
+type Breaker struct {
+	sync.Mutex
+	settings    Settings
+	state       State
+	buckets     [windowBuckets]bucket
+	bucketStart time.Time
+	current     int
+	consecutive int
+	openedAt    time.Time
+	probes      int
+	successes   int
+	now         func() time.Time
+}
+
+// NewBreaker creates a new closed Breaker
+func NewBreaker(settings Settings) *Breaker {
+	return &Breaker{settings: settings, now: time.Now}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.currentState()
+}
+
+// Ready checks whether a request can be sent, without counting it as a probe
+func (b *Breaker) Ready() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	switch b.currentState() {
+	case Open:
+		return false
+	case HalfOpen:
+		return b.probes < b.settings.HalfOpenRequests
+	default:
+		return true
+	}
+}
+
+// Allow checks whether a request can be sent, counting it as a probe when the breaker is half-open
+func (b *Breaker) Allow() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	switch b.currentState() {
+	case Open:
+		return false
+	case HalfOpen:
+		if b.probes >= b.settings.HalfOpenRequests {
+			return false
+		}
+		b.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+// Release gives back the probe counted by Allow when the outcome of the request is unknown
+func (b *Breaker) Release() {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.currentState() == HalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// Success records a successful request
+func (b *Breaker) Success() {
+	b.Lock()
+	defer b.Unlock()
+
+	switch b.currentState() {
+	case HalfOpen:
+		b.successes++
+		if b.successes >= b.settings.HalfOpenRequests {
+			b.close()
+		}
+	case Closed:
+		b.bucket().requests++
+		b.consecutive = 0
+	}
+}
+
+// Failure records a failed request
+func (b *Breaker) Failure() {
+	b.Lock()
+	defer b.Unlock()
+
+	switch b.currentState() {
+	case HalfOpen:
+		b.open()
+	case Closed:
+		bkt := b.bucket()
+		bkt.requests++
+		bkt.failures++
+		b.consecutive++
+
+		if b.shouldOpen() {
+			b.open()
+		}
+	}
+}
+
+func (b *Breaker) shouldOpen() bool {
+	if b.settings.ConsecutiveFailures > 0 && b.consecutive >= b.settings.ConsecutiveFailures {
+		return true
+	}
+
+	if b.settings.ErrorPercentThreshold <= 0 {
+		return false
+	}
+
+	var requests, failures int
+	for _, bkt := range b.buckets {
+		requests += bkt.requests
+		failures += bkt.failures
+	}
+
+	return requests >= b.settings.RequestVolumeThreshold && failures*100 >= b.settings.ErrorPercentThreshold*requests
+}
+
+// currentState moves the breaker to half-open once the sleep window is over
+func (b *Breaker) currentState() State {
+	if b.state == Open && !b.now().Before(b.openedAt.Add(b.settings.SleepWindow)) {
+		b.state = HalfOpen
+		b.probes = 0
+		b.successes = 0
+	}
+	return b.state
+}
+
+func (b *Breaker) open() {
+	b.state = Open
+	b.openedAt = b.now()
+}
+
+func (b *Breaker) close() {
+	b.state = Closed
+	b.consecutive = 0
+	b.buckets = [windowBuckets]bucket{}
+	b.bucketStart = time.Time{}
+}
+
+// bucket returns the bucket of the current part of the rolling window, resetting the ones that went out of it
+func (b *Breaker) bucket() *bucket {
+	now := b.now()
+	size := b.settings.RollingWindow / windowBuckets
+	if size <= 0 {
+		size = time.Second
+	}
+
+	if b.bucketStart.IsZero() {
+		b.bucketStart = now
+	}
+
+	for elapsed := 0; now.Sub(b.bucketStart) >= size; elapsed++ {
+		if elapsed >= windowBuckets {
+			b.buckets = [windowBuckets]bucket{}
+			b.bucketStart = now
+			break
+		}
+
+		b.current = (b.current + 1) % windowBuckets
+		b.buckets[b.current] = bucket{}
+		b.bucketStart = b.bucketStart.Add(size)
+	}
+
+	return &b.buckets[b.current]
+}
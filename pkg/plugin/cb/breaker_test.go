@@ -0,0 +1,103 @@
+package cb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBreaker(settings Settings) (*Breaker, *time.Time) {
+	now := time.Unix(1000, 0)
+	b := NewBreaker(settings)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreakerConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(Settings{ConsecutiveFailures: 3, SleepWindow: time.Second, HalfOpenRequests: 1})
+
+	b.Failure()
+	b.Failure()
+	b.Success()
+	b.Failure()
+	b.Failure()
+	assert.Equal(t, Closed, b.State())
+
+	b.Failure()
+	assert.Equal(t, Open, b.State())
+	assert.False(t, b.Allow())
+}
+
+func TestBreakerErrorPercentage(t *testing.T) {
+	b, now := newTestBreaker(Settings{
+		ErrorPercentThreshold:  50,
+		RequestVolumeThreshold: 10,
+		RollingWindow:          10 * time.Second,
+		SleepWindow:            time.Second,
+		HalfOpenRequests:       1,
+	})
+
+	for i := 0; i < 5; i++ {
+		b.Success()
+	}
+	for i := 0; i < 4; i++ {
+		b.Failure()
+	}
+	assert.Equal(t, Closed, b.State(), "not enough requests yet")
+
+	// the requests leave the rolling window
+	*now = now.Add(10 * time.Second)
+	for i := 0; i < 9; i++ {
+		b.Success()
+	}
+	b.Failure()
+	assert.Equal(t, Closed, b.State())
+
+	for i := 0; i < 8; i++ {
+		b.Failure()
+	}
+	assert.Equal(t, Open, b.State())
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	b, now := newTestBreaker(Settings{ConsecutiveFailures: 1, SleepWindow: time.Second, HalfOpenRequests: 2})
+
+	b.Failure()
+	assert.Equal(t, Open, b.State())
+	assert.False(t, b.Ready())
+
+	*now = now.Add(time.Second)
+	assert.Equal(t, HalfOpen, b.State())
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow(), "only two probes are let through")
+
+	b.Success()
+	assert.Equal(t, HalfOpen, b.State())
+	b.Success()
+	assert.Equal(t, Closed, b.State())
+
+	b.Failure()
+	*now = now.Add(time.Second)
+	assert.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, Open, b.State(), "a failed probe opens the circuit again")
+}
+
+func TestBreakerRelease(t *testing.T) {
+	b, now := newTestBreaker(Settings{ConsecutiveFailures: 1, SleepWindow: time.Second, HalfOpenRequests: 1})
+
+	b.Failure()
+	*now = now.Add(time.Second)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Ready())
+
+	b.Release()
+	assert.True(t, b.Ready(), "the released probe can be taken again")
+	assert.Equal(t, HalfOpen, b.State())
+
+	b.Release()
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow(), "releasing without a probe taken does not free more probes")
+}
@@ -0,0 +1,219 @@
+package cb
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+// routeTarget is the key of the breaker of the requests that did not go through the reverse proxy
+const routeTarget = ""
+
+// Status is the state of the breaker of an upstream target
+type Status struct {
+	API        string `json:"api"`
+	ListenPath string `json:"listen_path"`
+	Target     string `json:"target"`
+	State      State  `json:"state"`
+}
+
+// targetBreakers are the circuit breakers of the upstream targets of an API
+type targetBreakers struct {
+	sync.Mutex
+	api      string
+	def      *proxy.Definition
+	settings Settings
+	breakers map[string]*Breaker
+}
+
+func newTargetBreakers(def *proxy.Definition, settings Settings) *targetBreakers {
+	return &targetBreakers{def: def, settings: settings, breakers: make(map[string]*Breaker)}
+}
+
+func (s *targetBreakers) get(target string) *Breaker {
+	s.Lock()
+	defer s.Unlock()
+
+	b, ok := s.breakers[target]
+	if !ok {
+		b = NewBreaker(s.settings)
+		s.breakers[target] = b
+	}
+	return b
+}
+
+// admit checks whether any of the targets of the API can take a request, returning the breaker whose probe
+// the request took. The probes of the targets are counted once the reverse proxy elected one of them.
+func (s *targetBreakers) admit() (*Breaker, bool) {
+	if s.def == nil || !s.def.IsBalancerDefined() {
+		b := s.get(routeTarget)
+		return b, b.Allow()
+	}
+
+	for _, target := range s.def.Upstreams.Targets {
+		if s.get(target.Target).Ready() {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// statuses returns the states of the breakers, sorted by target
+func (s *targetBreakers) statuses() []Status {
+	s.Lock()
+	targets := make([]string, 0, len(s.breakers))
+	for target := range s.breakers {
+		targets = append(targets, target)
+	}
+	s.Unlock()
+
+	sort.Strings(targets)
+
+	var listenPath string
+	if s.def != nil {
+		listenPath = s.def.ListenPath
+	}
+
+	statuses := make([]Status, 0, len(targets))
+	for _, target := range targets {
+		statuses = append(statuses, Status{API: s.api, ListenPath: listenPath, Target: target, State: s.get(target).State()})
+	}
+	return statuses
+}
+
+// requestSelector keeps the request away from the targets with an open circuit, and records the outcome
+// of the attempts of the request on the breakers of the targets they were sent to
+type requestSelector struct {
+	breakers *targetBreakers
+	fallback http.Handler
+	target   string
+	elected  bool
+	rejected bool
+	// probe is the breaker of the current attempt until its outcome is recorded
+	probe *Breaker
+}
+
+func (r *requestSelector) Select(targets []*balancer.Target) []*balancer.Target {
+	result := make([]*balancer.Target, 0, len(targets))
+	for _, target := range targets {
+		if r.breakers.get(target.Target).Ready() {
+			result = append(result, target)
+		}
+	}
+
+	// the last probe of a half-open circuit may have been taken by a concurrent request,
+	// the balancer still elects a target and the request is rejected once it is
+	if len(result) == 0 {
+		return targets
+	}
+	return result
+}
+
+// Elected takes the probe of a half-open circuit, the request is rejected when the circuit can't take it.
+// Another attempt of the request, i.e. by the retry plugin, means the previous one failed.
+func (r *requestSelector) Elected(target string) *proxy.Rejection {
+	if r.probe != nil {
+		r.probe.Failure()
+		r.probe = nil
+	}
+
+	b := r.breakers.get(target)
+	if !b.Allow() {
+		r.rejected = true
+		return &proxy.Rejection{Reason: ErrCircuitOpen.Message, Handler: r.fallback}
+	}
+
+	r.target = target
+	r.elected = true
+	r.rejected = false
+	r.probe = b
+	return nil
+}
+
+// breaker returns the breaker of the target the request was sent to
+func (r *requestSelector) breaker() (*Breaker, string) {
+	if !r.elected {
+		return r.breakers.get(routeTarget), routeTarget
+	}
+	return r.breakers.get(r.target), r.target
+}
+
+// record records the outcome of the last attempt of the request
+func (r *requestSelector) record(failed bool) {
+	b, _ := r.breaker()
+	if failed {
+		b.Failure()
+	} else {
+		b.Success()
+	}
+	r.probe = nil
+}
+
+// release gives back the probe of the last attempt when its outcome was not recorded
+func (r *requestSelector) release() {
+	if r.probe != nil {
+		r.probe.Release()
+		r.probe = nil
+	}
+}
+
+// registry keeps the breakers of all the APIs for the admin API
+type registry struct {
+	sync.Mutex
+	apis map[string]*targetBreakers
+}
+
+var breakersRegistry = &registry{apis: make(map[string]*targetBreakers)}
+
+// add registers the breakers of an API by its name, replacing the ones of a previous definition of the API
+func (r *registry) add(breakers *targetBreakers) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.apis[breakers.api] = breakers
+}
+
+// prune drops the breakers of the APIs that were removed, or that were loaded again without the plugin,
+// the breakers of the current definitions of the APIs are the ones of their proxy definition
+func (r *registry) prune(defs []*api.Definition) {
+	current := make(map[string]*proxy.Definition, len(defs))
+	for _, def := range defs {
+		current[def.Name] = def.Proxy
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	for name, breakers := range r.apis {
+		if def, ok := current[name]; !ok || def != breakers.def {
+			delete(r.apis, name)
+		}
+	}
+}
+
+func (r *registry) statuses() []Status {
+	r.Lock()
+	names := make([]string, 0, len(r.apis))
+	for name := range r.apis {
+		names = append(names, name)
+	}
+	r.Unlock()
+
+	sort.Strings(names)
+
+	statuses := make([]Status, 0)
+	for _, name := range names {
+		r.Lock()
+		breakers, ok := r.apis[name]
+		r.Unlock()
+
+		if ok {
+			statuses = append(statuses, breakers.statuses()...)
+		}
+	}
+	return statuses
+}
@@ -1,67 +1,114 @@
 package cb
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
 
 	"github.com/Knetic/govaluate"
-	"github.com/afex/hystrix-go/hystrix"
 	"github.com/felixge/httpsnoop"
 	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 
 	janusErr "github.com/hellofresh/janus/pkg/errors"
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/proxy"
 )
 
 const (
 	defaultPredicate = "statusCode == 0 || statusCode >= 500"
 )
 
-// NewCBMiddleware creates a new cb middleware
+// ErrCircuitOpen is used when the circuits of all the upstream targets are open and no fallback is configured
+var ErrCircuitOpen = janusErr.New(http.StatusServiceUnavailable, "circuit breaker is open")
+
+// NewCBMiddleware creates a new cb middleware, with the circuit breakers of the route targets
 func NewCBMiddleware(cfg Config) func(http.Handler) http.Handler {
+	return newCBMiddleware(cfg, newTargetBreakers(nil, cfg.settings()))
+}
+
+func newCBMiddleware(cfg Config, breakers *targetBreakers) func(http.Handler) http.Handler {
+	if cfg.Predicate == "" {
+		cfg.Predicate = defaultPredicate
+	}
+
+	expression, err := govaluate.NewEvaluableExpression(cfg.Predicate)
+	if err != nil {
+		log.WithError(err).WithField("predicate", cfg.Predicate).Error("could not create an expression with this predicate")
+	}
+
+	fallback := newFallback(cfg.Fallback)
+
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger := log.WithFields(log.Fields{
-				"name":                    cfg.Name,
-				"timeout":                 cfg.Timeout,
-				"max_concurrent_requests": cfg.MaxConcurrentRequests,
-				"error_percent_threshold": cfg.ErrorPercentThreshold,
-			})
-
-			logger.Debug("Starting cb middleware")
-			if cfg.Predicate == "" {
-				cfg.Predicate = defaultPredicate
-			}
+			log.Debug("Starting cb middleware")
 
-			expression, err := govaluate.NewEvaluableExpression(cfg.Predicate)
-			if err != nil {
-				log.WithError(err).Error("could not create an expression with this predicate")
+			if expression == nil {
 				handler.ServeHTTP(w, r)
 				return
 			}
 
-			err = hystrix.Do(cfg.Name, func() error {
-				m := httpsnoop.CaptureMetrics(handler, w, r)
-				params := make(map[string]interface{}, 8)
-				params["statusCode"] = m.Code
-				params["request"] = r
+			probe, ok := breakers.admit()
+			if !ok {
+				stats.Record(r.Context(), obs.MCircuitBreakerRejected.M(1))
+				log.WithField("path", r.URL.Path).Debug("Circuit breaker is open")
+				fallback.ServeHTTP(w, r)
+				return
+			}
 
-				result, err := expression.Evaluate(params)
-				if err != nil {
-					return errors.New("cannot evaluate the expression")
-				}
+			selector := &requestSelector{breakers: breakers, fallback: fallback, probe: probe}
+			// a half-open circuit would run out of probes if the ones of the requests without outcome were kept
+			defer selector.release()
+			r = r.WithContext(proxy.WithTargetSelector(r.Context(), selector))
 
-				if result.(bool) {
-					return fmt.Errorf("%s %s request failed", r.Method, r.URL)
-				}
+			m := httpsnoop.CaptureMetrics(handler, w, r)
+			if selector.rejected {
+				stats.Record(r.Context(), obs.MCircuitBreakerRejected.M(1))
+				log.WithField("path", r.URL.Path).Debug("Circuit breaker is open")
+				return
+			}
 
-				return nil
-			}, nil)
+			params := make(map[string]interface{}, 2)
+			params["statusCode"] = m.Code
+			params["request"] = r
 
+			result, err := expression.Evaluate(params)
 			if err != nil {
-				logger.WithError(err).Error("Request failed on the cb middleware")
-				janusErr.Handler(w, r, fmt.Errorf("request failed: %w", err))
+				log.WithError(err).Error("cannot evaluate the expression")
+				return
+			}
+
+			breaker, target := selector.breaker()
+			before := breaker.State()
+			failed, _ := result.(bool)
+			selector.record(failed)
+
+			state := breaker.State()
+			if ctx, err := tag.New(r.Context(), tag.Upsert(obs.KeyUpstreamPath, target)); err == nil {
+				stats.Record(ctx, obs.MCircuitBreakerState.M(int64(state)))
+			}
+			if state != before {
+				log.WithFields(log.Fields{
+					"target": target,
+					"state":  state.String(),
+				}).Warn("Circuit breaker state changed")
 			}
 		})
 	}
 }
+
+// newFallback creates the handler of the fallback response, or of ErrCircuitOpen when no fallback is configured
+func newFallback(resp *proxy.Response) http.Handler {
+	circuitOpen := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		janusErr.Handler(w, r, ErrCircuitOpen)
+	})
+	if resp == nil {
+		return circuitOpen
+	}
+
+	handler, err := proxy.NewResponseHandler(resp)
+	if err != nil {
+		log.WithError(err).Error("Could not create the fallback response")
+		return circuitOpen
+	}
+	return handler
+}
@@ -4,9 +4,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
-	"github.com/hellofresh/janus/pkg/test"
+	"github.com/hellofresh/stats-go/client"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/api"
+	"github.com/hellofresh/janus/pkg/plugin/retry"
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/test"
 )
 
 func TestMiddleware(t *testing.T) {
@@ -41,7 +49,6 @@ func TestMiddleware(t *testing.T) {
 
 func testWrongPredicate(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
 	cfg := Config{
-		Name:      "example",
 		Predicate: "this is wrong",
 	}
 	mw := NewCBMiddleware(cfg)
@@ -52,7 +59,7 @@ func testWrongPredicate(t *testing.T, r *http.Request, w *httptest.ResponseRecor
 }
 
 func testSuccessfulUpstreamRetry(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
-	mw := NewCBMiddleware(Config{Name: "example"})
+	mw := NewCBMiddleware(Config{})
 
 	mw(http.HandlerFunc(test.Ping)).ServeHTTP(w, r)
 
@@ -60,9 +67,188 @@ func testSuccessfulUpstreamRetry(t *testing.T, r *http.Request, w *httptest.Resp
 }
 
 func testFailedUpstreamRetry(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
-	mw := NewCBMiddleware(Config{Name: "example"})
+	mw := NewCBMiddleware(Config{})
 
 	mw(test.FailWith(http.StatusBadGateway)).ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusBadGateway, w.Code)
 }
+
+func TestMiddlewareFallback(t *testing.T) {
+	mw := NewCBMiddleware(Config{
+		ConsecutiveFailures: 2,
+		Fallback: &proxy.Response{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       "[]",
+		},
+	})
+	handler := mw(test.FailWith(http.StatusInternalServerError))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+}
+
+func TestMiddlewareOpensPerTarget(t *testing.T) {
+	var failingHits, healthyHits int
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingHits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyHits++
+	}))
+	defer healthy.Close()
+
+	def := proxy.NewDefinition()
+	def.ListenPath = "/"
+	def.Upstreams.Targets = proxy.Targets{{Target: failing.URL}, {Target: healthy.URL}}
+	b, err := balancer.New("roundrobin")
+	require.NoError(t, err)
+
+	cfg := Config{ConsecutiveFailures: 1}
+	breakers := newTargetBreakers(def, cfg.settings())
+	handler := newCBMiddleware(cfg, breakers)(proxy.NewBalancedReverseProxy(def, b, client.NewNoop()))
+
+	for i := 0; i < 10; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	assert.Equal(t, 1, failingHits)
+	assert.Equal(t, 9, healthyHits)
+	assert.Equal(t, Open, breakers.get(failing.URL).State())
+	assert.Equal(t, Closed, breakers.get(healthy.URL).State())
+
+	statuses := breakers.statuses()
+	require.Len(t, statuses, 2)
+}
+
+func TestMiddlewareBeforeRetry(t *testing.T) {
+	var failingHits, healthyHits int
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingHits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyHits++
+	}))
+	defer healthy.Close()
+
+	def := proxy.NewDefinition()
+	def.ListenPath = "/"
+	def.Upstreams.Targets = proxy.Targets{{Target: failing.URL}, {Target: healthy.URL}}
+	b, err := balancer.New("roundrobin")
+	require.NoError(t, err)
+
+	cfg := Config{ConsecutiveFailures: 1}
+	breakers := newTargetBreakers(def, cfg.settings())
+	retryMiddleware := retry.NewRetryMiddleware(retry.Config{
+		Attempts:   2,
+		Backoff:    retry.Duration(time.Millisecond),
+		MaxBackoff: retry.Duration(time.Millisecond),
+	})
+	handler := newCBMiddleware(cfg, breakers)(retryMiddleware(proxy.NewBalancedReverseProxy(def, b, client.NewNoop())))
+
+	// the failed attempt retried on the other target is counted on the breaker of its own target
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, failingHits)
+	assert.Equal(t, 1, healthyHits)
+	assert.Equal(t, Open, breakers.get(failing.URL).State())
+	assert.Equal(t, Closed, breakers.get(healthy.URL).State())
+
+	for i := 0; i < 4; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	assert.Equal(t, 1, failingHits)
+	assert.Equal(t, 5, healthyHits)
+}
+
+func TestMiddlewareReleasesProbes(t *testing.T) {
+	cfg := Config{ConsecutiveFailures: 1, HalfOpenRequests: 1, Predicate: "unknown == 1"}
+	breakers := newTargetBreakers(nil, cfg.settings())
+	breaker := breakers.get(routeTarget)
+	breaker.Failure()
+	breaker.now = func() time.Time { return time.Now().Add(time.Hour) }
+	require.Equal(t, HalfOpen, breaker.State())
+
+	// the predicate can't be evaluated, the outcome of the request is unknown
+	handler := newCBMiddleware(cfg, breakers)(http.HandlerFunc(test.Ping))
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.True(t, breaker.Ready(), "the probes are given back")
+}
+
+func TestSelectorRejectsOpenTargets(t *testing.T) {
+	def := proxy.NewDefinition()
+	def.Upstreams.Targets = proxy.Targets{{Target: "http://a"}}
+	breakers := newTargetBreakers(def, Config{ConsecutiveFailures: 1, HalfOpenRequests: 1}.settings())
+	breaker := breakers.get("http://a")
+	breaker.Failure()
+	require.Equal(t, Open, breaker.State())
+
+	// the balancer gets the open targets when they all are, but the request is not sent to them
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	selector := &requestSelector{breakers: breakers, fallback: fallback}
+	targets := def.Upstreams.Targets.ToBalancerTargets()
+	assert.Equal(t, targets, selector.Select(targets))
+
+	rejection := selector.Elected("http://a")
+	require.NotNil(t, rejection)
+	assert.Equal(t, ErrCircuitOpen.Message, rejection.Reason)
+	assert.NotNil(t, rejection.Handler)
+	assert.True(t, selector.rejected)
+	assert.False(t, selector.elected)
+}
+
+func TestRegistry(t *testing.T) {
+	r := &registry{apis: make(map[string]*targetBreakers)}
+
+	newAPI := func(name, listenPath string) *api.Definition {
+		def := api.NewDefinition()
+		def.Name = name
+		def.Proxy.ListenPath = listenPath
+		def.Proxy.Upstreams.Targets = proxy.Targets{{Target: "http://" + name}}
+
+		breakers := newTargetBreakers(def.Proxy, Config{}.settings())
+		breakers.api = name
+		breakers.get("http://" + name)
+		r.add(breakers)
+		return def
+	}
+
+	// the APIs sharing a listen path on different hosts have their own breakers
+	recipes := newAPI("recipes", "/recipes")
+	newAPI("recipes-us", "/recipes")
+	newAPI("menus", "/menus")
+
+	statuses := r.statuses()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "menus", statuses[0].API)
+	assert.Equal(t, "recipes", statuses[1].API)
+	assert.Equal(t, "recipes-us", statuses[2].API)
+
+	// the removed APIs and the ones loaded again without the plugin are dropped on reload
+	menusWithoutCB := api.NewDefinition()
+	menusWithoutCB.Name = "menus"
+	r.prune([]*api.Definition{recipes, menusWithoutCB})
+
+	statuses = r.statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "recipes", statuses[0].API)
+}
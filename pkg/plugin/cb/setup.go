@@ -2,34 +2,76 @@ package cb
 
 import (
 	"errors"
-	"fmt"
-	"net/url"
-	"strings"
+	"net/http"
+	"time"
 
-	"github.com/afex/hystrix-go/hystrix"
-	metricCollector "github.com/afex/hystrix-go/hystrix/metric_collector"
-	"github.com/afex/hystrix-go/plugins"
 	"github.com/asaskevich/govalidator"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/render"
 )
 
 const (
 	pluginName = "cb"
+
+	defaultErrorPercentThreshold  = 50
+	defaultRequestVolumeThreshold = 20
+	defaultRollingWindow          = 10000
+	defaultSleepWindow            = 5000
+	defaultHalfOpenRequests       = 1
 )
 
-// Config represents the Body Limit configuration
+// Config represents the Circuit Breaker configuration
 type Config struct {
-	hystrix.CommandConfig
-	Name      string `json:"name"`
-	Predicate string `json:"predicate"`
+	// ConsecutiveFailures opens the circuit of a target after this number of failures in a row, zero disables it
+	ConsecutiveFailures    int `json:"consecutive_failures"`
+	ErrorPercentThreshold  int `json:"error_percent_threshold"`
+	RequestVolumeThreshold int `json:"request_volume_threshold"`
+	// RollingWindow is the period, in milliseconds, the error percentage is computed over
+	RollingWindow int `json:"rolling_window"`
+	// SleepWindow is how long, in milliseconds, a circuit stays open before probing the target
+	SleepWindow      int    `json:"sleep_window"`
+	HalfOpenRequests int    `json:"half_open_requests"`
+	Predicate        string `json:"predicate"`
+	// Fallback is the response sent when the circuits of all the targets are open, the proxy fallback is used when not set
+	Fallback *proxy.Response `json:"fallback"`
+}
+
+// settings returns the breaker settings of the config, with the defaults of the missing values
+func (c Config) settings() Settings {
+	s := Settings{
+		ConsecutiveFailures:    c.ConsecutiveFailures,
+		ErrorPercentThreshold:  c.ErrorPercentThreshold,
+		RequestVolumeThreshold: c.RequestVolumeThreshold,
+		RollingWindow:          time.Duration(c.RollingWindow) * time.Millisecond,
+		SleepWindow:            time.Duration(c.SleepWindow) * time.Millisecond,
+		HalfOpenRequests:       c.HalfOpenRequests,
+	}
+
+	if s.ErrorPercentThreshold <= 0 && s.ConsecutiveFailures <= 0 {
+		s.ErrorPercentThreshold = defaultErrorPercentThreshold
+	}
+	if s.RequestVolumeThreshold <= 0 {
+		s.RequestVolumeThreshold = defaultRequestVolumeThreshold
+	}
+	if s.RollingWindow <= 0 {
+		s.RollingWindow = defaultRollingWindow * time.Millisecond
+	}
+	if s.SleepWindow <= 0 {
+		s.SleepWindow = defaultSleepWindow * time.Millisecond
+	}
+	if s.HalfOpenRequests <= 0 {
+		s.HalfOpenRequests = defaultHalfOpenRequests
+	}
+
+	return s
 }
 
 func init() {
-	plugin.RegisterEventHook(plugin.StartupEvent, onStartup)
 	plugin.RegisterEventHook(plugin.AdminAPIStartupEvent, onAdminAPIStartup)
+	plugin.RegisterEventHook(plugin.ReloadEvent, onReload)
 	plugin.RegisterPlugin(pluginName, plugin.Plugin{
 		Action:   setupCB,
 		Validate: validateConfig,
@@ -46,17 +88,20 @@ func setupCB(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
 	log.WithFields(log.Fields{
 		"plugin_event": plugin.SetupEvent,
 		"plugin":       pluginName,
-		"name":         c.Name,
+		"listen_path":  def.ListenPath,
 	}).Debug("Configuring cb plugin")
 
-	hystrix.ConfigureCommand(c.Name, hystrix.CommandConfig{
-		Timeout:               c.Timeout,
-		MaxConcurrentRequests: c.MaxConcurrentRequests,
-		ErrorPercentThreshold: c.ErrorPercentThreshold,
-		SleepWindow:           c.SleepWindow,
-	})
+	if c.Fallback == nil {
+		c.Fallback = def.Fallback
+	}
+
+	breakers := newTargetBreakers(def.Definition, c.settings())
+	if def.Name != "" {
+		breakers.api = def.Name
+		breakersRegistry.add(breakers)
+	}
 
-	def.AddMiddleware(NewCBMiddleware(c))
+	def.AddMiddleware(newCBMiddleware(c, breakers))
 	return nil
 }
 
@@ -67,56 +112,41 @@ func validateConfig(rawConfig plugin.Config) (bool, error) {
 		return false, err
 	}
 
+	if config.Fallback != nil {
+		if err := config.Fallback.Validate(); err != nil {
+			return false, err
+		}
+	}
+
 	return govalidator.ValidateStruct(config)
 }
 
-func onAdminAPIStartup(event interface{}) error {
-	logger := log.WithFields(log.Fields{
-		"plugin_event": plugin.AdminAPIStartupEvent,
-		"plugin":       pluginName,
-	})
-
-	e, ok := event.(plugin.OnAdminAPIStartup)
+// onReload drops the breakers of the APIs that are gone, the plugin was set up again for the other ones
+func onReload(event interface{}) error {
+	e, ok := event.(plugin.OnReload)
 	if !ok {
-		return errors.New("could not convert event to admin startup type")
+		return errors.New("could not convert event to reload type")
 	}
 
-	logger.Debug("Registering hystrix stream endpoint")
-	hystrixStreamHandler := hystrix.NewStreamHandler()
-	hystrixStreamHandler.Start()
-
-	e.Router.GET("/hystrix", hystrixStreamHandler.ServeHTTP)
+	breakersRegistry.prune(e.Configurations)
 	return nil
 }
 
-func onStartup(event interface{}) error {
+func onAdminAPIStartup(event interface{}) error {
 	logger := log.WithFields(log.Fields{
-		"plugin_event": plugin.StartupEvent,
+		"plugin_event": plugin.AdminAPIStartupEvent,
 		"plugin":       pluginName,
 	})
 
-	e, ok := event.(plugin.OnStartup)
+	e, ok := event.(plugin.OnAdminAPIStartup)
 	if !ok {
-		return errors.New("could not convert event to startup type")
-	}
-
-	logger.WithField("metrics_dsn", e.Config.Stats.DSN).Debug("Statsd metrics enabled")
-
-	dsnURL, err := url.Parse(e.Config.Stats.DSN)
-	if err != nil {
-		return fmt.Errorf("could not parse stats dsn: %w", err)
+		return errors.New("could not convert event to admin startup type")
 	}
 
-	c, err := plugins.InitializeStatsdCollector(&plugins.StatsdCollectorConfig{
-		StatsdAddr: dsnURL.Host,
-		Prefix:     strings.Trim(dsnURL.Path, "/"),
+	logger.Debug("Registering circuit breakers endpoint")
+	e.Router.GET("/circuit-breakers", func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, http.StatusOK, breakersRegistry.statuses())
 	})
-	if err != nil {
-		return fmt.Errorf("could not initialize statsd client: %w", err)
-	}
-
-	metricCollector.Registry.Register(c.NewStatsdCollector)
-	logger.Debug("Metrics enabled")
 
 	return nil
 }
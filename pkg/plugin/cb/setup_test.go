@@ -1,9 +1,10 @@
 package cb
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
-	"github.com/hellofresh/janus/pkg/config"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/janus/pkg/router"
@@ -34,14 +35,6 @@ func TestCbPlugin(t *testing.T) {
 			scenario: "when the plugin admin startup is successful",
 			function: testAdminStartupSuccess,
 		},
-		{
-			scenario: "when the plugin startup is successful",
-			function: testStartupSuccess,
-		},
-		{
-			scenario: "when the plugin startup is not successful",
-			function: testStartupNoSuccess,
-		},
 	}
 
 	for _, test := range tests {
@@ -51,32 +44,15 @@ func TestCbPlugin(t *testing.T) {
 	}
 }
 
-func testStartupNoSuccess(t *testing.T) {
-	event2 := plugin.OnStartup{
-		Register: proxy.NewRegister(proxy.WithRouter(router.NewChiRouter())),
-		Config: &config.Specification{
-			Stats: config.Stats{
-				DSN: "statsd://statsd:8080",
-			},
-		},
-	}
-	err := onStartup(event2)
-	require.Error(t, err)
-}
-
-func testStartupSuccess(t *testing.T) {
-	event2 := plugin.OnStartup{
-		Register: proxy.NewRegister(proxy.WithRouter(router.NewChiRouter())),
-		Config:   &config.Specification{},
-	}
-	err := onStartup(event2)
-	require.NoError(t, err)
-}
-
 func testAdminStartupSuccess(t *testing.T) {
-	event1 := plugin.OnAdminAPIStartup{Router: router.NewChiRouter()}
+	r := router.NewChiRouter()
+	event1 := plugin.OnAdminAPIStartup{Router: r}
 	err := onAdminAPIStartup(event1)
 	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/circuit-breakers", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
 }
 
 func testSetupSuccess(t *testing.T) {
@@ -88,11 +64,16 @@ func testSetupSuccess(t *testing.T) {
 
 func testSetupWithCorrectConfig(t *testing.T) {
 	rawConfig := map[string]interface{}{
-		"timeout":                 1000,
-		"max_concurrent_requests": 100,
+		"consecutive_failures":    5,
 		"error_percent_threshold": 25,
+		"rolling_window":          10000,
 		"sleep_window":            1,
+		"half_open_requests":      2,
 		"predicate":               "statusCode => 500",
+		"fallback": map[string]interface{}{
+			"status_code": 200,
+			"body":        "[]",
+		},
 	}
 
 	result, err := validateConfig(rawConfig)
@@ -102,7 +83,7 @@ func testSetupWithCorrectConfig(t *testing.T) {
 
 func testSetupWithIncorrectConfig(t *testing.T) {
 	rawConfig := map[string]interface{}{
-		"timeout": "wrong",
+		"sleep_window": "wrong",
 	}
 
 	result, err := validateConfig(rawConfig)
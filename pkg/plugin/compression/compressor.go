@@ -0,0 +1,344 @@
+package compression
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/felixge/httpsnoop"
+	"github.com/klauspost/compress/gzip"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+const (
+	defaultMinSize        = "1K"
+	defaultMaxRequestSize = "10M"
+)
+
+// defaultContentTypes are the text based media types, the other ones are often already compressed
+var defaultContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/*+json",
+	"application/javascript",
+	"application/x-javascript",
+	"application/xml",
+	"application/*+xml",
+	"application/graphql",
+	"application/x-ndjson",
+	"image/svg+xml",
+}
+
+var (
+	// ErrInvalidRequestBody is used when the request body can't be decompressed
+	ErrInvalidRequestBody = errors.New(http.StatusBadRequest, "the request body is not valid gzip")
+	// ErrRequestEntityTooLarge is used when the decompressed request body is bigger than the max_request_size
+	ErrRequestEntityTooLarge = errors.New(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+)
+
+// compressor compresses the responses with the encoding the client prefers, and decompresses the requests
+type compressor struct {
+	encodings          []string
+	encoders           map[string]*encoders
+	minSize            int
+	contentTypes       []string
+	decompressRequests bool
+	maxRequestSize     int64
+}
+
+func newCompressor(config Config) (*compressor, error) {
+	c := &compressor{
+		encodings:          config.Encodings,
+		encoders:           make(map[string]*encoders),
+		contentTypes:       config.ContentTypes,
+		decompressRequests: config.DecompressRequests,
+	}
+	if len(c.encodings) == 0 {
+		c.encodings = defaultEncodings
+	}
+	if len(c.contentTypes) == 0 {
+		c.contentTypes = defaultContentTypes
+	}
+
+	level := config.Level
+	switch level {
+	case "":
+		level = levelDefault
+	case levelFastest, levelDefault, levelBest:
+	default:
+		return nil, fmt.Errorf("level must be %q, %q or %q", levelFastest, levelDefault, levelBest)
+	}
+
+	for _, encoding := range c.encodings {
+		e, err := newEncoders(encoding, level)
+		if err != nil {
+			return nil, err
+		}
+		c.encoders[encoding] = e
+	}
+
+	for _, contentType := range c.contentTypes {
+		if _, err := path.Match(contentType, ""); err != nil {
+			return nil, fmt.Errorf("invalid content type %q: %w", contentType, err)
+		}
+	}
+
+	size, err := toBytes(config.MinSize, defaultMinSize)
+	if err != nil {
+		return nil, fmt.Errorf("min_size: %w", err)
+	}
+	c.minSize = int(size)
+
+	if c.maxRequestSize, err = toBytes(config.MaxRequestSize, defaultMaxRequestSize); err != nil {
+		return nil, fmt.Errorf("max_request_size: %w", err)
+	}
+
+	return c, nil
+}
+
+func toBytes(size, defaultSize string) (int64, error) {
+	if size == "" {
+		size = defaultSize
+	}
+	b, err := bytefmt.ToBytes(size)
+	return int64(b), err
+}
+
+// Handler is the middleware function
+func (c *compressor) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.decompressRequests {
+			if err := c.decompressRequest(r); err != nil {
+				errors.Handler(w, r, err)
+				return
+			}
+		}
+
+		cw := &compressWriter{
+			compressor: c,
+			w:          w,
+			encoding:   negotiate(r.Header.Get("Accept-Encoding"), c.encodings),
+			head:       r.Method == http.MethodHead,
+		}
+		defer cw.close()
+
+		next.ServeHTTP(cw.wrap(w), r)
+	})
+}
+
+// decompressRequest replaces a gzip request body by its content, for the upstreams that can't decompress it
+func (c *compressor) decompressRequest(r *http.Request) error {
+	if !strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), encodingGzip) || r.Body == nil {
+		return nil
+	}
+
+	reader, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return ErrInvalidRequestBody
+	}
+	defer reader.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(reader, c.maxRequestSize+1))
+	if err != nil {
+		return ErrInvalidRequestBody
+	}
+	if int64(len(body)) > c.maxRequestSize {
+		return ErrRequestEntityTooLarge
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Del("Content-Encoding")
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// compressible checks whether a response of the given media type can be compressed
+func (c *compressor) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range c.contentTypes {
+		if ok, _ := path.Match(pattern, mediaType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds the beginning of the response until it knows whether to compress it:
+// when its headers rule it out, when it reaches the min_size, when it is flushed or when it ends
+type compressWriter struct {
+	*compressor
+	w        http.ResponseWriter
+	encoding string
+	head     bool
+
+	status  int
+	decided bool
+	buf     []byte
+	encoder encoder
+}
+
+func (cw *compressWriter) wrap(w http.ResponseWriter) http.ResponseWriter {
+	return httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return cw.writeHeader
+		},
+		Write: func(httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return cw.write
+		},
+		Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+			return func() {
+				if !cw.decided {
+					cw.decide(true)
+				}
+				if cw.encoder != nil {
+					if err := cw.encoder.Flush(); err != nil {
+						log.WithError(err).Warn("Could not flush the compressed response")
+					}
+				}
+				next()
+			}
+		},
+		// The bodies copied from a reader have to go through the encoder too
+		ReadFrom: func(httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+			return func(src io.Reader) (int64, error) {
+				return io.Copy(writerFunc(cw.write), src)
+			}
+		},
+	})
+}
+
+func (cw *compressWriter) writeHeader(code int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+
+	// The informational responses are followed by the actual one
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		cw.w.WriteHeader(code)
+		return
+	}
+
+	cw.status = code
+	if code == http.StatusNoContent || code == http.StatusNotModified || code == http.StatusSwitchingProtocols {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressWriter) write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) >= cw.minSize || cw.headerRulesOut() {
+			cw.decide(true)
+		}
+		return len(b), nil
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+	return cw.w.Write(b)
+}
+
+// headerRulesOut checks whether the response can't be compressed, whatever its size
+func (cw *compressWriter) headerRulesOut() bool {
+	h := cw.w.Header()
+	if cw.encoding == "" || cw.head {
+		return true
+	}
+	// The responses already encoded by the upstreams are never compressed twice
+	if ce := h.Get("Content-Encoding"); ce != "" && !strings.EqualFold(ce, "identity") {
+		return true
+	}
+	if strings.Contains(strings.ToLower(h.Get("Cache-Control")), "no-transform") {
+		return true
+	}
+	contentType := h.Get("Content-Type")
+	return contentType != "" && !cw.compressible(contentType)
+}
+
+// decide writes the headers, compressing the response when it is big enough and of a compressible type
+func (cw *compressWriter) decide(bigEnough bool) {
+	cw.decided = true
+	h := cw.w.Header()
+
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" && len(cw.buf) > 0 {
+		contentType = http.DetectContentType(cw.buf)
+	}
+
+	typeAllowed := cw.compressible(contentType)
+	alreadyEncoded := h.Get("Content-Encoding") != "" && !strings.EqualFold(h.Get("Content-Encoding"), "identity")
+	if typeAllowed && !alreadyEncoded {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil {
+		bigEnough = n >= cw.minSize
+	}
+
+	if bigEnough && typeAllowed && !cw.headerRulesOut() && cw.status != http.StatusNoContent && cw.status != http.StatusNotModified {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		// The compressed body is not the byte-for-byte representation the strong ETag identifies
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		cw.encoder = cw.encoders[cw.encoding].get(cw.w)
+	}
+
+	cw.w.WriteHeader(cw.status)
+
+	if len(cw.buf) > 0 {
+		buf := cw.buf
+		cw.buf = nil
+		if _, err := cw.write(buf); err != nil {
+			log.WithError(err).Warn("Could not write the response")
+		}
+	}
+}
+
+// close ends the response, the ones that are not written yet are smaller than the min_size
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return
+		}
+		cw.decide(false)
+	}
+
+	if cw.encoder != nil {
+		if err := cw.encoder.Close(); err != nil {
+			log.WithError(err).Warn("Could not compress the response")
+		}
+		cw.encoders[cw.encoding].put(cw.encoder)
+		cw.encoder = nil
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) {
+	return f(b)
+}
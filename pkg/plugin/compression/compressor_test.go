@@ -0,0 +1,227 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var body = strings.Repeat(`{"name": "Chicken curry", "ingredients": ["chicken", "rice", "curry"]}`, 50)
+
+func serve(t *testing.T, config Config, r *http.Request, upstream http.HandlerFunc) *httptest.ResponseRecorder {
+	c, err := newCompressor(config)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c.Handler(upstream).ServeHTTP(w, r)
+	return w
+}
+
+func respond(contentType, content string, headers ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		for i := 0; i < len(headers); i += 2 {
+			w.Header().Set(headers[i], headers[i+1])
+		}
+		io.WriteString(w, content)
+	}
+}
+
+func accepting(encoding string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", encoding)
+	return r
+}
+
+func decode(t *testing.T, encoding string, data []byte) string {
+	var (
+		reader io.Reader
+		err    error
+	)
+	switch encoding {
+	case encodingGzip:
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case encodingBrotli:
+		reader = brotli.NewReader(bytes.NewReader(data))
+	case encodingZstd:
+		reader, err = zstd.NewReader(bytes.NewReader(data))
+	}
+	require.NoError(t, err)
+
+	decoded, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	return string(decoded)
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"gzip;q=1.0, br;q=0.5, zstd;q=0.8", "gzip"},
+		{"br;q=0, gzip;q=0.1", "gzip"},
+		{"*", "br"},
+		{"*;q=0.5, zstd", "zstd"},
+		{"identity", ""},
+		{"x-gzip", "gzip"},
+		{"br;q=invalid, gzip", "gzip"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, negotiate(tt.acceptEncoding, defaultEncodings), tt.acceptEncoding)
+	}
+
+	assert.Equal(t, "gzip", negotiate("br, gzip", []string{"gzip", "br"}))
+}
+
+func TestCompress(t *testing.T) {
+	for _, encoding := range defaultEncodings {
+		t.Run(encoding, func(t *testing.T) {
+			w := serve(t, Config{}, accepting(encoding), respond("application/json; charset=utf-8", body, "Content-Length", "3550", "ETag", `"abc"`))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			assert.Equal(t, `W/"abc"`, w.Header().Get("ETag"))
+			assert.Empty(t, w.Header().Get("Content-Length"))
+			assert.Less(t, w.Body.Len(), len(body))
+			assert.Equal(t, body, decode(t, encoding, w.Body.Bytes()))
+		})
+	}
+}
+
+func TestCompressSkipped(t *testing.T) {
+	short := `{"name": "Curry"}`
+	tests := []struct {
+		name           string
+		config         Config
+		method         string
+		acceptEncoding string
+		upstream       http.HandlerFunc
+		content        string
+		vary           bool
+	}{
+		{"not accepted", Config{}, http.MethodGet, "identity", respond("application/json", body), body, true},
+		{"not configured", Config{Encodings: []string{"gzip"}}, http.MethodGet, "br", respond("application/json", body), body, true},
+		{"below min size", Config{}, http.MethodGet, "gzip", respond("application/json", short), short, true},
+		{"below min size by content length", Config{MinSize: "4K"}, http.MethodGet, "gzip", respond("application/json", body, "Content-Length", "3550"), body, true},
+		{"content type not allowed", Config{}, http.MethodGet, "gzip", respond("image/png", body), body, false},
+		{"content type not in the list", Config{ContentTypes: []string{"text/*"}}, http.MethodGet, "gzip", respond("application/json", body), body, false},
+		{"already encoded", Config{}, http.MethodGet, "gzip", respond("application/json", body, "Content-Encoding", "br"), body, false},
+		{"no transform", Config{}, http.MethodGet, "gzip", respond("application/json", body, "Cache-Control", "no-transform"), body, true},
+		{"head request", Config{}, http.MethodHead, "gzip", respond("application/json", ""), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			r.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := serve(t, tt.config, r, tt.upstream)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NotEqual(t, tt.acceptEncoding, w.Header().Get("Content-Encoding"))
+			assert.Equal(t, tt.content, w.Body.String())
+			if tt.vary {
+				assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			} else {
+				assert.Empty(t, w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCompressStatus(t *testing.T) {
+	w := serve(t, Config{}, accepting("gzip"), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, body)
+	})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, body, decode(t, encodingGzip, w.Body.Bytes()))
+
+	w = serve(t, Config{}, accepting("gzip"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+
+	// The content type is sniffed when the upstream doesn't set it
+	w = serve(t, Config{MinSize: "1B"}, accepting("gzip"), func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html><body>Recipes</body></html>")
+	})
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "<html><body>Recipes</body></html>", decode(t, encodingGzip, w.Body.Bytes()))
+}
+
+func TestCompressFlush(t *testing.T) {
+	c, err := newCompressor(Config{})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+
+		// The flushed part of the stream can be decoded before the end of the response
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		reader, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+		require.NoError(t, err)
+		chunk := make([]byte, 9)
+		_, err = io.ReadFull(reader, chunk)
+		require.NoError(t, err)
+		assert.Equal(t, "data: 1\n\n", string(chunk))
+
+		io.WriteString(w, "data: 2\n\n")
+	})).ServeHTTP(rec, accepting("gzip"))
+
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", decode(t, encodingGzip, rec.Body.Bytes()))
+}
+
+func TestDecompressRequests(t *testing.T) {
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	io.WriteString(gw, body)
+	gw.Close()
+
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Content-Encoding"))
+		assert.Equal(t, int64(len(body)), r.ContentLength)
+		io.Copy(w, r.Body)
+	}
+	request := func(content []byte) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(content))
+		r.Header.Set("Content-Encoding", "gzip")
+		return r
+	}
+
+	w := serve(t, Config{DecompressRequests: true}, request(compressed.Bytes()), echo)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, body, w.Body.String())
+
+	w = serve(t, Config{DecompressRequests: true}, request([]byte(body)), echo)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(t, Config{DecompressRequests: true, MaxRequestSize: "1K"}, request(compressed.Bytes()), echo)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// The requests are left compressed by default
+	w = serve(t, Config{}, request(compressed.Bytes()), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
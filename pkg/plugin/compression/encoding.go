@@ -0,0 +1,133 @@
+package compression
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingBrotli = "br"
+	encodingZstd   = "zstd"
+	encodingGzip   = "gzip"
+
+	levelFastest = "fastest"
+	levelDefault = "default"
+	levelBest    = "best"
+)
+
+var defaultEncodings = []string{encodingBrotli, encodingZstd, encodingGzip}
+
+// encoder is a compressing writer that can be reused for another response
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoders pools the encoders of an encoding, creating them is expensive for zstd and brotli
+type encoders struct {
+	pool sync.Pool
+}
+
+func newEncoders(encoding, level string) (*encoders, error) {
+	var newEncoder func() encoder
+
+	switch encoding {
+	case encodingGzip:
+		l := map[string]int{levelFastest: gzip.BestSpeed, levelDefault: gzip.DefaultCompression, levelBest: gzip.BestCompression}[level]
+		newEncoder = func() encoder {
+			w, _ := gzip.NewWriterLevel(ioutil.Discard, l)
+			return w
+		}
+	case encodingBrotli:
+		l := map[string]int{levelFastest: brotli.BestSpeed, levelDefault: brotli.DefaultCompression, levelBest: brotli.BestCompression}[level]
+		newEncoder = func() encoder {
+			return brotli.NewWriterLevel(ioutil.Discard, l)
+		}
+	case encodingZstd:
+		l := map[string]zstd.EncoderLevel{levelFastest: zstd.SpeedFastest, levelDefault: zstd.SpeedDefault, levelBest: zstd.SpeedBestCompression}[level]
+		newEncoder = func() encoder {
+			w, _ := zstd.NewWriter(ioutil.Discard, zstd.WithEncoderLevel(l), zstd.WithEncoderConcurrency(1))
+			return w
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q, must be one of %s", encoding, strings.Join(defaultEncodings, ", "))
+	}
+
+	e := &encoders{}
+	e.pool.New = func() interface{} { return newEncoder() }
+	return e, nil
+}
+
+func (e *encoders) get(w io.Writer) encoder {
+	enc := e.pool.Get().(encoder)
+	enc.Reset(w)
+	return enc
+}
+
+func (e *encoders) put(enc encoder) {
+	enc.Reset(ioutil.Discard)
+	e.pool.Put(enc)
+}
+
+// negotiate picks the encoding of the response from the Accept-Encoding header of the request,
+// the one with the highest q-value or the first of the encodings when several have the same
+func negotiate(acceptEncoding string, encodings []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseCoding(part)
+		if name == "x-gzip" {
+			name = encodingGzip
+		}
+		if name != "" {
+			accepted[name] = q
+		}
+	}
+
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, encoding := range encodings {
+		q, ok := accepted[encoding]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// parseCoding parses a coding of the Accept-Encoding header, such as "gzip;q=0.8"
+func parseCoding(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	name := strings.ToLower(strings.TrimSpace(params[0]))
+
+	q := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if !strings.HasPrefix(strings.ToLower(param), "q=") {
+			continue
+		}
+		v, err := strconv.ParseFloat(param[2:], 64)
+		if err != nil || v < 0 || v > 1 {
+			return "", 0
+		}
+		q = v
+	}
+	return name, q
+}
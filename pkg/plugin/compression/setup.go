@@ -1,18 +1,60 @@
 package compression
 
 import (
-	"github.com/go-chi/chi/middleware"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 )
 
+// Config represents the compression configuration
+type Config struct {
+	// Encodings are the encodings the responses are compressed with, the first ones are preferred
+	// when the client accepts several of them as much. Defaults to br, zstd and gzip
+	Encodings []string `json:"encodings"`
+	// Level is the compression level: fastest, default or best
+	Level string `json:"level"`
+	// MinSize is the size under which the responses are not compressed. Defaults to 1K
+	MinSize string `json:"min_size"`
+	// ContentTypes are the media types of the responses compressed, with * wildcards
+	ContentTypes []string `json:"content_types"`
+	// DecompressRequests decompresses the gzip request bodies before proxying them
+	DecompressRequests bool `json:"decompress_requests"`
+	// MaxRequestSize is the maximum size of the decompressed request bodies. Defaults to 10M
+	MaxRequestSize string `json:"max_request_size"`
+}
+
 func init() {
 	plugin.RegisterPlugin("compression", plugin.Plugin{
-		Action: setupCompression,
+		Action:   setupCompression,
+		Validate: validateConfig,
 	})
 }
 
 func setupCompression(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
-	def.AddMiddleware(middleware.DefaultCompress)
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return err
+	}
+
+	c, err := newCompressor(config)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(c.Handler)
 	return nil
 }
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := newCompressor(config); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
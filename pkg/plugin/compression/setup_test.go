@@ -15,3 +15,48 @@ func TestSetup(t *testing.T) {
 
 	assert.Len(t, def.Middleware(), 1)
 }
+
+func TestCompressionConfig(t *testing.T) {
+	var config Config
+	rawConfig := map[string]interface{}{
+		"encodings":           []string{"gzip", "br"},
+		"level":               "best",
+		"min_size":            "2K",
+		"content_types":       []string{"application/json"},
+		"decompress_requests": true,
+		"max_request_size":    "1M",
+	}
+
+	err := plugin.Decode(rawConfig, &config)
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Encodings:          []string{"gzip", "br"},
+		Level:              "best",
+		MinSize:            "2K",
+		ContentTypes:       []string{"application/json"},
+		DecompressRequests: true,
+		MaxRequestSize:     "1M",
+	}, config)
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	assert.NoError(t, setupCompression(def, rawConfig))
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func TestCompressionValidation(t *testing.T) {
+	for _, rawConfig := range []plugin.Config{
+		{"encodings": []string{"deflate"}},
+		{"level": "9"},
+		{"min_size": "small"},
+		{"content_types": []string{"text/["}},
+		{"max_request_size": "-1"},
+	} {
+		valid, err := validateConfig(rawConfig)
+		assert.False(t, valid)
+		assert.Error(t, err)
+	}
+
+	valid, err := validateConfig(plugin.Config{"encodings": []string{"zstd"}, "level": "fastest"})
+	assert.True(t, valid)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,197 @@
+package consumer
+
+import (
+	"encoding/json"
+
+	"github.com/hellofresh/janus/cassandra/wrapper"
+	log "github.com/sirupsen/logrus"
+)
+
+// CassandraRepository represents a cassandra repository
+type CassandraRepository struct {
+	session wrapper.Holder
+}
+
+// NewCassandraRepository creates a cassandra consumer repo
+func NewCassandraRepository(session wrapper.Holder) (*CassandraRepository, error) {
+	log.Debugf("getting new consumer cassandra repo")
+	return &CassandraRepository{session: session}, nil
+}
+
+// FindAll fetches all the consumers available
+func (r *CassandraRepository) FindAll() ([]*Consumer, error) {
+	log.Debugf("finding all consumers")
+
+	var results []*Consumer
+
+	iter := r.session.GetSession().Query("SELECT consumer FROM consumer").Iter()
+
+	var savedConsumer string
+
+	err := iter.ScanAndClose(func() bool {
+		consumer := NewConsumer()
+		if err := json.Unmarshal([]byte(savedConsumer), consumer); err != nil {
+			log.Errorf("error trying to unmarshal consumer json: %v", err)
+			return false
+		}
+		results = append(results, consumer)
+		return true
+	}, &savedConsumer)
+
+	if err != nil {
+		log.Errorf("error getting all consumers: %v", err)
+	}
+	return results, err
+}
+
+// FindByUsername find a consumer by username
+// returns ErrConsumerNotFound when a consumer is not found.
+func (r *CassandraRepository) FindByUsername(username string) (*Consumer, error) {
+	log.Debugf("finding: %s", username)
+
+	var savedConsumer string
+
+	err := r.session.GetSession().Query(
+		"SELECT consumer "+
+			"FROM consumer "+
+			"WHERE username = ?",
+		username).Scan(&savedConsumer)
+
+	if err != nil {
+		if err.Error() == "not found" {
+			log.Debugf("consumer not found")
+			return nil, ErrConsumerNotFound
+		}
+		log.Errorf("error selecting consumer %s: %v", username, err)
+		return nil, err
+	}
+
+	consumer := NewConsumer()
+	if err = json.Unmarshal([]byte(savedConsumer), consumer); err != nil {
+		log.Errorf("error trying to unmarshal consumer json: %v", err)
+		return nil, err
+	}
+
+	log.Debugf("successfully found consumer %s", username)
+	return consumer, nil
+}
+
+// FindByCredential find the consumer that owns the given credential
+// returns ErrConsumerNotFound when no consumer owns the credential.
+func (r *CassandraRepository) FindByCredential(credential *Credential) (*Consumer, error) {
+	log.Debugf("finding credential of type %s", credential.Type)
+
+	var username string
+
+	err := r.session.GetSession().Query(
+		"SELECT username "+
+			"FROM consumer_credential "+
+			"WHERE type = ? AND key = ?",
+		string(credential.Type), credential.Key).Scan(&username)
+
+	if err != nil {
+		if err.Error() == "not found" {
+			log.Debugf("credential not found")
+			return nil, ErrConsumerNotFound
+		}
+		log.Errorf("error selecting credential: %v", err)
+		return nil, err
+	}
+
+	return r.FindByUsername(username)
+}
+
+// Add adds a consumer to the repository, replacing the existing one with the same username
+func (r *CassandraRepository) Add(consumer *Consumer) error {
+	log.Debugf("adding: %s", consumer.Username)
+
+	for _, credential := range consumer.Credentials {
+		owner, err := r.FindByCredential(credential)
+		if err == nil && owner.Username != consumer.Username {
+			return ErrCredentialExists
+		}
+		if err != nil && err != ErrConsumerNotFound {
+			return err
+		}
+	}
+
+	existing, err := r.FindByUsername(consumer.Username)
+	if err != nil && err != ErrConsumerNotFound {
+		return err
+	}
+	if existing != nil {
+		if err := r.removeCredentials(existing); err != nil {
+			return err
+		}
+	}
+
+	savedConsumer, err := json.Marshal(consumer)
+	if err != nil {
+		log.Errorf("error marshaling consumer: %v", err)
+		return err
+	}
+
+	err = r.session.GetSession().Query(
+		"UPDATE consumer "+
+			"SET consumer = ? "+
+			"WHERE username = ?",
+		string(savedConsumer), consumer.Username).Exec()
+	if err != nil {
+		log.Errorf("error saving consumer %s: %v", consumer.Username, err)
+		return err
+	}
+
+	for _, credential := range consumer.Credentials {
+		err = r.session.GetSession().Query(
+			"UPDATE consumer_credential "+
+				"SET username = ? "+
+				"WHERE type = ? AND key = ?",
+			consumer.Username, string(credential.Type), credential.Key).Exec()
+		if err != nil {
+			log.Errorf("error saving credential for consumer %s: %v", consumer.Username, err)
+			return err
+		}
+	}
+
+	log.Debugf("successfully saved consumer %s", consumer.Username)
+	return nil
+}
+
+// Remove removes a consumer from the repository
+func (r *CassandraRepository) Remove(username string) error {
+	log.Debugf("removing: %s", username)
+
+	existing, err := r.FindByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	if err := r.removeCredentials(existing); err != nil {
+		return err
+	}
+
+	err = r.session.GetSession().Query(
+		"DELETE FROM consumer WHERE username = ?", username).Exec()
+
+	if err != nil {
+		log.Errorf("error removing consumer %s: %v", username, err)
+	} else {
+		log.Debugf("successfully removed consumer %s", username)
+	}
+
+	return err
+}
+
+func (r *CassandraRepository) removeCredentials(consumer *Consumer) error {
+	for _, credential := range consumer.Credentials {
+		err := r.session.GetSession().Query(
+			"DELETE FROM consumer_credential WHERE type = ? AND key = ?",
+			string(credential.Type), credential.Key).Exec()
+		if err != nil {
+			log.Errorf("error removing credential of consumer %s: %v", consumer.Username, err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"fmt"
+
+	"github.com/asaskevich/govalidator"
+)
+
+// CredentialType is the kind of credential that identifies a consumer
+type CredentialType string
+
+const (
+	// BasicCredential is a username used with HTTP basic authentication
+	BasicCredential CredentialType = "basic"
+	// APIKeyCredential is an opaque API key
+	APIKeyCredential CredentialType = "api_key"
+	// JWTCredential is the subject (`sub` claim) of a JWT
+	JWTCredential CredentialType = "jwt"
+	// MTLSCredential is the subject common name of a client certificate
+	MTLSCredential CredentialType = "mtls"
+	// HMACCredential is the key ID of a shared secret used to sign requests
+	HMACCredential CredentialType = "hmac"
+)
+
+// Consumer represents the identity behind a request, shared by all the auth plugins
+type Consumer struct {
+	Username    string        `bson:"username" json:"username" valid:"required~username is required"`
+	CustomID    string        `bson:"custom_id" json:"custom_id"`
+	Groups      []string      `bson:"groups" json:"groups"`
+	Credentials []*Credential `bson:"credentials" json:"credentials"`
+}
+
+// Credential attaches an authentication secret of a given type to a consumer
+type Credential struct {
+	Type   CredentialType `bson:"type" json:"type" valid:"in(basic|api_key|jwt|mtls|hmac)~credential type is not supported"`
+	Key    string         `bson:"key" json:"key" valid:"required~credential key is required"`
+	Secret string         `bson:"secret,omitempty" json:"secret,omitempty"`
+}
+
+// Repository defines the behavior of a consumer repository
+type Repository interface {
+	FindAll() ([]*Consumer, error)
+	FindByUsername(username string) (*Consumer, error)
+	FindByCredential(credential *Credential) (*Consumer, error)
+	Add(consumer *Consumer) error
+	Remove(username string) error
+}
+
+// NewConsumer creates a new instance of Consumer
+func NewConsumer() *Consumer {
+	return &Consumer{Groups: make([]string, 0), Credentials: make([]*Credential, 0)}
+}
+
+// NewCredential creates a new credential of the given type
+func NewCredential(t CredentialType, key string) *Credential {
+	return &Credential{Type: t, Key: key}
+}
+
+// Validate validates consumer data
+func (c *Consumer) Validate() (bool, error) {
+	return govalidator.ValidateStruct(c)
+}
+
+// Credential returns the consumer credential of the given type and key
+func (c *Consumer) Credential(t CredentialType, key string) (*Credential, bool) {
+	for _, credential := range c.Credentials {
+		if credential.Type == t && credential.Key == key {
+			return credential, true
+		}
+	}
+
+	return nil, false
+}
+
+// InGroup checks if the consumer is member of any of the given groups
+func (c *Consumer) InGroup(groups ...string) bool {
+	for _, member := range c.Groups {
+		for _, group := range groups {
+			if member == group {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// String returns the index key for the credential
+func (c *Credential) String() string {
+	return fmt.Sprintf("%s:%s", c.Type, c.Key)
+}
@@ -0,0 +1,128 @@
+package consumer
+
+import (
+	"context"
+	"net/http"
+
+	obs "github.com/hellofresh/janus/pkg/observability"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/tag"
+)
+
+type consumerKeyType int
+
+// anonymousTag tags the requests of the anonymous consumers in the metrics, their names are chosen by the clients
+const anonymousTag = "anonymous"
+
+const (
+	consumerKey consumerKeyType = iota
+	claimsKey
+)
+
+// NewContext puts the authenticated consumer to context for future use
+func NewContext(ctx context.Context, consumer *Consumer) context.Context {
+	if ctx == nil {
+		panic("Can not put consumer to empty context")
+	}
+
+	return context.WithValue(ctx, consumerKey, consumer)
+}
+
+// FromContext tries to extract the authenticated consumer from context if present
+func FromContext(ctx context.Context) (*Consumer, bool) {
+	if ctx == nil {
+		panic("Can not get consumer from empty context")
+	}
+
+	consumer, ok := ctx.Value(consumerKey).(*Consumer)
+	return consumer, ok
+}
+
+// NewClaimsContext puts the verified JWT claims of the authenticated consumer to context for future use
+func NewClaimsContext(ctx context.Context, claims map[string]interface{}) context.Context {
+	if ctx == nil {
+		panic("Can not put claims to empty context")
+	}
+
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext tries to extract the verified JWT claims from context if present
+func ClaimsFromContext(ctx context.Context) (map[string]interface{}, bool) {
+	if ctx == nil {
+		panic("Can not get claims from empty context")
+	}
+
+	claims, ok := ctx.Value(claimsKey).(map[string]interface{})
+	return claims, ok
+}
+
+// Identify resolves the consumer owning the given credential and attaches it to the request context.
+// Credentials that are not assigned to any consumer still result in an anonymous consumer
+// named after the credential type and key, such as jwt:bob, so that downstream plugins always have something to key on
+// and credentials of different types sharing a key are not mistaken for each other.
+// Groups the auth plugin knows about for the credential are merged into the consumer groups.
+func Identify(r *http.Request, credential *Credential, groups ...string) *http.Request {
+	found, known := lookup(credential)
+	consumer := withGroups(found, groups)
+
+	logger := log.WithFields(log.Fields{
+		"consumer":        consumer.Username,
+		"credential_type": credential.Type,
+	})
+	logger.Debug("Consumer identified")
+
+	ctx := NewContext(r.Context(), consumer)
+	consumerTag := anonymousTag
+	if known {
+		consumerTag = consumer.Username
+	}
+	ctx, err := tag.New(ctx, tag.Upsert(obs.KeyConsumer, consumerTag))
+	if err != nil {
+		logger.WithError(err).Warn("Could not tag request with consumer")
+	}
+
+	return r.WithContext(ctx)
+}
+
+// FindByCredential finds the consumer owning the given credential
+func FindByCredential(credential *Credential) (*Consumer, error) {
+	if repo == nil {
+		return nil, ErrConsumerNotFound
+	}
+
+	return repo.FindByCredential(credential)
+}
+
+// lookup returns the consumer owning the credential, known is false when it is an anonymous one
+func lookup(credential *Credential) (consumer *Consumer, known bool) {
+	consumer, err := FindByCredential(credential)
+	if err == nil {
+		return consumer, true
+	}
+
+	if err != ErrConsumerNotFound {
+		log.WithError(err).Warn("Could not look up consumer by credential")
+	}
+
+	return &Consumer{Username: credential.String()}, false
+}
+
+// withGroups returns a copy of the consumer that is also member of the given groups,
+// the stored consumer is left untouched
+func withGroups(consumer *Consumer, groups []string) *Consumer {
+	if len(groups) == 0 {
+		return consumer
+	}
+
+	merged := *consumer
+	merged.Groups = make([]string, 0, len(consumer.Groups)+len(groups))
+	merged.Groups = append(merged.Groups, consumer.Groups...)
+	for _, group := range groups {
+		if !merged.InGroup(group) {
+			merged.Groups = append(merged.Groups, group)
+		}
+	}
+
+	return &merged
+}
@@ -0,0 +1,55 @@
+package consumer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/tag"
+)
+
+func consumerTag(r *http.Request) string {
+	value, _ := tag.FromContext(r.Context()).Value(obs.KeyConsumer)
+	return value
+}
+
+func TestFromContextEmpty(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+}
+
+func TestIdentifyKnownConsumer(t *testing.T) {
+	repo = newInMemoryRepo()
+	defer func() { repo = nil }()
+
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	r = Identify(r, NewCredential(APIKeyCredential, "key2"))
+
+	consumer, ok := FromContext(r.Context())
+	require.True(t, ok)
+	assert.Equal(t, "test2", consumer.Username)
+	assert.Equal(t, "test2", consumerTag(r))
+}
+
+func TestIdentifyAnonymousConsumer(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+
+	r = Identify(r, NewCredential(BasicCredential, "john"))
+
+	consumer, ok := FromContext(r.Context())
+	require.True(t, ok)
+	assert.Equal(t, "basic:john", consumer.Username)
+	assert.Equal(t, anonymousTag, consumerTag(r))
+
+	r = Identify(r, NewCredential(JWTCredential, "john"))
+
+	consumer, ok = FromContext(r.Context())
+	require.True(t, ok)
+	assert.Equal(t, "jwt:john", consumer.Username)
+}
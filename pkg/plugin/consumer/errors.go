@@ -0,0 +1,18 @@
+package consumer
+
+import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+var (
+	// ErrConsumerNotFound is used when a consumer is not found
+	ErrConsumerNotFound = errors.New(http.StatusNotFound, "consumer not found")
+	// ErrConsumerExists is used when a consumer already exists
+	ErrConsumerExists = errors.New(http.StatusConflict, "consumer already exists")
+	// ErrCredentialExists is used when a credential is already attached to another consumer
+	ErrCredentialExists = errors.New(http.StatusConflict, "credential is already attached to another consumer")
+	// ErrInvalidAdminRouter is used when an invalid admin router is given
+	ErrInvalidAdminRouter = errors.New(http.StatusNotFound, "invalid admin router given")
+)
@@ -0,0 +1,65 @@
+package consumer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FileSystemRepository represents a file system repository. Consumers are loaded
+// once on startup and then managed in memory
+type FileSystemRepository struct {
+	*InMemoryRepository
+}
+
+// NewFileSystemRepository creates a file system consumer repo
+func NewFileSystemRepository(dir string) (*FileSystemRepository, error) {
+	repo := &FileSystemRepository{InMemoryRepository: NewInMemoryRepository()}
+
+	// Grab json files from directory
+	files, err := ioutil.ReadDir(dir)
+	if nil != err {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if filepath.Ext(f.Name()) == ".json" {
+			filePath := filepath.Join(dir, f.Name())
+			logger := log.WithField("path", filePath)
+
+			consumersRaw, err := ioutil.ReadFile(filePath)
+			if err != nil {
+				logger.WithError(err).Error("Couldn't load the consumer file")
+				return nil, err
+			}
+
+			for _, consumer := range repo.parseConsumers(consumersRaw) {
+				if err = repo.Add(consumer); err != nil {
+					logger.WithError(err).WithField("username", consumer.Username).Error("Can't add the consumer to the repository")
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return repo, nil
+}
+
+func (r *FileSystemRepository) parseConsumers(consumersRaw []byte) []*Consumer {
+	var consumers []*Consumer
+
+	// Try unmarshalling as if json is an unnamed Array of multiple consumers
+	if err := json.Unmarshal(consumersRaw, &consumers); err != nil {
+		// Try unmarshalling as if json is a single Consumer
+		consumer := NewConsumer()
+		if err := json.Unmarshal(consumersRaw, consumer); err != nil {
+			log.WithError(err).Error("Couldn't unmarshal consumer configuration")
+			return nil
+		}
+		consumers = append(consumers, consumer)
+	}
+
+	return consumers
+}
@@ -0,0 +1,246 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/render"
+	"github.com/hellofresh/janus/pkg/router"
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
+)
+
+// consumerView is a consumer as the admin API returns it, the secrets of the credentials are write-only
+type consumerView struct {
+	Username    string            `json:"username"`
+	CustomID    string            `json:"custom_id"`
+	Groups      []string          `json:"groups"`
+	Credentials []*credentialView `json:"credentials"`
+}
+
+type credentialView struct {
+	Type CredentialType `json:"type"`
+	Key  string         `json:"key"`
+}
+
+func newConsumerView(consumer *Consumer) *consumerView {
+	view := &consumerView{
+		Username:    consumer.Username,
+		CustomID:    consumer.CustomID,
+		Groups:      consumer.Groups,
+		Credentials: make([]*credentialView, 0, len(consumer.Credentials)),
+	}
+	for _, credential := range consumer.Credentials {
+		view.Credentials = append(view.Credentials, &credentialView{Type: credential.Type, Key: credential.Key})
+	}
+
+	return view
+}
+
+// Handler is the api rest handlers
+type Handler struct {
+	repo Repository
+}
+
+// NewHandler creates a new instance of Handler
+func NewHandler(repo Repository) *Handler {
+	return &Handler{repo}
+}
+
+// Index is the find all handler
+func (c *Handler) Index() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := trace.StartSpan(r.Context(), "repo.FindAll")
+		data, err := c.repo.FindAll()
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		views := make([]*consumerView, 0, len(data))
+		for _, consumer := range data {
+			views = append(views, newConsumerView(consumer))
+		}
+
+		render.JSON(w, http.StatusOK, views)
+	}
+}
+
+// Show is the find by handler
+func (c *Handler) Show() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+		_, span := trace.StartSpan(r.Context(), "repo.FindByUsername")
+		data, err := c.repo.FindByUsername(username)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, newConsumerView(data))
+	}
+}
+
+// Update is the update handler
+func (c *Handler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+		_, span := trace.StartSpan(r.Context(), "repo.FindByUsername")
+		consumer, err := c.repo.FindByUsername(username)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+		// Decoding reuses the stored credentials, their secrets are kept aside first
+		secrets := make(map[string]string, len(consumer.Credentials))
+		for _, credential := range consumer.Credentials {
+			secrets[credential.String()] = credential.Secret
+		}
+
+		err = json.NewDecoder(r.Body).Decode(consumer)
+		if err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+		consumer.Username = username
+
+		// The secrets are not returned by the admin API, the credentials sent back without one keep theirs
+		for _, credential := range consumer.Credentials {
+			if credential.Secret == "" {
+				credential.Secret = secrets[credential.String()]
+			}
+		}
+
+		if isValid, err := consumer.Validate(); !isValid && err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		_, span = trace.StartSpan(r.Context(), "repo.Add")
+		err = c.repo.Add(consumer)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// Create is the create handler
+func (c *Handler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		consumer := NewConsumer()
+
+		err := json.NewDecoder(r.Body).Decode(consumer)
+		if nil != err {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		if isValid, err := consumer.Validate(); !isValid && err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		_, span := trace.StartSpan(r.Context(), "repo.FindByUsername")
+		_, err = c.repo.FindByUsername(consumer.Username)
+		span.End()
+
+		if err != ErrConsumerNotFound {
+			log.WithError(err).Warn("An error occurred when looking for a consumer")
+			errors.Handler(w, r, ErrConsumerExists)
+			return
+		}
+
+		_, span = trace.StartSpan(r.Context(), "repo.Add")
+		err = c.repo.Add(consumer)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		w.Header().Add("Location", fmt.Sprintf("/consumers/%s", consumer.Username))
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// Delete is the delete handler
+func (c *Handler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+
+		_, span := trace.StartSpan(r.Context(), "repo.Remove")
+		err := c.repo.Remove(username)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Groups is the find groups handler
+func (c *Handler) Groups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+		_, span := trace.StartSpan(r.Context(), "repo.FindByUsername")
+		consumer, err := c.repo.FindByUsername(username)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, consumer.Groups)
+	}
+}
+
+// UpdateGroups is the replace groups handler
+func (c *Handler) UpdateGroups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+		_, span := trace.StartSpan(r.Context(), "repo.FindByUsername")
+		consumer, err := c.repo.FindByUsername(username)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		groups := make([]string, 0)
+		if err := json.NewDecoder(r.Body).Decode(&groups); err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+		consumer.Groups = groups
+
+		_, span = trace.StartSpan(r.Context(), "repo.Add")
+		err = c.repo.Add(consumer)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
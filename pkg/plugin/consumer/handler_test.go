@@ -0,0 +1,64 @@
+package consumer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHandlerRouter(repo Repository) http.Handler {
+	handler := NewHandler(repo)
+
+	r := chi.NewRouter()
+	r.Get("/consumers", handler.Index())
+	r.Get("/consumers/{username}", handler.Show())
+	r.Put("/consumers/{username}", handler.Update())
+	return r
+}
+
+func TestHandlerHidesSecrets(t *testing.T) {
+	repo := NewInMemoryRepository()
+	require.NoError(t, repo.Add(&Consumer{
+		Username:    "signer",
+		Credentials: []*Credential{{Type: HMACCredential, Key: "signer-key", Secret: "top-secret"}},
+	}))
+	r := newHandlerRouter(repo)
+
+	for _, path := range []string{"/consumers", "/consumers/signer"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"key":"signer-key"`)
+		assert.NotContains(t, w.Body.String(), "secret")
+	}
+}
+
+func TestHandlerUpdateKeepsSecrets(t *testing.T) {
+	repo := NewInMemoryRepository()
+	require.NoError(t, repo.Add(&Consumer{
+		Username:    "signer",
+		Credentials: []*Credential{{Type: HMACCredential, Key: "signer-key", Secret: "top-secret"}},
+	}))
+	r := newHandlerRouter(repo)
+
+	// The consumer is sent back as the admin API returned it, without the secret
+	body := `{"groups": ["partners"], "credentials": [{"type": "hmac", "key": "signer-key"}, {"type": "hmac", "key": "new-key", "secret": "new-secret"}]}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/consumers/signer", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	consumer, err := repo.FindByUsername("signer")
+	require.NoError(t, err)
+	credential, ok := consumer.Credential(HMACCredential, "signer-key")
+	require.True(t, ok)
+	assert.Equal(t, "top-secret", credential.Secret)
+	credential, ok = consumer.Credential(HMACCredential, "new-key")
+	require.True(t, ok)
+	assert.Equal(t, "new-secret", credential.Secret)
+}
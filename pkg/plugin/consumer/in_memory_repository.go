@@ -0,0 +1,109 @@
+package consumer
+
+import (
+	"sync"
+)
+
+// InMemoryRepository represents a in memory repository
+type InMemoryRepository struct {
+	sync.RWMutex
+	consumers   map[string]*Consumer
+	credentials map[string]string
+}
+
+// NewInMemoryRepository creates a in memory repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		consumers:   make(map[string]*Consumer),
+		credentials: make(map[string]string),
+	}
+}
+
+// FindAll fetches all the consumers available
+func (r *InMemoryRepository) FindAll() ([]*Consumer, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	var consumers []*Consumer
+	for _, consumer := range r.consumers {
+		consumers = append(consumers, consumer)
+	}
+
+	return consumers, nil
+}
+
+// FindByUsername find a consumer by username
+func (r *InMemoryRepository) FindByUsername(username string) (*Consumer, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.findByUsername(username)
+}
+
+// FindByCredential find the consumer that owns the given credential
+func (r *InMemoryRepository) FindByCredential(credential *Credential) (*Consumer, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	username, ok := r.credentials[credential.String()]
+	if !ok {
+		return nil, ErrConsumerNotFound
+	}
+
+	return r.findByUsername(username)
+}
+
+// Add adds a consumer to the repository, replacing the existing one with the same username
+func (r *InMemoryRepository) Add(consumer *Consumer) error {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, credential := range consumer.Credentials {
+		if owner, ok := r.credentials[credential.String()]; ok && owner != consumer.Username {
+			return ErrCredentialExists
+		}
+	}
+
+	r.removeCredentials(consumer.Username)
+	for _, credential := range consumer.Credentials {
+		r.credentials[credential.String()] = consumer.Username
+	}
+	r.consumers[consumer.Username] = consumer
+
+	return nil
+}
+
+// Remove removes a consumer from the repository
+func (r *InMemoryRepository) Remove(username string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, err := r.findByUsername(username); err != nil {
+		return err
+	}
+
+	r.removeCredentials(username)
+	delete(r.consumers, username)
+
+	return nil
+}
+
+func (r *InMemoryRepository) findByUsername(username string) (*Consumer, error) {
+	consumer, ok := r.consumers[username]
+	if !ok {
+		return nil, ErrConsumerNotFound
+	}
+
+	return consumer, nil
+}
+
+func (r *InMemoryRepository) removeCredentials(username string) {
+	existing, ok := r.consumers[username]
+	if !ok {
+		return
+	}
+
+	for _, credential := range existing.Credentials {
+		delete(r.credentials, credential.String())
+	}
+}
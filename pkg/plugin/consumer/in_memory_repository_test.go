@@ -0,0 +1,111 @@
+package consumer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInMemoryRepo() *InMemoryRepository {
+	repo := NewInMemoryRepository()
+
+	repo.Add(&Consumer{
+		Username:    "test1",
+		Credentials: []*Credential{NewCredential(BasicCredential, "test1")},
+	})
+
+	repo.Add(&Consumer{
+		Username: "test2",
+		Credentials: []*Credential{
+			NewCredential(APIKeyCredential, "key2"),
+			NewCredential(JWTCredential, "subject2"),
+		},
+	})
+
+	return repo
+}
+
+func TestAdd(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	err := repo.Add(&Consumer{
+		Username:    "test3",
+		Credentials: []*Credential{NewCredential(MTLSCredential, "test3.example.com")},
+	})
+	assert.NoError(t, err)
+}
+
+func TestAddCredentialOwnedByAnotherConsumer(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	err := repo.Add(&Consumer{
+		Username:    "test3",
+		Credentials: []*Credential{NewCredential(APIKeyCredential, "key2")},
+	})
+	assert.Equal(t, ErrCredentialExists, err)
+}
+
+func TestAddReplacesCredentials(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	err := repo.Add(&Consumer{
+		Username:    "test2",
+		Credentials: []*Credential{NewCredential(APIKeyCredential, "key2-rotated")},
+	})
+	require.NoError(t, err)
+
+	_, err = repo.FindByCredential(NewCredential(APIKeyCredential, "key2"))
+	assert.Equal(t, ErrConsumerNotFound, err)
+
+	consumer, err := repo.FindByCredential(NewCredential(APIKeyCredential, "key2-rotated"))
+	require.NoError(t, err)
+	assert.Equal(t, "test2", consumer.Username)
+}
+
+func TestFindByCredential(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	consumer, err := repo.FindByCredential(NewCredential(JWTCredential, "subject2"))
+	require.NoError(t, err)
+	assert.Equal(t, "test2", consumer.Username)
+
+	_, err = repo.FindByCredential(NewCredential(BasicCredential, "subject2"))
+	assert.Equal(t, ErrConsumerNotFound, err)
+}
+
+func TestRemoveExistentConsumer(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	err := repo.Remove("test1")
+	require.NoError(t, err)
+
+	_, err = repo.FindByCredential(NewCredential(BasicCredential, "test1"))
+	assert.Equal(t, ErrConsumerNotFound, err)
+}
+
+func TestRemoveNonexistentConsumer(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	err := repo.Remove("invalid")
+	assert.Equal(t, ErrConsumerNotFound, err)
+}
+
+func TestFindAll(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	results, err := repo.FindAll()
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestFindByUsername(t *testing.T) {
+	repo := newInMemoryRepo()
+
+	consumer, err := repo.FindByUsername("test1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test1", consumer.Username)
+
+	_, err = repo.FindByUsername("invalid")
+	assert.Equal(t, ErrConsumerNotFound, err)
+}
@@ -0,0 +1,145 @@
+package consumer
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName = "consumers"
+
+	mongoQueryTimeout = 10 * time.Second
+)
+
+// MongoRepository represents a mongodb repository
+type MongoRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRepository creates a mongodb consumer repo
+func NewMongoRepository(db *mongo.Database) (*MongoRepository, error) {
+	return &MongoRepository{collection: db.Collection(collectionName)}, nil
+}
+
+// FindAll fetches all the consumers available
+func (r *MongoRepository) FindAll() ([]*Consumer, error) {
+	var result []*Consumer
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	cur, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "username", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		c := NewConsumer()
+		if err := cur.Decode(c); err != nil {
+			return nil, err
+		}
+
+		result = append(result, c)
+	}
+
+	return result, cur.Err()
+}
+
+// FindByUsername find a consumer by username
+func (r *MongoRepository) FindByUsername(username string) (*Consumer, error) {
+	return r.findOneByQuery(bson.M{"username": username})
+}
+
+// FindByCredential find the consumer that owns the given credential
+func (r *MongoRepository) FindByCredential(credential *Credential) (*Consumer, error) {
+	return r.findOneByQuery(bson.M{
+		"credentials": bson.M{"$elemMatch": bson.M{"type": credential.Type, "key": credential.Key}},
+	})
+}
+
+func (r *MongoRepository) findOneByQuery(query interface{}) (*Consumer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	result := NewConsumer()
+	err := r.collection.FindOne(ctx, query).Decode(result)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConsumerNotFound
+	}
+
+	return result, err
+}
+
+// Add adds a consumer to the repository, replacing the existing one with the same username
+func (r *MongoRepository) Add(consumer *Consumer) error {
+	for _, credential := range consumer.Credentials {
+		owner, err := r.FindByCredential(credential)
+		if err == nil && owner.Username != consumer.Username {
+			return ErrCredentialExists
+		}
+		if err != nil && err != ErrConsumerNotFound {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	if err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"username": consumer.Username},
+		bson.M{"$set": consumer},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Err(); err != nil && err != mongo.ErrNoDocuments {
+		// another consumer got one of the credentials since they were checked
+		if isDuplicateKey(err) {
+			return ErrCredentialExists
+		}
+		log.WithField("username", consumer.Username).WithError(err).Error("There was an error adding the consumer")
+		return err
+	}
+
+	log.WithField("username", consumer.Username).Debug("Consumer added")
+	return nil
+}
+
+// Remove removes a consumer from the repository
+func (r *MongoRepository) Remove(username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.DeleteOne(ctx, bson.M{"username": username})
+	if err != nil {
+		log.WithField("username", username).WithError(err).Error("There was an error removing the consumer")
+		return err
+	}
+
+	if res.DeletedCount < 1 {
+		return ErrConsumerNotFound
+	}
+
+	log.WithField("username", username).Debug("Consumer removed")
+	return nil
+}
+
+func isDuplicateKey(err error) bool {
+	const duplicateKeyCode = 11000
+
+	switch err := err.(type) {
+	case mongo.CommandError:
+		return err.Code == duplicateKeyCode
+	case mongo.WriteException:
+		for _, writeErr := range err.WriteErrors {
+			if writeErr.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/router"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+const (
+	file = "file"
+
+	mongoIdxTimeout = 10 * time.Second
+)
+
+var (
+	repo        Repository
+	adminRouter router.Router
+)
+
+func init() {
+	plugin.RegisterEventHook(plugin.StartupEvent, onStartup)
+	plugin.RegisterEventHook(plugin.AdminAPIStartupEvent, onAdminAPIStartup)
+}
+
+// SetRepository sets the repository the consumers are identified from,
+// it is set on startup from the configured database
+func SetRepository(r Repository) {
+	repo = r
+}
+
+func onAdminAPIStartup(event interface{}) error {
+	e, ok := event.(plugin.OnAdminAPIStartup)
+	if !ok {
+		return errors.New("could not convert event to admin startup type")
+	}
+
+	adminRouter = e.Router
+	return nil
+}
+
+func onStartup(event interface{}) error {
+	var err error
+
+	e, ok := event.(plugin.OnStartup)
+	if !ok {
+		return errors.New("could not convert event to startup type")
+	}
+
+	if e.MongoDB != nil {
+		log.Debug("Mongo DB is set, using mongo repository for consumers")
+
+		repo, err = NewMongoRepository(e.MongoDB)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), mongoIdxTimeout)
+		defer cancel()
+
+		if _, err := e.MongoDB.Collection(collectionName).Indexes().CreateMany(
+			ctx,
+			[]mongo.IndexModel{
+				{
+					Keys: bsonx.Doc{
+						{Key: "username", Value: bsonx.Int32(1)},
+					},
+					Options: options.Index().SetUnique(true).SetBackground(true),
+				},
+				{
+					// a credential identifies a single consumer, the consumers without credentials are left out
+					Keys: bsonx.Doc{
+						{Key: "credentials.type", Value: bsonx.Int32(1)},
+						{Key: "credentials.key", Value: bsonx.Int32(1)},
+					},
+					Options: options.Index().SetUnique(true).SetBackground(true).
+						SetPartialFilterExpression(bsonx.Doc{{Key: "credentials.key", Value: bsonx.Document(bsonx.Doc{{Key: "$exists", Value: bsonx.Boolean(true)}})}}),
+				},
+			},
+		); err != nil {
+			return fmt.Errorf("failed to create indexes for consumers repository: %w", err)
+		}
+	} else if e.Cassandra != nil {
+		log.Debug("Cassandra is set, using cassandra repository for consumers")
+
+		repo, err = NewCassandraRepository(e.Cassandra)
+		if err != nil {
+			log.Errorf("error getting cassandra repo: %v", err)
+			return err
+		}
+	} else if consumersPath, ok := fileRepositoryPath(e); ok {
+		log.WithField("path", consumersPath).Debug("Trying to load consumer configuration files")
+
+		repo, err = NewFileSystemRepository(consumersPath)
+		if err != nil {
+			return fmt.Errorf("could not create a file based repository for consumers: %w", err)
+		}
+	} else {
+		log.Debug("No DB set, using memory repository for consumers")
+
+		repo = NewInMemoryRepository()
+	}
+
+	if adminRouter == nil {
+		return ErrInvalidAdminRouter
+	}
+
+	handlers := NewHandler(repo)
+	group := adminRouter.Group("/consumers")
+	if e.Config != nil {
+		group.Use(jwt.NewMiddleware(jwt.NewGuard(e.Config.Web.Credentials)).Handler)
+	}
+	{
+		group.GET("/", handlers.Index())
+		group.POST("/", handlers.Create())
+		group.GET("/{username}", handlers.Show())
+		group.PUT("/{username}", handlers.Update())
+		group.DELETE("/{username}", handlers.Delete())
+		group.GET("/{username}/groups", handlers.Groups())
+		group.PUT("/{username}/groups", handlers.UpdateGroups())
+	}
+
+	return nil
+}
+
+// fileRepositoryPath returns the consumers directory when the file based storage is used and the directory exists
+func fileRepositoryPath(e plugin.OnStartup) (string, bool) {
+	if e.Config == nil {
+		return "", false
+	}
+
+	dsnURL, err := url.Parse(e.Config.Database.DSN)
+	if err != nil || dsnURL.Scheme != file {
+		return "", false
+	}
+
+	consumersPath := fmt.Sprintf("%s/consumers", dsnURL.Path)
+	if _, err := os.Stat(consumersPath); err != nil {
+		return "", false
+	}
+
+	return consumersPath, true
+}
@@ -19,6 +19,8 @@ const (
 	ReloadEvent   = "reload"
 	ShutdownEvent = "shutdown"
 	SetupEvent    = "setup"
+
+	QuotaWarningEvent = "quota_warning"
 )
 
 // OnStartup represents a event that happens when Janus starts up on the main process
@@ -40,3 +42,14 @@ type OnReload struct {
 type OnAdminAPIStartup struct {
 	Router router.Router
 }
+
+// OnQuotaWarning represents a event that happens when an organization used most of one of its quotas
+type OnQuotaWarning struct {
+	Organization string
+	// Window is the calendar window of the quota, day or month
+	Window string
+	// Period identifies the day or month, i.e. 2006-01-02 or 2006-01
+	Period string
+	Used   int64
+	Limit  int64
+}
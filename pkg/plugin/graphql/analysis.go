@@ -0,0 +1,170 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+const (
+	costDirective  = "cost"
+	typenameField  = "__typename"
+	maxMeasurement = math.MaxInt32
+)
+
+// costDirectiveDefinition is added to the schemas that use the @cost directive without declaring it
+const costDirectiveDefinition = `directive @cost(complexity: Int, multipliers: [String!]) on FIELD_DEFINITION`
+
+// measure is the size of a selection set
+type measure struct {
+	depth      int
+	aliases    int
+	complexity int
+}
+
+// analyzer measures the selection sets of an operation, following its fragments
+type analyzer struct {
+	doc       *ast.QueryDocument
+	variables map[string]interface{}
+	fragments map[string]*measure
+}
+
+func analyze(doc *ast.QueryDocument, op *ast.OperationDefinition, variables map[string]interface{}) (measure, error) {
+	a := &analyzer{doc: doc, variables: variables, fragments: make(map[string]*measure)}
+	return a.selectionSet(op.SelectionSet)
+}
+
+func (a *analyzer) selectionSet(set ast.SelectionSet) (measure, error) {
+	var m measure
+	for _, selection := range set {
+		var (
+			child measure
+			err   error
+		)
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if child, err = a.selectionSet(selection.SelectionSet); err != nil {
+				return m, err
+			}
+			child.depth++
+			if selection.Alias != "" && selection.Alias != selection.Name {
+				child.aliases++
+			}
+			child.complexity = a.complexity(selection, child.complexity)
+		case *ast.InlineFragment:
+			if child, err = a.selectionSet(selection.SelectionSet); err != nil {
+				return m, err
+			}
+		case *ast.FragmentSpread:
+			if child, err = a.fragment(selection.Name); err != nil {
+				return m, err
+			}
+		}
+
+		if child.depth > m.depth {
+			m.depth = child.depth
+		}
+		m.aliases = add(m.aliases, child.aliases)
+		m.complexity = add(m.complexity, child.complexity)
+	}
+
+	return m, nil
+}
+
+// fragment measures a fragment once, however many times it is spread
+func (a *analyzer) fragment(name string) (measure, error) {
+	if m, ok := a.fragments[name]; ok {
+		if m == nil {
+			return measure{}, fmt.Errorf("fragment %q spreads itself", name)
+		}
+		return *m, nil
+	}
+
+	def := a.doc.Fragments.ForName(name)
+	if def == nil {
+		return measure{}, fmt.Errorf("fragment %q is not defined", name)
+	}
+
+	a.fragments[name] = nil
+	m, err := a.selectionSet(def.SelectionSet)
+	if err != nil {
+		return m, err
+	}
+	a.fragments[name] = &m
+	return m, nil
+}
+
+// complexity is the cost of a field and of its selection set, multiplied by the values of its multiplier arguments.
+// The fields cost 1 unless the schema gives them a complexity with the @cost directive.
+func (a *analyzer) complexity(field *ast.Field, children int) int {
+	if field.Name == typenameField {
+		return 0
+	}
+
+	cost, multipliers := 1, []string(nil)
+	if field.Definition != nil {
+		if directive := field.Definition.Directives.ForName(costDirective); directive != nil {
+			if arg := directive.Arguments.ForName("complexity"); arg != nil {
+				cost = toInt(arg.Value, nil)
+			}
+			if arg := directive.Arguments.ForName("multipliers"); arg != nil {
+				for _, child := range arg.Value.Children {
+					multipliers = append(multipliers, child.Value.Raw)
+				}
+			}
+		}
+	}
+
+	total := add(cost, children)
+	for _, name := range multipliers {
+		if arg := field.Arguments.ForName(name); arg != nil {
+			if n := toInt(arg.Value, a.variables); n > 0 {
+				total = mul(total, n)
+			}
+		}
+	}
+	return total
+}
+
+func toInt(v *ast.Value, variables map[string]interface{}) int {
+	value, err := v.Value(variables)
+	if err != nil {
+		return 0
+	}
+
+	var n float64
+	switch value := value.(type) {
+	case int64:
+		n = float64(value)
+	case float64:
+		n = value
+	case json.Number:
+		n, _ = value.Float64()
+	}
+
+	if n < 0 {
+		return 0
+	}
+	if n > maxMeasurement {
+		return maxMeasurement
+	}
+	return int(n)
+}
+
+// add and mul saturate, so that the multipliers can't overflow the complexity
+func add(a, b int) int {
+	if a+b > maxMeasurement {
+		return maxMeasurement
+	}
+	return a + b
+}
+
+func mul(a, b int) int {
+	if a != 0 && b > maxMeasurement/a {
+		return maxMeasurement
+	}
+	return a * b
+}
@@ -0,0 +1,430 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/bytefmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	obs "github.com/hellofresh/janus/pkg/observability"
+)
+
+const (
+	defaultMaxBodySize  = "1M"
+	defaultMaxBatchSize = 10
+	anonymous           = "anonymous"
+	// otherOperation tags the operations the configuration doesn't know, their names are chosen by the clients
+	otherOperation = "other"
+	batchOperation = "batch"
+)
+
+var (
+	// ErrInvalidRequest is used when the request has no GraphQL operation
+	ErrInvalidRequest = errors.New(http.StatusBadRequest, "the request is not a valid GraphQL request")
+	// ErrRequestTooLarge is used when the request body is bigger than the max_body_size
+	ErrRequestTooLarge = errors.New(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+	// ErrInvalidQuery is used when the query can't be parsed, or doesn't match the schema
+	ErrInvalidQuery = errors.New(http.StatusBadRequest, "the GraphQL query is invalid")
+	// ErrOperationNotFound is used when the operation to run is not in the query
+	ErrOperationNotFound = errors.New(http.StatusBadRequest, "the GraphQL operation to run can't be found in the query")
+	// ErrOperationNotAllowed is used when the operation is denied
+	ErrOperationNotAllowed = errors.New(http.StatusForbidden, "the GraphQL operation is not allowed")
+	// ErrPersistedQueryNotFound is used when the hash of the request is not one of a persisted query
+	ErrPersistedQueryNotFound = errors.New(http.StatusBadRequest, "PersistedQueryNotFound")
+	// ErrPersistedQueryMismatch is used when the query doesn't have the hash sent with it
+	ErrPersistedQueryMismatch = errors.New(http.StatusBadRequest, "the query does not match its persisted query hash")
+	// ErrQueryNotPersisted is used when only the persisted queries are allowed
+	ErrQueryNotPersisted = errors.New(http.StatusForbidden, "only persisted GraphQL queries are allowed")
+	// ErrMaxBatchSize is used when the batch has more operations than the max_batch_size
+	ErrMaxBatchSize = errors.New(http.StatusBadRequest, "the GraphQL batch has too many operations")
+	// ErrMaxDepth is used when the operation is nested deeper than the max_depth
+	ErrMaxDepth = errors.New(http.StatusBadRequest, "the GraphQL operation is too deep")
+	// ErrMaxAliases is used when the operation has more aliases than the max_aliases
+	ErrMaxAliases = errors.New(http.StatusBadRequest, "the GraphQL operation has too many aliases")
+	// ErrMaxComplexity is used when the operation costs more than the max_complexity
+	ErrMaxComplexity = errors.New(http.StatusBadRequest, "the GraphQL operation is too complex")
+)
+
+// Limit is the detail of the errors of the operations over a limit
+type Limit struct {
+	Operation string `json:"operation"`
+	Max       int    `json:"max"`
+	Actual    int    `json:"actual"`
+}
+
+// guard parses the GraphQL operations of the requests, rejects the ones over the limits or not allowed,
+// and tags the requests with the names of their operations
+type guard struct {
+	maxDepth      int
+	maxAliases    int
+	maxComplexity int
+	maxBatchSize  int
+	schema        *ast.Schema
+	allowed       map[string]bool
+	denied        map[string]bool
+	persisted     map[string]string
+	enforce       bool
+	// tagged are the operation names the metrics are tagged with, the ones of the configuration
+	tagged map[string]bool
+	limit  int64
+}
+
+func newGuard(config Config) (*guard, error) {
+	if config.MaxDepth < 0 || config.MaxAliases < 0 || config.MaxComplexity < 0 || config.MaxBatchSize < 0 {
+		return nil, fmt.Errorf("the limits can't be negative")
+	}
+
+	g := &guard{
+		maxDepth:      config.MaxDepth,
+		maxAliases:    config.MaxAliases,
+		maxComplexity: config.MaxComplexity,
+		maxBatchSize:  config.MaxBatchSize,
+		allowed:       set(config.AllowedOperations),
+		denied:        set(config.DeniedOperations),
+		enforce:       config.PersistedQueries.Enforce,
+	}
+
+	var err error
+	if g.schema, err = loadSchema(config); err != nil {
+		return nil, err
+	}
+	if g.persisted, err = loadPersistedQueries(config.PersistedQueries); err != nil {
+		return nil, err
+	}
+	if g.enforce && len(g.persisted) == 0 {
+		return nil, fmt.Errorf("persisted_queries: enforce requires queries")
+	}
+	if g.tagged, err = taggedOperations(config.AllowedOperations, g.persisted); err != nil {
+		return nil, err
+	}
+
+	if g.maxBatchSize == 0 {
+		g.maxBatchSize = defaultMaxBatchSize
+	}
+
+	size := config.MaxBodySize
+	if size == "" {
+		size = defaultMaxBodySize
+	}
+	limit, err := bytefmt.ToBytes(size)
+	if err != nil {
+		return nil, err
+	}
+	g.limit = int64(limit)
+
+	return g, nil
+}
+
+func loadSchema(config Config) (*ast.Schema, error) {
+	sdl := config.Schema
+	switch {
+	case config.SchemaFile != "" && config.Schema != "":
+		return nil, fmt.Errorf("only one of schema and schema_file can be set")
+	case config.SchemaFile != "":
+		data, err := ioutil.ReadFile(config.SchemaFile)
+		if err != nil {
+			return nil, err
+		}
+		sdl = string(data)
+	case config.Schema == "":
+		return nil, nil
+	}
+
+	sources := []*ast.Source{{Name: "schema", Input: sdl}}
+	if !strings.Contains(sdl, "directive @"+costDirective) {
+		sources = append(sources, &ast.Source{Name: "cost", Input: costDirectiveDefinition, BuiltIn: true})
+	}
+
+	schema, gqlErr := gqlparser.LoadSchema(sources...)
+	if gqlErr != nil {
+		return nil, fmt.Errorf("invalid schema: %w", gqlErr)
+	}
+	return schema, nil
+}
+
+func loadPersistedQueries(config PersistedQueries) (map[string]string, error) {
+	queries := make(map[string]string, len(config.Queries))
+	if config.File != "" {
+		data, err := ioutil.ReadFile(config.File)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &queries); err != nil {
+			return nil, fmt.Errorf("persisted_queries: %w", err)
+		}
+	}
+	for hash, query := range config.Queries {
+		queries[hash] = query
+	}
+
+	// The hashes are compared in lower case, as the clients send them
+	persisted := make(map[string]string, len(queries))
+	for hash, query := range queries {
+		if hashQuery(query) != strings.ToLower(hash) {
+			return nil, fmt.Errorf("persisted_queries: %q is not the SHA-256 hash of its query", hash)
+		}
+		persisted[strings.ToLower(hash)] = query
+	}
+	return persisted, nil
+}
+
+// taggedOperations returns the allowed operations and the operations of the persisted queries
+func taggedOperations(allowed []string, persisted map[string]string) (map[string]bool, error) {
+	tagged := set(allowed)
+	for hash, query := range persisted {
+		doc, err := parser.ParseQuery(&ast.Source{Input: query})
+		if err != nil {
+			return nil, fmt.Errorf("persisted_queries: %s: %w", hash, err)
+		}
+		for _, op := range doc.Operations {
+			if op.Name != "" {
+				tagged[op.Name] = true
+			}
+		}
+	}
+	return tagged, nil
+}
+
+// Handler is the middleware function
+func (g *guard) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, ok, err := readBatch(r, g.limit)
+		if err != nil {
+			g.reject(w, r, "", "invalid_request", err)
+			return
+		}
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(b.operations) > g.maxBatchSize {
+			g.reject(w, r, batchOperation, "max_batch_size", limitError(ErrMaxBatchSize, batchOperation, g.maxBatchSize, len(b.operations)))
+			return
+		}
+
+		var (
+			names      []string
+			resolved   bool
+			complexity int
+		)
+		ops := make([]*ast.OperationDefinition, 0, len(b.operations))
+		for _, p := range b.operations {
+			if p.Query == "" && p.Hash != "" {
+				resolved = true
+			}
+
+			op, m, reason, err := g.check(p)
+			var name string
+			if op != nil {
+				name = operationName(op)
+			}
+			if err != nil {
+				g.reject(w, r, name, reason, err)
+				return
+			}
+
+			names = append(names, name)
+			ops = append(ops, op)
+			complexity = add(complexity, m.complexity)
+		}
+
+		// The operations of a batch share the complexity budget, so that it can't be multiplied by batching them
+		if g.maxComplexity > 0 && complexity > g.maxComplexity {
+			g.reject(w, r, batchOperation, "max_complexity", limitError(ErrMaxComplexity, batchOperation, g.maxComplexity, complexity))
+			return
+		}
+
+		if resolved {
+			if err := b.write(r); err != nil {
+				errors.Handler(w, r, err)
+				return
+			}
+		}
+
+		ctx, span := trace.StartSpan(r.Context(), spanName(ops))
+		defer span.End()
+		if len(ops) == 1 {
+			span.AddAttributes(
+				trace.StringAttribute("graphql.operation.name", names[0]),
+				trace.StringAttribute("graphql.operation.type", string(ops[0].Operation)),
+			)
+		}
+
+		operation := batchOperation
+		if len(names) == 1 {
+			operation = g.tagName(names[0])
+		}
+		ctx, err = tag.New(ctx, tag.Upsert(obs.KeyGraphQLOperation, operation))
+		if err != nil {
+			log.WithError(err).Warn("Could not tag the request with the GraphQL operation")
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// check resolves the persisted query of the operation and checks it against the limits,
+// reason is the tag of the rejection metric when it is rejected
+func (g *guard) check(p *params) (op *ast.OperationDefinition, m measure, reason string, err error) {
+	if reason, err := g.resolve(p); err != nil {
+		return nil, m, reason, err
+	}
+
+	doc, err := g.parse(p.Query)
+	if err != nil {
+		return nil, m, "invalid_query", err
+	}
+
+	op = operation(doc, p.OperationName)
+	if op == nil {
+		return nil, m, "operation_not_found", ErrOperationNotFound
+	}
+
+	if (len(g.allowed) > 0 && !g.allowed[op.Name]) || g.denied[op.Name] {
+		return op, m, "operation_not_allowed", ErrOperationNotAllowed
+	}
+
+	m, err = analyze(doc, op, p.Variables)
+	if err != nil {
+		return op, m, "invalid_query", &errors.Error{Code: ErrInvalidQuery.Code, Message: ErrInvalidQuery.Message, Details: []string{err.Error()}}
+	}
+
+	name := operationName(op)
+	switch {
+	case g.maxDepth > 0 && m.depth > g.maxDepth:
+		return op, m, "max_depth", limitError(ErrMaxDepth, name, g.maxDepth, m.depth)
+	case g.maxAliases > 0 && m.aliases > g.maxAliases:
+		return op, m, "max_aliases", limitError(ErrMaxAliases, name, g.maxAliases, m.aliases)
+	case g.maxComplexity > 0 && m.complexity > g.maxComplexity:
+		return op, m, "max_complexity", limitError(ErrMaxComplexity, name, g.maxComplexity, m.complexity)
+	}
+
+	return op, m, "", nil
+}
+
+// resolve replaces the hash of a persisted query by its query
+func (g *guard) resolve(p *params) (string, error) {
+	if p.Hash != "" {
+		query, ok := g.persisted[strings.ToLower(p.Hash)]
+		switch {
+		case !ok:
+			return "persisted_query_not_found", ErrPersistedQueryNotFound
+		case p.Query == "":
+			p.Query = query
+		case hashQuery(p.Query) != strings.ToLower(p.Hash):
+			return "persisted_query_mismatch", ErrPersistedQueryMismatch
+		}
+		return "", nil
+	}
+
+	if p.Query == "" {
+		return "invalid_request", ErrInvalidRequest
+	}
+	if g.enforce {
+		if _, ok := g.persisted[hashQuery(p.Query)]; !ok {
+			return "query_not_persisted", ErrQueryNotPersisted
+		}
+	}
+	return "", nil
+}
+
+// parse parses the query, and validates it when there is a schema
+func (g *guard) parse(query string) (*ast.QueryDocument, error) {
+	var (
+		doc  *ast.QueryDocument
+		errs gqlerror.List
+	)
+	if g.schema != nil {
+		doc, errs = gqlparser.LoadQuery(g.schema, query)
+	} else {
+		var err *gqlerror.Error
+		if doc, err = parser.ParseQuery(&ast.Source{Input: query}); err != nil {
+			errs = gqlerror.List{err}
+		}
+	}
+
+	if len(errs) > 0 {
+		messages := make([]string, 0, len(errs))
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return nil, &errors.Error{Code: ErrInvalidQuery.Code, Message: ErrInvalidQuery.Message, Details: messages}
+	}
+	return doc, nil
+}
+
+func (g *guard) reject(w http.ResponseWriter, r *http.Request, operation, reason string, err error) {
+	ctx, tagErr := tag.New(r.Context(), tag.Upsert(obs.KeyGraphQLOperation, g.tagName(operation)), tag.Upsert(obs.KeyGraphQLRejection, reason))
+	if tagErr == nil {
+		stats.Record(ctx, obs.MGraphQLRejected.M(1))
+	}
+
+	log.WithError(err).WithFields(log.Fields{"operation": operation, "reason": reason}).Debug("GraphQL operation rejected")
+	errors.Handler(w, r, err)
+}
+
+// operation returns the operation to run, the only one of the document when it isn't named
+func operation(doc *ast.QueryDocument, name string) *ast.OperationDefinition {
+	if name != "" {
+		return doc.Operations.ForName(name)
+	}
+	if len(doc.Operations) == 1 {
+		return doc.Operations[0]
+	}
+	return nil
+}
+
+// tagName keeps the number of metric series bounded, only the operations of the configuration are tagged by name
+func (g *guard) tagName(name string) string {
+	if name == "" || name == anonymous || g.tagged[name] {
+		return name
+	}
+	return otherOperation
+}
+
+// operationName names the anonymous operations in the metrics, the traces and the errors
+func operationName(op *ast.OperationDefinition) string {
+	if op.Name == "" {
+		return anonymous
+	}
+	return op.Name
+}
+
+func limitError(err *errors.Error, operation string, max, actual int) *errors.Error {
+	return &errors.Error{Code: err.Code, Message: err.Message, Details: Limit{Operation: operation, Max: max, Actual: actual}}
+}
+
+func spanName(ops []*ast.OperationDefinition) string {
+	if len(ops) > 1 {
+		return "graphql batch"
+	}
+
+	return fmt.Sprintf("graphql %s %s", ops[0].Operation, operationName(ops[0]))
+}
+
+func hashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+func set(values []string) map[string]bool {
+	s := make(map[string]bool, len(values))
+	for _, v := range values {
+		s[v] = true
+	}
+	return s
+}
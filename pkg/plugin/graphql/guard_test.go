@@ -0,0 +1,244 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const recipesQuery = `query Recipes($n: Int) { recipes(first: $n) { id name ingredients(first: 3) { name } } }`
+
+// serve sends the request through the guard, and returns the request the upstream received
+func serve(t *testing.T, config Config, r *http.Request) (*httptest.ResponseRecorder, *http.Request) {
+	g, err := newGuard(config)
+	require.NoError(t, err)
+
+	var upstream *http.Request
+	w := httptest.NewRecorder()
+	g.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(w, r)
+
+	return w, upstream
+}
+
+func post(body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func operationBody(query string, fields map[string]interface{}) string {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+	fields["query"] = query
+	body, _ := json.Marshal(fields)
+	return string(body)
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		query  string
+		code   int
+		error  string
+	}{
+		{"within the limits", Config{MaxDepth: 3, MaxAliases: 1, MaxComplexity: 4}, `{ a { b { c } } x: d }`, http.StatusOK, ""},
+		{"too deep", Config{MaxDepth: 2}, `{ a { b { c } } }`, http.StatusBadRequest, `"details":{"operation":"anonymous","max":2,"actual":3}`},
+		{"too deep in a fragment", Config{MaxDepth: 2}, `query Deep { ...A } fragment A on Query { a { b } }`, http.StatusOK, ""},
+		{"too deep in nested fragments", Config{MaxDepth: 2}, `query Deep { a { ...B } } fragment B on A { b { c } }`, http.StatusBadRequest, `"operation":"Deep","max":2,"actual":3`},
+		{"too many aliases", Config{MaxAliases: 2}, `{ a: r { b: id } c: r { id } d: r { id } }`, http.StatusBadRequest, `"max":2,"actual":4`},
+		{"same name aliases", Config{MaxAliases: 1}, `{ r: r { id: id } }`, http.StatusOK, ""},
+		{"too complex", Config{MaxComplexity: 3}, `{ a { b { c } } d }`, http.StatusBadRequest, `"max":3,"actual":4`},
+		{"typename is free", Config{MaxComplexity: 1}, `{ a __typename }`, http.StatusOK, ""},
+		{"undefined fragment", Config{}, `{ ...A }`, http.StatusBadRequest, `fragment \"A\" is not defined`},
+		{"fragment cycle", Config{}, `{ ...A } fragment A on Query { a { ...A } }`, http.StatusBadRequest, `fragment \"A\" spreads itself`},
+		{"syntax error", Config{}, `{ a `, http.StatusBadRequest, "the GraphQL query is invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := serve(t, tt.config, post(operationBody(tt.query, nil)))
+			assert.Equal(t, tt.code, w.Code)
+			assert.Contains(t, w.Body.String(), tt.error)
+		})
+	}
+}
+
+func TestSchemaComplexity(t *testing.T) {
+	config := Config{SchemaFile: "testdata/schema.graphql", MaxComplexity: 100}
+
+	// ingredients cost (1 + 1) * 3, recipes cost (2 + 1 + 1 + 6) * $n
+	w, _ := serve(t, config, post(operationBody(recipesQuery, map[string]interface{}{"variables": map[string]int{"n": 10}})))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = serve(t, config, post(operationBody(recipesQuery, map[string]interface{}{"variables": map[string]int{"n": 11}})))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"operation":"Recipes","max":100,"actual":110`)
+
+	w, _ = serve(t, config, post(operationBody(recipesQuery, map[string]interface{}{"variables": map[string]int{"n": 1e9}})))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The operations are validated against the schema
+	w, _ = serve(t, config, post(operationBody(`{ recipes { calories } }`, nil)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `Cannot query field \"calories\" on type \"Recipe\"`)
+}
+
+func TestOperations(t *testing.T) {
+	query := `query Recipes { recipes { id } } mutation Rate { rateRecipe(id: 1, rating: 5) { id } }`
+
+	w, upstream := serve(t, Config{}, post(operationBody(query, map[string]interface{}{"operationName": "Rate"})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, upstream)
+
+	w, _ = serve(t, Config{}, post(operationBody(query, nil)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), ErrOperationNotFound.Message)
+
+	w, _ = serve(t, Config{DeniedOperations: []string{"Rate"}}, post(operationBody(query, map[string]interface{}{"operationName": "Rate"})))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, _ = serve(t, Config{AllowedOperations: []string{"Recipes"}}, post(operationBody(query, map[string]interface{}{"operationName": "Recipes"})))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = serve(t, Config{AllowedOperations: []string{"Recipes"}}, post(operationBody(`{ recipes { id } }`, nil)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestPersistedQueries(t *testing.T) {
+	query := `{ recipes { id } }`
+	hash := hashQuery(query)
+	config := Config{PersistedQueries: PersistedQueries{Queries: map[string]string{strings.ToUpper(hash): query}}}
+	extensions := map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+		"tracing":        true,
+	}
+
+	// The upstream gets the query of the hash, with the other fields of the request
+	w, upstream := serve(t, config, post(operationBody("", map[string]interface{}{"extensions": extensions, "variables": map[string]int64{"id": 9007199254740993}})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, upstream)
+	body, err := ioutil.ReadAll(upstream.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"query": "{ recipes { id } }",
+		"variables": {"id": 9007199254740993},
+		"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "`+hash+`"}, "tracing": true}
+	}`, string(body))
+	assert.Equal(t, int64(len(body)), upstream.ContentLength)
+
+	w, upstream = serve(t, config, post(operationBody(query, map[string]interface{}{"extensions": extensions})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, upstream)
+
+	w, _ = serve(t, config, post(operationBody(`{ recipes { name } }`, map[string]interface{}{"extensions": extensions})))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), ErrPersistedQueryMismatch.Message)
+
+	unknown := map[string]interface{}{"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hashQuery("{ a }")}}
+	w, _ = serve(t, config, post(operationBody("", map[string]interface{}{"extensions": unknown})))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "PersistedQueryNotFound")
+
+	// Only the persisted queries are let through when they are enforced
+	w, _ = serve(t, config, post(operationBody(`{ recipes { name } }`, nil)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	config.PersistedQueries.Enforce = true
+	w, _ = serve(t, config, post(operationBody(`{ recipes { name } }`, nil)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, _ = serve(t, config, post(operationBody(query, nil)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The GET requests get the query in their query string
+	params := url.Values{"extensions": {`{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}`}}
+	w, upstream = serve(t, config, httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, upstream)
+	assert.Equal(t, query, upstream.URL.Query().Get("query"))
+}
+
+func TestRequests(t *testing.T) {
+	config := Config{MaxDepth: 2, MaxBodySize: "100B"}
+
+	// The requests that are not GraphQL operations go through
+	w, upstream := serve(t, config, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotNil(t, upstream)
+
+	params := url.Values{"query": {`{ a { b { c } } }`}}
+	w, _ = serve(t, config, httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{ a { b } }`))
+	r.Header.Set("Content-Type", "application/graphql; charset=utf-8")
+	w, upstream = serve(t, config, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, upstream)
+	body, err := ioutil.ReadAll(upstream.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{ a { b } }`, string(body))
+
+	// Every operation of a batch is checked
+	w, _ = serve(t, config, post(`[{"query": "{ a }"}, {"query": "{ b }"}]`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w, _ = serve(t, config, post(`[{"query": "{ a }"}, {"query": "{ a { b { c } } }"}]`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The operations of a batch share the complexity budget, and batches are limited in size
+	config = Config{MaxComplexity: 3, MaxBatchSize: 2}
+	w, _ = serve(t, config, post(`[{"query": "{ a }"}, {"query": "{ b c }"}]`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w, _ = serve(t, config, post(`[{"query": "{ a b }"}, {"query": "{ b c }"}]`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"details":{"operation":"batch","max":3,"actual":4}`)
+	w, _ = serve(t, config, post(`[{"query": "{ a }"}, {"query": "{ b }"}, {"query": "{ c }"}]`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), ErrMaxBatchSize.Message)
+
+	w, _ = serve(t, Config{}, post("["+strings.Repeat(`{"query": "{ a }"},`, defaultMaxBatchSize)+`{"query": "{ a }"}]`))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	config = Config{MaxDepth: 2, MaxBodySize: "100B"}
+	for _, body := range []string{`{"query": 1}`, `[]`, `not json`, `{}`} {
+		w, _ = serve(t, config, post(body))
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	w, _ = serve(t, config, post(operationBody(`{ a }`+strings.Repeat(" ", 100), nil)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestTagName(t *testing.T) {
+	persisted := `query Menu { menu { id } }`
+	g, err := newGuard(Config{
+		AllowedOperations: []string{"Recipes", "Menu"},
+		PersistedQueries:  PersistedQueries{Queries: map[string]string{hashQuery(persisted): persisted}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Recipes", g.tagName("Recipes"))
+	assert.Equal(t, "Menu", g.tagName("Menu"))
+	assert.Equal(t, anonymous, g.tagName(anonymous))
+	assert.Equal(t, otherOperation, g.tagName("Random1234"))
+
+	g, err = newGuard(Config{PersistedQueries: PersistedQueries{Queries: map[string]string{hashQuery(persisted): persisted}}})
+	require.NoError(t, err)
+	assert.Equal(t, "Menu", g.tagName("Menu"))
+	assert.Equal(t, otherOperation, g.tagName("Recipes"))
+
+	_, err = newGuard(Config{PersistedQueries: PersistedQueries{Queries: map[string]string{hashQuery("{ a "): "{ a "}}})
+	assert.Error(t, err)
+}
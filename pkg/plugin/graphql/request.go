@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// params are the parameters of a GraphQL operation, from the query string or the body of the request
+type params struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+	// Hash is the SHA-256 hash of the query, sent with the persistedQuery extension
+	Hash string
+	// fields are the JSON fields of the operation, forwarded as they are but the query
+	fields map[string]json.RawMessage
+}
+
+type extensions struct {
+	PersistedQuery struct {
+		Sha256Hash string `json:"sha256Hash"`
+	} `json:"persistedQuery"`
+}
+
+func newParams(fields map[string]json.RawMessage) (*params, error) {
+	p := &params{fields: fields}
+	if err := unmarshal(fields["query"], &p.Query); err != nil {
+		return nil, err
+	}
+	if err := unmarshal(fields["operationName"], &p.OperationName); err != nil {
+		return nil, err
+	}
+	if err := unmarshal(fields["variables"], &p.Variables); err != nil {
+		return nil, err
+	}
+
+	var ext extensions
+	if err := unmarshal(fields["extensions"], &ext); err != nil {
+		return nil, err
+	}
+	p.Hash = ext.PersistedQuery.Sha256Hash
+
+	return p, nil
+}
+
+// unmarshal decodes the numbers of the variables as json.Number, and skips the missing and null fields
+func unmarshal(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// batch is the operations of a request, several of them when the request body is a JSON array
+type batch struct {
+	operations []*params
+	batched    bool
+	raw        bool
+}
+
+// readBatch reads the GraphQL operations of a request, ok is false when the request doesn't carry any
+func readBatch(r *http.Request, limit int64) (b *batch, ok bool, err error) {
+	if r.Method == http.MethodGet {
+		return readQueryString(r)
+	}
+	if r.Method != http.MethodPost {
+		return nil, false, nil
+	}
+
+	if r.ContentLength > limit {
+		return nil, true, ErrRequestTooLarge
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, true, err
+	}
+	if int64(len(body)) > limit {
+		return nil, true, ErrRequestTooLarge
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// The application/graphql bodies are the query itself
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/graphql" {
+		return &batch{operations: []*params{{Query: string(body)}}, raw: true}, true, nil
+	}
+
+	b = &batch{}
+	var operations []map[string]json.RawMessage
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		b.batched = true
+		err = json.Unmarshal(body, &operations)
+	} else {
+		var operation map[string]json.RawMessage
+		err = json.Unmarshal(body, &operation)
+		operations = append(operations, operation)
+	}
+	if err != nil || len(operations) == 0 {
+		return nil, true, ErrInvalidRequest
+	}
+
+	for _, fields := range operations {
+		p, err := newParams(fields)
+		if err != nil {
+			return nil, true, ErrInvalidRequest
+		}
+		b.operations = append(b.operations, p)
+	}
+	return b, true, nil
+}
+
+func readQueryString(r *http.Request) (*batch, bool, error) {
+	query := r.URL.Query()
+	if query.Get("query") == "" && query.Get("extensions") == "" {
+		return nil, false, nil
+	}
+
+	p := &params{Query: query.Get("query"), OperationName: query.Get("operationName")}
+	if err := unmarshal(json.RawMessage(query.Get("variables")), &p.Variables); err != nil {
+		return nil, true, ErrInvalidRequest
+	}
+
+	var ext extensions
+	if err := unmarshal(json.RawMessage(query.Get("extensions")), &ext); err != nil {
+		return nil, true, ErrInvalidRequest
+	}
+	p.Hash = ext.PersistedQuery.Sha256Hash
+
+	return &batch{operations: []*params{p}}, true, nil
+}
+
+// write replaces the operations of the request, once the persisted queries were resolved
+func (b *batch) write(r *http.Request) error {
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		query.Set("query", b.operations[0].Query)
+		r.URL.RawQuery = query.Encode()
+		return nil
+	}
+
+	body := []byte(b.operations[0].Query)
+	if !b.raw {
+		operations := make([]map[string]json.RawMessage, 0, len(b.operations))
+		for _, p := range b.operations {
+			query, err := json.Marshal(p.Query)
+			if err != nil {
+				return err
+			}
+			if p.fields == nil {
+				p.fields = make(map[string]json.RawMessage)
+			}
+			p.fields["query"] = query
+			operations = append(operations, p.fields)
+		}
+
+		var (
+			v   interface{} = operations[0]
+			err error
+		)
+		if b.batched {
+			v = operations
+		}
+		if body, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
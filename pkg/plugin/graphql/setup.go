@@ -0,0 +1,78 @@
+package graphql
+
+import (
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+// Config represents the GraphQL configuration
+type Config struct {
+	// MaxDepth is the maximum nesting of the fields of an operation, unlimited when zero
+	MaxDepth int `json:"max_depth"`
+	// MaxAliases is the maximum number of aliased fields of an operation, unlimited when zero
+	MaxAliases int `json:"max_aliases"`
+	// MaxComplexity is the complexity budget of an operation, shared by the operations of a batch. Unlimited when zero
+	MaxComplexity int `json:"max_complexity"`
+	// Schema is the SDL of the schema, with the complexity of the fields given by the @cost directive.
+	// The operations are validated against it when it is set.
+	Schema string `json:"schema"`
+	// SchemaFile is the path of the SDL of the schema
+	SchemaFile string `json:"schema_file"`
+	// AllowedOperations are the names of the only operations let through
+	AllowedOperations []string `json:"allowed_operations"`
+	// DeniedOperations are the names of the operations rejected
+	DeniedOperations []string `json:"denied_operations"`
+	// PersistedQueries are the queries the clients can send by their SHA-256 hash
+	PersistedQueries PersistedQueries `json:"persisted_queries"`
+	// MaxBatchSize is the maximum number of operations of a batch. Defaults to 10
+	MaxBatchSize int `json:"max_batch_size"`
+	// MaxBodySize is the maximum size of the request bodies. Defaults to 1M
+	MaxBodySize string `json:"max_body_size"`
+}
+
+// PersistedQueries are queries known ahead of time, sent by their SHA-256 hash with the persistedQuery extension
+type PersistedQueries struct {
+	// File is the path of a JSON object of the queries by their hash
+	File string `json:"file"`
+	// Queries are the queries by their hash
+	Queries map[string]string `json:"queries"`
+	// Enforce rejects the queries that are not persisted
+	Enforce bool `json:"enforce"`
+}
+
+func init() {
+	plugin.RegisterPlugin("graphql", plugin.Plugin{
+		Action:   setupGraphQL,
+		Validate: validateConfig,
+	})
+}
+
+func setupGraphQL(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return err
+	}
+
+	g, err := newGuard(config)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(g.Handler)
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := newGuard(config); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,59 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+func TestGraphQLConfig(t *testing.T) {
+	var config Config
+	rawConfig := map[string]interface{}{
+		"max_depth":          5,
+		"max_aliases":        10,
+		"max_complexity":     100,
+		"schema_file":        "testdata/schema.graphql",
+		"denied_operations":  []string{"IntrospectionQuery"},
+		"persisted_queries":  map[string]interface{}{"queries": map[string]string{hashQuery("{ recipes { id } }"): "{ recipes { id } }"}, "enforce": true},
+		"allowed_operations": []string{"Recipes"},
+	}
+
+	err := plugin.Decode(rawConfig, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 5, config.MaxDepth)
+	assert.Equal(t, 10, config.MaxAliases)
+	assert.Equal(t, 100, config.MaxComplexity)
+	assert.Equal(t, []string{"IntrospectionQuery"}, config.DeniedOperations)
+	assert.Equal(t, []string{"Recipes"}, config.AllowedOperations)
+	assert.True(t, config.PersistedQueries.Enforce)
+	assert.Len(t, config.PersistedQueries.Queries, 1)
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	assert.NoError(t, setupGraphQL(def, rawConfig))
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func TestGraphQLValidation(t *testing.T) {
+	for _, rawConfig := range []plugin.Config{
+		{"max_depth": -1},
+		{"max_batch_size": -1},
+		{"schema": "type Query {", "max_depth": 5},
+		{"schema": "type Query { a: Int }", "schema_file": "testdata/schema.graphql"},
+		{"schema_file": "testdata/missing.graphql"},
+		{"persisted_queries": map[string]interface{}{"queries": map[string]string{"abc": "{ recipes { id } }"}}},
+		{"persisted_queries": map[string]interface{}{"enforce": true}},
+		{"max_body_size": "big"},
+	} {
+		valid, err := validateConfig(rawConfig)
+		assert.False(t, valid)
+		assert.Error(t, err)
+	}
+
+	valid, err := validateConfig(plugin.Config{"max_depth": 5, "schema": "type Query { a: Int @cost(complexity: 3) }"})
+	assert.True(t, valid)
+	assert.NoError(t, err)
+}
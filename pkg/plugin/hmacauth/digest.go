@@ -0,0 +1,74 @@
+package hmacauth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+var digestAlgorithms = map[string]func() hash.Hash{
+	"sha-256": sha256.New,
+	"sha-512": sha512.New,
+}
+
+// verifyDigest checks the Digest header (RFC 3230) against the request body.
+// The body is buffered up to maxSize bytes and restored so it can still be proxied.
+func verifyDigest(r *http.Request, maxSize int64) error {
+	header := r.Header.Get("Digest")
+	if header == "" {
+		return ErrMissingDigest
+	}
+
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, maxSize+1))
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+		if int64(len(body)) > maxSize {
+			return ErrRequestEntityTooLarge
+		}
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	// several digests may be sent, all the supported ones must match and at least one must be supported
+	verified := false
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidDigest
+		}
+
+		newHash, ok := digestAlgorithms[strings.ToLower(kv[0])]
+		if !ok {
+			continue
+		}
+
+		expected, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil {
+			return ErrInvalidDigest
+		}
+
+		h := newHash()
+		h.Write(body)
+		if subtle.ConstantTimeCompare(expected, h.Sum(nil)) != 1 {
+			return ErrInvalidDigest
+		}
+		verified = true
+	}
+
+	if !verified {
+		return ErrInvalidDigest
+	}
+
+	return nil
+}
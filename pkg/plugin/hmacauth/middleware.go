@@ -0,0 +1,176 @@
+package hmacauth
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrMissingSignature is used when the request is not signed
+	ErrMissingSignature = errors.New(http.StatusUnauthorized, "request signature is missing")
+	// ErrInvalidSignature is used when the signature can not be parsed or does not match the request
+	ErrInvalidSignature = errors.New(http.StatusUnauthorized, "request signature is invalid")
+	// ErrUnsupportedAlgorithm is used when the signature algorithm is not allowed
+	ErrUnsupportedAlgorithm = errors.New(http.StatusUnauthorized, "signature algorithm is not allowed")
+	// ErrMissingSignedHeader is used when a header that must be signed is not part of the signature or the request
+	ErrMissingSignedHeader = errors.New(http.StatusUnauthorized, "a required header is not signed")
+	// ErrClockSkew is used when the request date is outside of the accepted window
+	ErrClockSkew = errors.New(http.StatusUnauthorized, "request date is outside of the accepted clock skew")
+	// ErrMissingDigest is used when the body digest is required but not sent
+	ErrMissingDigest = errors.New(http.StatusUnauthorized, "request digest is missing")
+	// ErrInvalidDigest is used when the body digest does not match the body
+	ErrInvalidDigest = errors.New(http.StatusUnauthorized, "request digest does not match the body")
+	// ErrReplayedRequest is used when the same signed request was already received
+	ErrReplayedRequest = errors.New(http.StatusUnauthorized, "request was already received")
+	// ErrRequestEntityTooLarge is used when the body is too large to verify its digest
+	ErrRequestEntityTooLarge = errors.New(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+	// ErrNotAuthorized is used when the key is unknown
+	ErrNotAuthorized = errors.New(http.StatusUnauthorized, "not authorized")
+)
+
+// NewHMACAuth creates a middleware that authenticates requests signed with a secret of a consumer
+func NewHMACAuth(config Config, nonces NonceStore) func(http.Handler) http.Handler {
+	clockSkew := time.Duration(config.ClockSkew)
+
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log.Debug("Starting HMAC auth middleware")
+			logger := log.WithFields(log.Fields{
+				"path":   r.RequestURI,
+				"origin": r.RemoteAddr,
+			})
+
+			sig, err := ParseSignature(r)
+			if err != nil {
+				errors.Handler(w, r, err)
+				return
+			}
+			logger = logger.WithField("key_id", sig.KeyID)
+
+			if !contains(config.Algorithms, sig.Algorithm) {
+				errors.Handler(w, r, ErrUnsupportedAlgorithm)
+				return
+			}
+
+			for _, header := range config.EnforceHeaders {
+				if !sig.Covers(header) {
+					errors.Handler(w, r, ErrMissingSignedHeader)
+					return
+				}
+			}
+
+			if err := checkFreshness(r, sig, clockSkew); err != nil {
+				logger.WithError(err).Debug("Signed request is not fresh")
+				errors.Handler(w, r, err)
+				return
+			}
+
+			checkDigest := config.ValidateRequestBody || r.Header.Get("Digest") != ""
+			if checkDigest && !sig.Covers("digest") {
+				errors.Handler(w, r, ErrMissingSignedHeader)
+				return
+			}
+
+			c, err := consumer.FindByCredential(consumer.NewCredential(consumer.HMACCredential, sig.KeyID))
+			if err != nil {
+				logger.WithError(err).Debug("Unknown signature key")
+				errors.Handler(w, r, ErrNotAuthorized)
+				return
+			}
+			// the consumer may not own the credential anymore, i.e. when it was removed in the meantime
+			credential, ok := c.Credential(consumer.HMACCredential, sig.KeyID)
+			if !ok {
+				logger.Debug("Consumer has no such signature key")
+				errors.Handler(w, r, ErrNotAuthorized)
+				return
+			}
+
+			signingString, err := sig.SigningString(r)
+			if err != nil {
+				errors.Handler(w, r, err)
+				return
+			}
+
+			expected, err := sig.Sign(signingString, []byte(credential.Secret))
+			if err != nil {
+				errors.Handler(w, r, err)
+				return
+			}
+
+			if !hmac.Equal(expected, sig.Signature) {
+				logger.Debug("Signature does not match the request")
+				errors.Handler(w, r, ErrInvalidSignature)
+				return
+			}
+
+			// the signature covers the digest header, so the body is only read for the authenticated requests
+			if checkDigest {
+				if err := verifyDigest(r, config.MaxBodySize); err != nil {
+					logger.WithError(err).Debug("Request digest verification failed")
+					errors.Handler(w, r, err)
+					return
+				}
+			}
+
+			// a signed request is only valid within the clock skew window, so nonces are kept for the whole window
+			seen, err := nonces.Remember(sig.KeyID+":"+base64.StdEncoding.EncodeToString(sig.Signature), 2*clockSkew)
+			if err != nil {
+				logger.WithError(err).Error("Could not check the request nonce")
+				errors.Handler(w, r, errors.New(http.StatusInternalServerError, "there was an error when checking the request nonce"))
+				return
+			}
+			if seen {
+				logger.Warn("Replayed signed request")
+				errors.Handler(w, r, ErrReplayedRequest)
+				return
+			}
+
+			handler.ServeHTTP(w, consumer.Identify(r, consumer.NewCredential(consumer.HMACCredential, sig.KeyID)))
+		})
+	}
+}
+
+// checkFreshness checks the signature creation time, or the signed Date header when it is not set,
+// is within the clock skew window and the signature is not expired
+func checkFreshness(r *http.Request, sig *Signature, clockSkew time.Duration) error {
+	now := time.Now()
+
+	signedAt := sig.Created
+	if !sig.Covers(created) {
+		if !sig.Covers("date") {
+			return ErrMissingSignedHeader
+		}
+
+		date, err := http.ParseTime(r.Header.Get("Date"))
+		if err != nil {
+			return ErrClockSkew
+		}
+		signedAt = date
+	}
+
+	if signedAt.Before(now.Add(-clockSkew)) || signedAt.After(now.Add(clockSkew)) {
+		return ErrClockSkew
+	}
+
+	if sig.Covers(expires) && now.After(sig.Expires) {
+		return ErrClockSkew
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
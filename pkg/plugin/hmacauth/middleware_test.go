@@ -0,0 +1,194 @@
+package hmacauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	"github.com/hellofresh/janus/pkg/test"
+)
+
+const (
+	keyID  = "partner-key"
+	secret = "partner-secret"
+)
+
+func setupConsumers(t *testing.T) {
+	repo := consumer.NewInMemoryRepository()
+	require.NoError(t, repo.Add(&consumer.Consumer{
+		Username: "partner",
+		Credentials: []*consumer.Credential{
+			{Type: consumer.HMACCredential, Key: keyID, Secret: secret},
+		},
+	}))
+	consumer.SetRepository(repo)
+}
+
+func newSignedRequest(t *testing.T, body string, date time.Time, key, secret string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader(body))
+	r.Header.Set("Date", date.UTC().Format(http.TimeFormat))
+
+	digest := sha256.Sum256([]byte(body))
+	r.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(digest[:]))
+
+	signingString := strings.Join([]string{
+		"(request-target): post /orders?id=1",
+		"host: " + r.Host,
+		"date: " + r.Header.Get("Date"),
+		"digest: " + r.Header.Get("Digest"),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingString))
+
+	r.Header.Set("Authorization", fmt.Sprintf(
+		`Signature keyId="%s",algorithm="hmac-sha256",headers="(request-target) host date digest",signature="%s"`,
+		key, base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	))
+
+	return r
+}
+
+func serve(config Config, nonces NonceStore, r *http.Request) (int, *consumer.Consumer) {
+	var identified *consumer.Consumer
+
+	w := httptest.NewRecorder()
+	NewHMACAuth(config, nonces)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identified, _ = consumer.FromContext(r.Context())
+		test.Ping(w, r)
+	})).ServeHTTP(w, r)
+
+	return w.Code, identified
+}
+
+func defaultConfig(t *testing.T) Config {
+	config, err := decodeConfig(map[string]interface{}{"validate_request_body": true})
+	require.NoError(t, err)
+
+	return config
+}
+
+func TestValidSignature(t *testing.T) {
+	setupConsumers(t)
+
+	code, identified := serve(defaultConfig(t), NewInMemoryNonceStore(), newSignedRequest(t, `{"id":1}`, time.Now(), keyID, secret))
+
+	assert.Equal(t, http.StatusOK, code)
+	require.NotNil(t, identified)
+	assert.Equal(t, "partner", identified.Username)
+}
+
+func TestInvalidSignatures(t *testing.T) {
+	setupConsumers(t)
+
+	tampered := newSignedRequest(t, `{"id":1}`, time.Now(), keyID, secret)
+	tampered.Body = ioutil.NopCloser(strings.NewReader(`{"id":2}`))
+
+	unsigned := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		code int
+	}{
+		{"missing signature", unsigned, http.StatusUnauthorized},
+		{"wrong secret", newSignedRequest(t, `{}`, time.Now(), keyID, "wrong"), http.StatusUnauthorized},
+		{"unknown key", newSignedRequest(t, `{}`, time.Now(), "unknown", secret), http.StatusUnauthorized},
+		{"old date", newSignedRequest(t, `{}`, time.Now().Add(-time.Hour), keyID, secret), http.StatusUnauthorized},
+		{"future date", newSignedRequest(t, `{}`, time.Now().Add(time.Hour), keyID, secret), http.StatusUnauthorized},
+		{"tampered body", tampered, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := serve(defaultConfig(t), NewInMemoryNonceStore(), tt.r)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+// readRecorder records whether the body was read
+type readRecorder struct {
+	io.Reader
+	read bool
+}
+
+func (r *readRecorder) Read(p []byte) (int, error) {
+	r.read = true
+	return r.Reader.Read(p)
+}
+
+func TestBodyNotReadBeforeSignature(t *testing.T) {
+	setupConsumers(t)
+
+	r := newSignedRequest(t, `{"id":1}`, time.Now(), keyID, "wrong")
+	body := &readRecorder{Reader: strings.NewReader(`{"id":1}`)}
+	r.Body = ioutil.NopCloser(body)
+
+	code, _ := serve(defaultConfig(t), NewInMemoryNonceStore(), r)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.False(t, body.read)
+
+	r = newSignedRequest(t, `{"id":1}`, time.Now(), keyID, secret)
+	body = &readRecorder{Reader: strings.NewReader(`{"id":1}`)}
+	r.Body = ioutil.NopCloser(body)
+
+	code, _ = serve(defaultConfig(t), NewInMemoryNonceStore(), r)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, body.read)
+}
+
+// staleRepository returns consumers that don't own the credential they are found by
+type staleRepository struct {
+	*consumer.InMemoryRepository
+}
+
+func (r staleRepository) FindByCredential(credential *consumer.Credential) (*consumer.Consumer, error) {
+	return &consumer.Consumer{Username: "partner"}, nil
+}
+
+func TestConsumerWithoutCredential(t *testing.T) {
+	consumer.SetRepository(staleRepository{consumer.NewInMemoryRepository()})
+	defer setupConsumers(t)
+
+	code, identified := serve(defaultConfig(t), NewInMemoryNonceStore(), newSignedRequest(t, `{"id":1}`, time.Now(), keyID, secret))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Nil(t, identified)
+}
+
+func TestReplayedRequest(t *testing.T) {
+	setupConsumers(t)
+
+	nonces := NewInMemoryNonceStore()
+	r := newSignedRequest(t, `{"id":1}`, time.Now(), keyID, secret)
+	replay := r.Clone(r.Context())
+	replay.Body = ioutil.NopCloser(strings.NewReader(`{"id":1}`))
+
+	code, _ := serve(defaultConfig(t), nonces, r)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = serve(defaultConfig(t), nonces, replay)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestEnforcedHeaders(t *testing.T) {
+	setupConsumers(t)
+
+	config := defaultConfig(t)
+	config.EnforceHeaders = []string{"(request-target)", "x-partner-id"}
+
+	code, _ := serve(config, NewInMemoryNonceStore(), newSignedRequest(t, `{}`, time.Now(), keyID, secret))
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
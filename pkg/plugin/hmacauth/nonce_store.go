@@ -0,0 +1,70 @@
+package hmacauth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// NonceStore remembers the nonces of the requests already seen, to reject replayed requests
+type NonceStore interface {
+	// Remember stores the nonce for the given time and reports whether it was already seen
+	Remember(nonce string, ttl time.Duration) (seen bool, err error)
+}
+
+// InMemoryNonceStore keeps the nonces in memory, it only protects the node it runs on
+type InMemoryNonceStore struct {
+	sync.Mutex
+	nonces    map[string]time.Time
+	lastPurge time.Time
+}
+
+// NewInMemoryNonceStore creates an in memory nonce store
+func NewInMemoryNonceStore() *InMemoryNonceStore {
+	return &InMemoryNonceStore{nonces: make(map[string]time.Time), lastPurge: time.Now()}
+}
+
+// Remember stores the nonce for the given time and reports whether it was already seen
+func (s *InMemoryNonceStore) Remember(nonce string, ttl time.Duration) (bool, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPurge) > ttl {
+		for n, expires := range s.nonces {
+			if now.After(expires) {
+				delete(s.nonces, n)
+			}
+		}
+		s.lastPurge = now
+	}
+
+	if expires, ok := s.nonces[nonce]; ok && now.Before(expires) {
+		return true, nil
+	}
+
+	s.nonces[nonce] = now.Add(ttl)
+	return false, nil
+}
+
+// RedisNonceStore keeps the nonces in redis, so the protection is shared across the nodes
+type RedisNonceStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisNonceStore creates a redis nonce store
+func NewRedisNonceStore(client *redis.Client, prefix string) *RedisNonceStore {
+	return &RedisNonceStore{client: client, prefix: prefix}
+}
+
+// Remember stores the nonce for the given time and reports whether it was already seen
+func (s *RedisNonceStore) Remember(nonce string, ttl time.Duration) (bool, error) {
+	stored, err := s.client.SetNX(s.prefix+":"+nonce, 1, ttl).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return !stored, nil
+}
@@ -0,0 +1,130 @@
+package hmacauth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/go-redis/redis/v7"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+const (
+	// DefaultClockSkew is the default time a signed request is accepted before or after its date
+	DefaultClockSkew = 5 * time.Minute
+	// DefaultMaxBodySize is the default maximum body size buffered to verify its digest
+	DefaultMaxBodySize = 10 << 20
+	// DefaultPrefix is the default prefix to use for the nonce keys in redis
+	DefaultPrefix = "hmac_auth"
+)
+
+var (
+	// ErrInvalidPolicy is used when an invalid nonce store policy was provided
+	ErrInvalidPolicy = errors.New(http.StatusBadRequest, "nonce store policy is not supported")
+	// ErrInvalidAlgorithm is used when an unsupported algorithm is configured
+	ErrInvalidAlgorithm = errors.New(http.StatusBadRequest, "signature algorithm is not supported")
+)
+
+// Config represents the HMAC auth configuration
+type Config struct {
+	Algorithms          []string         `json:"algorithms"`
+	EnforceHeaders      []string         `json:"enforce_headers"`
+	ClockSkew           proxy.Duration   `json:"clock_skew"`
+	ValidateRequestBody bool             `json:"validate_request_body"`
+	MaxBodySize         int64            `json:"max_body_size"`
+	NonceStore          nonceStoreConfig `json:"nonce_store"`
+}
+
+type nonceStoreConfig struct {
+	Policy      string      `json:"policy"`
+	RedisConfig redisConfig `json:"redis"`
+}
+
+type redisConfig struct {
+	DSN    string `json:"dsn"`
+	Prefix string `json:"prefix"`
+}
+
+func init() {
+	plugin.RegisterPlugin("hmac_auth", plugin.Plugin{
+		Action:   setupHMACAuth,
+		Validate: validateConfig,
+	})
+}
+
+func setupHMACAuth(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return err
+	}
+
+	nonces, err := getNonceStore(config.NonceStore)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(NewHMACAuth(config, nonces))
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	config, err := decodeConfig(rawConfig)
+	if err != nil {
+		return false, err
+	}
+
+	for _, algorithm := range config.Algorithms {
+		if _, ok := algorithms[algorithm]; !ok {
+			return false, ErrInvalidAlgorithm
+		}
+	}
+
+	return govalidator.ValidateStruct(config)
+}
+
+func decodeConfig(rawConfig plugin.Config) (Config, error) {
+	var config Config
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return config, err
+	}
+
+	if len(config.Algorithms) == 0 {
+		config.Algorithms = []string{algorithmHMACSHA256, algorithmHMACSHA512, algorithmHS2019}
+	}
+	if len(config.EnforceHeaders) == 0 {
+		config.EnforceHeaders = []string{requestTarget}
+	}
+	if config.ClockSkew == 0 {
+		config.ClockSkew = proxy.Duration(DefaultClockSkew)
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = DefaultMaxBodySize
+	}
+
+	return config, nil
+}
+
+func getNonceStore(config nonceStoreConfig) (NonceStore, error) {
+	switch config.Policy {
+	case "redis":
+		option, err := redis.ParseURL(config.RedisConfig.DSN)
+		if err != nil {
+			return nil, err
+		}
+
+		if config.RedisConfig.Prefix == "" {
+			config.RedisConfig.Prefix = DefaultPrefix
+		}
+
+		return NewRedisNonceStore(redis.NewClient(option), config.RedisConfig.Prefix), nil
+
+	case "local", "":
+		return NewInMemoryNonceStore(), nil
+
+	default:
+		return nil, ErrInvalidPolicy
+	}
+}
@@ -0,0 +1,206 @@
+package hmacauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	requestTarget = "(request-target)"
+	created       = "(created)"
+	expires       = "(expires)"
+
+	algorithmHMACSHA256 = "hmac-sha256"
+	algorithmHMACSHA512 = "hmac-sha512"
+	// algorithmHS2019 lets the verifier derive the algorithm from the key, we use HMAC SHA-512 for it
+	algorithmHS2019 = "hs2019"
+)
+
+var algorithms = map[string]func() hash.Hash{
+	algorithmHMACSHA256: sha256.New,
+	algorithmHMACSHA512: sha512.New,
+	algorithmHS2019:     sha512.New,
+}
+
+// Signature represents the parameters of a HTTP signature
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Signature []byte
+	Created   time.Time
+	Expires   time.Time
+}
+
+// ParseSignature reads the signature from the Signature header or the Authorization header with the Signature scheme
+func ParseSignature(r *http.Request) (*Signature, error) {
+	value := r.Header.Get("Signature")
+	if value == "" {
+		auth := r.Header.Get("Authorization")
+		if len(auth) < 10 || !strings.EqualFold(auth[:10], "Signature ") {
+			return nil, ErrMissingSignature
+		}
+		value = auth[10:]
+	}
+
+	params, err := parseParams(value)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &Signature{
+		KeyID:     params["keyId"],
+		Algorithm: strings.ToLower(params["algorithm"]),
+		Headers:   []string{"date"},
+	}
+
+	if sig.KeyID == "" || params["signature"] == "" {
+		return nil, ErrInvalidSignature
+	}
+
+	if sig.Algorithm == "" {
+		sig.Algorithm = algorithmHS2019
+	}
+
+	if headers, ok := params["headers"]; ok {
+		sig.Headers = strings.Fields(strings.ToLower(headers))
+	}
+
+	if sig.Signature, err = base64.StdEncoding.DecodeString(params["signature"]); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	if sig.Created, err = parseUnix(params["created"]); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	if sig.Expires, err = parseUnix(params["expires"]); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	return sig, nil
+}
+
+// SigningString builds the string that is signed, from the signed headers in the order they were listed
+func (s *Signature) SigningString(r *http.Request) (string, error) {
+	lines := make([]string, 0, len(s.Headers))
+
+	for _, name := range s.Headers {
+		switch name {
+		case requestTarget:
+			lines = append(lines, fmt.Sprintf("%s: %s %s", name, strings.ToLower(r.Method), r.URL.RequestURI()))
+		case created:
+			if s.Created.IsZero() {
+				return "", ErrInvalidSignature
+			}
+			lines = append(lines, fmt.Sprintf("%s: %d", name, s.Created.Unix()))
+		case expires:
+			if s.Expires.IsZero() {
+				return "", ErrInvalidSignature
+			}
+			lines = append(lines, fmt.Sprintf("%s: %d", name, s.Expires.Unix()))
+		case "host":
+			lines = append(lines, fmt.Sprintf("%s: %s", name, r.Host))
+		default:
+			values, ok := r.Header[http.CanonicalHeaderKey(name)]
+			if !ok {
+				return "", ErrMissingSignedHeader
+			}
+
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.TrimSpace(v)
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", name, strings.Join(trimmed, ", ")))
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// Sign computes the signature of the signing string with the given secret
+func (s *Signature) Sign(signingString string, secret []byte) ([]byte, error) {
+	newHash, ok := algorithms[s.Algorithm]
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(signingString))
+
+	return mac.Sum(nil), nil
+}
+
+// Covers checks if the header is part of the signed headers
+func (s *Signature) Covers(name string) bool {
+	for _, h := range s.Headers {
+		if h == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseParams(value string) (map[string]string, error) {
+	params := make(map[string]string)
+
+	for value = strings.TrimSpace(value); value != ""; {
+		eq := strings.Index(value, "=")
+		if eq < 1 {
+			return nil, ErrInvalidSignature
+		}
+
+		name := strings.TrimSpace(value[:eq])
+		value = strings.TrimSpace(value[eq+1:])
+
+		var v string
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				return nil, ErrInvalidSignature
+			}
+			v = value[1 : end+1]
+			value = value[end+2:]
+		} else {
+			end := strings.Index(value, ",")
+			if end < 0 {
+				end = len(value)
+			}
+			v = strings.TrimSpace(value[:end])
+			value = value[end:]
+		}
+
+		params[name] = v
+
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, ",") {
+			value = strings.TrimSpace(value[1:])
+		} else if value != "" {
+			return nil, ErrInvalidSignature
+		}
+	}
+
+	return params, nil
+}
+
+func parseUnix(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(seconds, 0), nil
+}
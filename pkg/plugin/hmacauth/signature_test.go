@@ -0,0 +1,50 @@
+package hmacauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignatureHeader(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Signature", `keyId="key",algorithm="hmac-sha512",created=1402170695,headers="(request-target) (created) Date",signature="c2ln"`)
+
+	sig, err := ParseSignature(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "key", sig.KeyID)
+	assert.Equal(t, "hmac-sha512", sig.Algorithm)
+	assert.Equal(t, []string{"(request-target)", "(created)", "date"}, sig.Headers)
+	assert.Equal(t, []byte("sig"), sig.Signature)
+	assert.Equal(t, int64(1402170695), sig.Created.Unix())
+}
+
+func TestParseSignatureDefaults(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", `Signature keyId="key",signature="c2ln"`)
+
+	sig, err := ParseSignature(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, algorithmHS2019, sig.Algorithm)
+	assert.Equal(t, []string{"date"}, sig.Headers)
+}
+
+func TestParseInvalidSignature(t *testing.T) {
+	for _, value := range []string{
+		`Bearer token`,
+		`Signature keyId="key"`,
+		`Signature keyId="key",signature="not base64!"`,
+		`Signature keyId="key,signature="c2ln"`,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", value)
+
+		_, err := ParseSignature(r)
+		assert.Error(t, err, value)
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"github.com/rs/cors"
 	log "github.com/sirupsen/logrus"
 	"github.com/ulule/limiter/v3"
-	"github.com/ulule/limiter/v3/drivers/middleware/stdlib"
 	storeMemory "github.com/ulule/limiter/v3/drivers/store/memory"
 
+	"github.com/hellofresh/janus/pkg/plugin/rate"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/janus/pkg/router"
 )
@@ -49,16 +49,12 @@ func (m *OAuthLoader) RegisterOAuthServers(oauthServers []*Spec, repo Repository
 		mw = append(mw, corsHandler)
 
 		if oauthServer.RateLimit.Enabled {
-			rate, err := limiter.NewRateFromFormatted(oauthServer.RateLimit.Limit)
+			rateLimitHandler, err := newRateLimitMiddleware(oauthServer.RateLimit.Limit)
 			if err != nil {
 				logger.WithError(err).Error("Not able to create rate limit")
+			} else {
+				mw = append(mw, rateLimitHandler)
 			}
-
-			limiterStore := storeMemory.NewStore()
-			limiterInstance := limiter.New(limiterStore, rate)
-			rateLimitHandler := stdlib.NewMiddleware(limiterInstance).Handler
-
-			mw = append(mw, rateLimitHandler)
 		}
 
 		endpoints := map[*proxy.RouterDefinition][]router.Constructor{
@@ -77,6 +73,22 @@ func (m *OAuthLoader) RegisterOAuthServers(oauthServers []*Spec, repo Repository
 	log.Debug("Done loading OAuth servers configurations")
 }
 
+// newRateLimitMiddleware limits the requests to the oauth server endpoints per client IP
+func newRateLimitMiddleware(limit string) (router.Constructor, error) {
+	r, err := limiter.NewRateFromFormatted(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := rate.NewKeyFunc([]string{"ip"}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	lmt := rate.NewFixedWindowLimiter(limiter.New(storeMemory.NewStore(), r))
+	return rate.NewRateLimitMiddleware([]rate.Limiter{lmt}, key, rate.NewErrorHandler(rate.ErrLimitExceeded)), nil
+}
+
 func (m *OAuthLoader) getOAuthServers(repo Repository) []*Spec {
 	oauthServers, err := repo.FindAll()
 	if err != nil {
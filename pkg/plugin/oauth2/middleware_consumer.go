@@ -0,0 +1,41 @@
+package oauth2
+
+import (
+	"net/http"
+
+	"github.com/hellofresh/janus/pkg/jwt"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewConsumerMiddleware creates a middleware that identifies the consumer by the JWT subject
+// and makes the verified claims available to the plugins enabled after it
+func NewConsumerMiddleware(parser *jwt.Parser) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := parser.ParseFromRequest(r)
+			if err != nil {
+				log.WithError(err).Debug("Could not parse the JWT, consumer is not identified")
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			claims, ok := parser.GetMapClaims(token)
+			if !ok || !token.Valid {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			r = r.WithContext(consumer.NewClaimsContext(r.Context(), claims))
+
+			subject, ok := claims["sub"].(string)
+			if !ok || subject == "" {
+				log.Debug("JWT has no subject, consumer is not identified")
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			handler.ServeHTTP(w, consumer.Identify(r, consumer.NewCredential(consumer.JWTCredential, subject)))
+		})
+	}
+}
@@ -152,8 +152,11 @@ func setupOAuth2(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
 		return err
 	}
 
+	parser := jwt.NewParser(jwt.NewParserConfig(oauthServer.TokenStrategy.Leeway, signingMethods...))
+
 	def.AddMiddleware(NewKeyExistsMiddleware(manager))
-	def.AddMiddleware(NewRevokeRulesMiddleware(jwt.NewParser(jwt.NewParserConfig(oauthServer.TokenStrategy.Leeway, signingMethods...)), oauthServer.AccessRules))
+	def.AddMiddleware(NewConsumerMiddleware(parser))
+	def.AddMiddleware(NewRevokeRulesMiddleware(parser, oauthServer.AccessRules))
 
 	return nil
 }
@@ -0,0 +1,67 @@
+package openapivalidator
+
+import (
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+const (
+	responseValidationOff = ""
+	responseValidationLog = "log"
+)
+
+// Config represents the OpenAPI validator configuration
+type Config struct {
+	// File is the path of the OpenAPI 3 document, in JSON or YAML
+	File string `json:"file"`
+	// Spec is the OpenAPI 3 document, as a JSON object or a JSON or YAML string
+	Spec interface{} `json:"spec"`
+	// BasePath is removed from the beginning of the request paths before looking them up in the document
+	BasePath string `json:"base_path"`
+	// AllowUnknownRoutes lets the requests that are not in the document through
+	AllowUnknownRoutes bool `json:"allow_unknown_routes"`
+	// ResponseValidation logs the responses that don't match the document when set to "log"
+	ResponseValidation string `json:"response_validation"`
+	// MaxBodySize is the size of the response bodies validated, the bigger ones only get their status
+	// and headers validated. Defaults to 1M
+	MaxBodySize string `json:"max_body_size"`
+	// MaxRequestBodySize is the maximum size of the request bodies, the bigger ones are rejected. Defaults to 1M
+	MaxRequestBodySize string `json:"max_request_body_size"`
+}
+
+func init() {
+	plugin.RegisterPlugin("openapi_validator", plugin.Plugin{
+		Action:   setupOpenAPIValidator,
+		Validate: validateConfig,
+	})
+}
+
+func setupOpenAPIValidator(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return err
+	}
+
+	v, err := newValidator(config)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(v.Handler)
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := newValidator(config); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package openapivalidator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+func TestOpenAPIValidatorConfig(t *testing.T) {
+	var config Config
+	rawConfig := map[string]interface{}{
+		"file":                 "testdata/recipes.yaml",
+		"base_path":            "/api",
+		"allow_unknown_routes": true,
+		"response_validation":  "log",
+	}
+
+	err := plugin.Decode(rawConfig, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "testdata/recipes.yaml", config.File)
+	assert.Equal(t, "/api", config.BasePath)
+	assert.True(t, config.AllowUnknownRoutes)
+	assert.Equal(t, responseValidationLog, config.ResponseValidation)
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	assert.NoError(t, setupOpenAPIValidator(def, rawConfig))
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func TestOpenAPIValidatorValidation(t *testing.T) {
+	valid, err := validateConfig(map[string]interface{}{"file": "testdata/recipes.yaml"})
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	valid, err = validateConfig(map[string]interface{}{"spec": "openapi: 3.0.0"})
+	assert.False(t, valid)
+	assert.Error(t, err)
+}
@@ -0,0 +1,334 @@
+package openapivalidator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.cloudfoundry.org/bytefmt"
+	"github.com/felixge/httpsnoop"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+const defaultMaxBodySize = "1M"
+
+// methods are the methods of the operations of an OpenAPI path
+var methods = []string{
+	http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+	http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace,
+}
+
+var (
+	// ErrInvalidRequest is used when the request doesn't match the OpenAPI document
+	ErrInvalidRequest = errors.New(http.StatusBadRequest, "the request does not match the API specification")
+	// ErrUnknownRoute is used when the path of the request is not in the OpenAPI document
+	ErrUnknownRoute = errors.New(http.StatusNotFound, "the path is not in the API specification")
+	// ErrUnknownMethod is used when the method of the request is not allowed on its path in the OpenAPI document
+	ErrUnknownMethod = errors.New(http.StatusMethodNotAllowed, "the method is not in the API specification")
+	// ErrRequestTooLarge is used when the request body is bigger than the max_request_body_size
+	ErrRequestTooLarge = errors.New(http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+)
+
+// Violation is a part of the request not matching the OpenAPI document
+type Violation struct {
+	// In is where the violation is: path, query, header, cookie or body
+	In string `json:"in"`
+	// Name is the name of the parameter
+	Name string `json:"name,omitempty"`
+	// Pointer is the JSON pointer of the invalid value of the body
+	Pointer string `json:"pointer,omitempty"`
+	Message string `json:"message"`
+}
+
+// validator validates the requests, and optionally the responses, against an OpenAPI document
+type validator struct {
+	router             *openapi3filter.Router
+	basePath           string
+	allowUnknownRoutes bool
+	validateResponses  bool
+	limit              int
+	requestLimit       int64
+	options            *openapi3filter.Options
+}
+
+func newValidator(config Config) (*validator, error) {
+	swagger, err := loadSpec(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := swagger.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+
+	// The requests are matched on their path only, the servers of the document are where the upstreams are
+	swagger.Servers = nil
+
+	switch config.ResponseValidation {
+	case responseValidationOff, responseValidationLog:
+	default:
+		return nil, fmt.Errorf("response_validation must be empty or %q", responseValidationLog)
+	}
+
+	limit, err := toBytes(config.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+	requestLimit, err := toBytes(config.MaxRequestBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &validator{
+		router:             openapi3filter.NewRouter().WithSwagger(swagger),
+		basePath:           "/" + strings.Trim(config.BasePath, "/"),
+		allowUnknownRoutes: config.AllowUnknownRoutes,
+		validateResponses:  config.ResponseValidation == responseValidationLog,
+		limit:              int(limit),
+		requestLimit:       int64(requestLimit),
+		options: &openapi3filter.Options{
+			MultiError: true,
+			// The auth plugins authenticate the requests, the security requirements of the document are ignored
+			AuthenticationFunc: func(context.Context, *openapi3filter.AuthenticationInput) error { return nil },
+		},
+	}, nil
+}
+
+func toBytes(size string) (uint64, error) {
+	if size == "" {
+		size = defaultMaxBodySize
+	}
+	return bytefmt.ToBytes(size)
+}
+
+func loadSpec(config Config) (*openapi3.Swagger, error) {
+	loader := openapi3.NewSwaggerLoader()
+
+	switch {
+	case config.File != "" && config.Spec != nil:
+		return nil, fmt.Errorf("only one of file and spec can be set")
+	case config.File != "":
+		return loader.LoadSwaggerFromFile(config.File)
+	case config.Spec != nil:
+		data, ok := config.Spec.(string)
+		if ok {
+			return loader.LoadSwaggerFromData([]byte(data))
+		}
+
+		raw, err := json.Marshal(config.Spec)
+		if err != nil {
+			return nil, err
+		}
+		return loader.LoadSwaggerFromData(raw)
+	default:
+		return nil, fmt.Errorf("one of file and spec is required")
+	}
+}
+
+// Handler is the middleware function
+func (v *validator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.findRoute(r)
+		if err != nil {
+			if v.allowUnknownRoutes {
+				next.ServeHTTP(w, r)
+				return
+			}
+			errors.Handler(w, r, err)
+			return
+		}
+
+		if err := v.readBody(r); err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.options,
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			errors.Handler(w, r, requestError(err))
+			return
+		}
+
+		if !v.validateResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &recorder{limit: v.limit}
+		next.ServeHTTP(rec.wrap(w), r)
+		v.validateResponse(input, rec)
+	})
+}
+
+// readBody buffers the request body up to the max_request_body_size, so that it can be validated and then proxied
+func (v *validator) readBody(r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if r.ContentLength > v.requestLimit {
+		return ErrRequestTooLarge
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, v.requestLimit+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > v.requestLimit {
+		return ErrRequestTooLarge
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+// findRoute looks the path of the request up in the document, without the base path
+func (v *validator) findRoute(r *http.Request) (*openapi3filter.Route, map[string]string, error) {
+	path := r.URL.Path
+	if v.basePath != "/" {
+		if path != v.basePath && !strings.HasPrefix(path, v.basePath+"/") {
+			return nil, nil, ErrUnknownRoute
+		}
+		path = "/" + strings.TrimLeft(path[len(v.basePath):], "/")
+	}
+
+	u := &url.URL{Path: path}
+	route, pathParams, err := v.router.FindRoute(r.Method, u)
+	if err == nil {
+		return route, pathParams, nil
+	}
+
+	for _, method := range methods {
+		if _, _, err := v.router.FindRoute(method, u); err == nil {
+			return nil, nil, ErrUnknownMethod
+		}
+	}
+	return nil, nil, ErrUnknownRoute
+}
+
+func (v *validator) validateResponse(input *openapi3filter.RequestValidationInput, rec *recorder) {
+	options := *v.options
+	options.ExcludeResponseBody = rec.truncated
+
+	err := openapi3filter.ValidateResponse(input.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.status,
+		Header:                 rec.header,
+		Body:                   ioutil.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		Options:                &options,
+	})
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"method": input.Request.Method,
+			"path":   input.Request.URL.Path,
+			"status": rec.status,
+		}).Warn("The response does not match the API specification")
+	}
+}
+
+// requestError describes every violation of the request
+func requestError(err error) *errors.Error {
+	var violations []Violation
+
+	errs, ok := err.(openapi3.MultiError)
+	if !ok {
+		errs = openapi3.MultiError{err}
+	}
+
+	status := http.StatusBadRequest
+	for _, err := range errs {
+		reqErr, ok := err.(*openapi3filter.RequestError)
+		if !ok {
+			violations = append(violations, Violation{Message: err.Error()})
+			continue
+		}
+
+		if reqErr.HTTPStatus() != http.StatusBadRequest && len(errs) == 1 {
+			status = reqErr.HTTPStatus()
+		}
+		violations = append(violations, newViolations(reqErr)...)
+	}
+
+	return &errors.Error{Code: status, Message: ErrInvalidRequest.Message, Details: violations}
+}
+
+func newViolations(err *openapi3filter.RequestError) []Violation {
+	violation := Violation{In: "body", Message: err.Reason}
+	if err.Parameter != nil {
+		violation.In, violation.Name = err.Parameter.In, err.Parameter.Name
+	}
+
+	causes, ok := err.Err.(openapi3.MultiError)
+	if !ok {
+		causes = openapi3.MultiError{err.Err}
+	}
+
+	var violations []Violation
+	for _, cause := range causes {
+		v := violation
+		switch cause := cause.(type) {
+		case nil:
+		case *openapi3.SchemaError:
+			v.Message = cause.Reason
+			if v.In == "body" {
+				v.Pointer = "/" + strings.Join(cause.JSONPointer(), "/")
+			}
+		default:
+			if v.Message == "" || v.Message == cause.Error() {
+				v.Message = cause.Error()
+			} else {
+				v.Message += ": " + cause.Error()
+			}
+		}
+		violations = append(violations, v)
+	}
+
+	return violations
+}
+
+// recorder keeps the status, headers and body of the response while it is sent to the client,
+// up to a limit for the body
+type recorder struct {
+	status    int
+	header    http.Header
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (rec *recorder) wrap(w http.ResponseWriter) http.ResponseWriter {
+	rec.status = http.StatusOK
+	rec.header = w.Header()
+
+	return httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(code int) {
+				rec.status = code
+				next(code)
+			}
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(b []byte) (int, error) {
+				if rec.truncated || rec.body.Len()+len(b) > rec.limit {
+					rec.truncated = true
+				} else {
+					rec.body.Write(b)
+				}
+				return next(b)
+			}
+		},
+	})
+}
@@ -0,0 +1,216 @@
+package openapivalidator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type errorBody struct {
+	Error   string      `json:"error"`
+	Details []Violation `json:"details"`
+}
+
+func serve(t *testing.T, v *validator, upstream http.HandlerFunc, method, url, body string, headers map[string]string) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	called := false
+	w := httptest.NewRecorder()
+	v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if upstream != nil {
+			upstream(w, r)
+			return
+		}
+
+		received, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(received))
+	})).ServeHTTP(w, req)
+
+	return w, called
+}
+
+func TestValidateRequest(t *testing.T) {
+	v, err := newValidator(Config{File: "testdata/recipes.yaml", BasePath: "/api/"})
+	require.NoError(t, err)
+
+	country := map[string]string{"X-Country": "DE"}
+	tests := []struct {
+		scenario   string
+		method     string
+		url        string
+		body       string
+		headers    map[string]string
+		code       int
+		violations []Violation
+	}{
+		{scenario: "valid query", method: http.MethodGet, url: "/api/recipes?limit=10", headers: country, code: http.StatusOK},
+		{scenario: "valid path parameter", method: http.MethodGet, url: "/api/recipes/42", code: http.StatusOK},
+		{
+			scenario: "valid body",
+			method:   http.MethodPost,
+			url:      "/api/recipes",
+			body:     `{"name": "Pasta", "servings": 2}`,
+			headers:  map[string]string{"Content-Type": "application/json"},
+			code:     http.StatusOK,
+		},
+		{
+			scenario: "invalid query and missing header",
+			method:   http.MethodGet,
+			url:      "/api/recipes?limit=1000",
+			code:     http.StatusBadRequest,
+			violations: []Violation{
+				{In: "query", Name: "limit", Message: "Number must be most 100"},
+				{In: "header", Name: "X-Country", Message: "must have a value"},
+			},
+		},
+		{
+			scenario:   "invalid path parameter",
+			method:     http.MethodGet,
+			url:        "/api/recipes/pasta",
+			code:       http.StatusBadRequest,
+			violations: []Violation{{In: "path", Name: "id", Message: `value pasta: an invalid integer: strconv.ParseFloat: parsing "pasta": invalid syntax`}},
+		},
+		{
+			scenario:   "invalid body",
+			method:     http.MethodPost,
+			url:        "/api/recipes",
+			body:       `{"name": "Pasta", "servings": 0}`,
+			headers:    map[string]string{"Content-Type": "application/json"},
+			code:       http.StatusBadRequest,
+			violations: []Violation{{In: "body", Pointer: "/servings", Message: "Number must be at least 1"}},
+		},
+		{scenario: "unknown path", method: http.MethodGet, url: "/api/orders", code: http.StatusNotFound},
+		{scenario: "outside of the base path", method: http.MethodGet, url: "/recipes", code: http.StatusNotFound},
+		{scenario: "unknown method", method: http.MethodDelete, url: "/api/recipes/42", code: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			w, called := serve(t, v, nil, tt.method, tt.url, tt.body, tt.headers)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.code == http.StatusOK, called)
+
+			if tt.violations != nil {
+				var body errorBody
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, ErrInvalidRequest.Message, body.Error)
+				assert.Equal(t, tt.violations, body.Details)
+			}
+		})
+	}
+}
+
+func TestAllowUnknownRoutes(t *testing.T) {
+	v, err := newValidator(Config{File: "testdata/recipes.yaml", BasePath: "/api", AllowUnknownRoutes: true})
+	require.NoError(t, err)
+
+	w, called := serve(t, v, nil, http.MethodGet, "/api/orders", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, called)
+}
+
+func TestInlineSpec(t *testing.T) {
+	spec := map[string]interface{}{
+		"openapi": "3.0.0",
+		"info":    map[string]interface{}{"title": "Health", "version": "1.0.0"},
+		"paths": map[string]interface{}{
+			"/health": map[string]interface{}{
+				"get": map[string]interface{}{
+					"responses": map[string]interface{}{"200": map[string]interface{}{"description": "OK"}},
+				},
+			},
+		},
+	}
+
+	v, err := newValidator(Config{Spec: spec})
+	require.NoError(t, err)
+
+	w, _ := serve(t, v, nil, http.MethodGet, "/health", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	yaml, err := ioutil.ReadFile("testdata/recipes.yaml")
+	require.NoError(t, err)
+	_, err = newValidator(Config{Spec: string(yaml)})
+	assert.NoError(t, err)
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	v, err := newValidator(Config{File: "testdata/recipes.yaml", MaxRequestBodySize: "40B"})
+	require.NoError(t, err)
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	w, called := serve(t, v, nil, http.MethodPost, "/recipes", `{"name": "Pasta", "servings": 2}`, headers)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, called)
+
+	w, called = serve(t, v, nil, http.MethodPost, "/recipes", `{"name": "Pasta with tomatoes", "servings": 2}`, headers)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.False(t, called)
+
+	// The requests without a length are limited too
+	req := httptest.NewRequest(http.MethodPost, "/recipes", ioutil.NopCloser(strings.NewReader(`{"name": "Pasta with tomatoes", "servings": 2}`)))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the request should not be proxied")
+	})).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestValidateResponse(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	v, err := newValidator(Config{File: "testdata/recipes.yaml", ResponseValidation: responseValidationLog})
+	require.NoError(t, err)
+
+	respond := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(body))
+		}
+	}
+
+	w, _ := serve(t, v, respond(`{"name": "Pasta"}`), http.MethodGet, "/recipes/1", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, hook.AllEntries())
+
+	w, _ = serve(t, v, respond(`{"servings": 2}`), http.MethodGet, "/recipes/1", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code, "the invalid responses are only logged")
+	assert.Equal(t, `{"servings": 2}`, w.Body.String())
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+}
+
+func TestValidatorConfig(t *testing.T) {
+	for _, config := range []Config{
+		{},
+		{File: "testdata/recipes.yaml", Spec: "openapi: 3.0.0"},
+		{File: "testdata/missing.yaml"},
+		{Spec: "openapi: 3.0.0"},
+		{File: "testdata/recipes.yaml", ResponseValidation: "enforce"},
+		{File: "testdata/recipes.yaml", MaxBodySize: "big"},
+		{File: "testdata/recipes.yaml", MaxRequestBodySize: "big"},
+	} {
+		_, err := newValidator(config)
+		assert.Error(t, err, "%+v", config)
+	}
+}
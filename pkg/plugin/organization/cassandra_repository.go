@@ -14,6 +14,8 @@ type Repository interface {
 	FindOrganization(organization string) (*OrganizationConfig, error)
 	Add(organization *Organization) error
 	AddOrganization(organization *OrganizationConfig) error
+	SetGroups(username string, groups []string) error
+	UpdatePasswordHash(username string, hash string) error
 	Remove(username string) error
 }
 
@@ -34,20 +36,22 @@ func (r *CassandraRepository) FindAll() ([]*Organization, error) {
 
 	var results []*Organization
 
-	iter := r.session.GetSession().Query("SELECT username, organization, password FROM organization").Iter()
+	iter := r.session.GetSession().Query("SELECT username, organization, password, groups FROM organization").Iter()
 
 	var username string
 	var comp string
 	var pass string
+	var groups []string
 
 	err := iter.ScanAndClose(func() bool {
 		var organization Organization
 		organization.Username = username
 		organization.Organization = comp
 		organization.Password = pass
+		organization.Groups = groups
 		results = append(results, &organization)
 		return true
-	}, &username, &comp, &pass)
+	}, &username, &comp, &pass, &groups)
 
 	if err != nil {
 		log.Errorf("error getting all organization users: %v", err)
@@ -64,10 +68,10 @@ func (r *CassandraRepository) FindByUsername(username string) (*Organization, er
 	var organization Organization
 
 	err := r.session.GetSession().Query(
-		"SELECT username, organization, password "+
+		"SELECT username, organization, password, groups "+
 			"FROM organization "+
 			"WHERE username = ?",
-		username).Scan(&organization.Username, &organization.Organization, &organization.Password)
+		username).Scan(&organization.Username, &organization.Organization, &organization.Password, &organization.Groups)
 
 	if err != nil {
 		if err.Error() == "not found" {
@@ -91,13 +95,14 @@ func (r *CassandraRepository) FindOrganization(organization string) (*Organizati
 	var bOrgConfig []byte
 
 	err := r.session.GetSession().Query(
-		"SELECT organization, priority, content_per_day, config "+
+		"SELECT organization, priority, content_per_day, content_per_month, config "+
 			"FROM organization_config "+
 			"WHERE organization = ?",
 		organization).Scan(
 		&organizationConfig.Organization,
 		&organizationConfig.Priority,
 		&organizationConfig.ContentPerDay,
+		&organizationConfig.ContentPerMonth,
 		&bOrgConfig)
 
 	if err != nil {
@@ -132,9 +137,10 @@ func (r *CassandraRepository) Add(organization *Organization) error {
 	err = r.session.GetSession().Query(
 		"UPDATE organization "+
 			"SET organization = ?, "+
-			"password = ? "+
+			"password = ?, "+
+			"groups = ? "+
 			"WHERE username = ?",
-		organization.Organization, hash, organization.Username).Exec()
+		organization.Organization, hash, organization.Groups, organization.Username).Exec()
 
 	if err != nil {
 		log.Errorf("error saving organization user %s: %v", organization.Username, err)
@@ -158,9 +164,10 @@ func (r *CassandraRepository) AddOrganization(organization *OrganizationConfig)
 		"UPDATE organization_config "+
 			"SET priority = ?, "+
 			"content_per_day = ?, "+
+			"content_per_month = ?, "+
 			"config = ? "+
 			"WHERE organization = ?",
-		organization.Priority, organization.ContentPerDay, bOrgConfig, organization.Organization).Exec()
+		organization.Priority, organization.ContentPerDay, organization.ContentPerMonth, bOrgConfig, organization.Organization).Exec()
 
 	if err != nil {
 		log.Errorf("error saving organization %s: %v", organization.Organization, err)
@@ -171,6 +178,48 @@ func (r *CassandraRepository) AddOrganization(organization *OrganizationConfig)
 	return err
 }
 
+// SetGroups replaces the groups the user is member of
+func (r *CassandraRepository) SetGroups(username string, groups []string) error {
+	log.Debugf("setting groups of: %s", username)
+
+	if _, err := r.FindByUsername(username); err != nil {
+		return err
+	}
+
+	err := r.session.GetSession().Query(
+		"UPDATE organization "+
+			"SET groups = ? "+
+			"WHERE username = ?",
+		groups, username).Exec()
+
+	if err != nil {
+		log.Errorf("error saving groups of organization user %s: %v", username, err)
+	} else {
+		log.Debugf("successfully saved groups of organization user %s", username)
+	}
+
+	return err
+}
+
+// UpdatePasswordHash replaces the password hash of the user with an already hashed one
+func (r *CassandraRepository) UpdatePasswordHash(username string, hash string) error {
+	log.Debugf("updating password hash of: %s", username)
+
+	err := r.session.GetSession().Query(
+		"UPDATE organization "+
+			"SET password = ? "+
+			"WHERE username = ? IF EXISTS",
+		hash, username).Exec()
+
+	if err != nil {
+		log.Errorf("error saving password hash of organization user %s: %v", username, err)
+	} else {
+		log.Debugf("successfully saved password hash of organization user %s", username)
+	}
+
+	return err
+}
+
 // Remove an user from the repository
 func (r *CassandraRepository) Remove(username string) error {
 	log.Debugf("removing: %s", username)
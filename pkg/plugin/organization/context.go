@@ -0,0 +1,28 @@
+package organization
+
+import (
+	"context"
+)
+
+type organizationKeyType int
+
+const organizationKey organizationKeyType = iota
+
+// NewContext puts the config of the organization of the authenticated user to context for future use
+func NewContext(ctx context.Context, config *OrganizationConfig) context.Context {
+	if ctx == nil {
+		panic("Can not put organization to empty context")
+	}
+
+	return context.WithValue(ctx, organizationKey, config)
+}
+
+// FromContext tries to extract the config of the organization of the authenticated user from context if present
+func FromContext(ctx context.Context) (*OrganizationConfig, bool) {
+	if ctx == nil {
+		panic("Can not get organization from empty context")
+	}
+
+	config, ok := ctx.Value(organizationKey).(*OrganizationConfig)
+	return config, ok
+}
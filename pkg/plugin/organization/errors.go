@@ -15,4 +15,6 @@ var (
 	ErrUserExists = errors.New(http.StatusNotFound, "user already exists")
 	// ErrInvalidAdminRouter is used when an invalid admin router is given
 	ErrInvalidAdminRouter = errors.New(http.StatusNotFound, "invalid admin router given")
+	// ErrUsageNotTracked is used when no plugin tracks the usage of the organizations
+	ErrUsageNotTracked = errors.New(http.StatusNotFound, "usage is not tracked, the quota plugin is not enabled")
 )
@@ -72,6 +72,38 @@ func (c *Handler) ShowOrganization() http.HandlerFunc {
 	}
 }
 
+// Usage is the organization usage handler
+func (c *Handler) Usage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reporter := getUsageReporter()
+		if reporter == nil {
+			errors.Handler(w, r, ErrUsageNotTracked)
+			return
+		}
+
+		organization := router.URLParam(r, "organization")
+		_, span := trace.StartSpan(r.Context(), "repo.FindOrganization")
+		organizationConfig, err := c.repo.FindOrganization(organization)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		ctx, span := trace.StartSpan(r.Context(), "usage.Usage")
+		data, err := reporter.Usage(ctx, organizationConfig)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		render.JSON(w, http.StatusOK, data)
+	}
+}
+
 // Update is the update handler
 func (c *Handler) Update() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -144,6 +176,7 @@ func (c *Handler) Create() http.HandlerFunc {
 			Username:     organization.Username,
 			Organization: organization.Organization,
 			Password:     organization.Password,
+			Groups:       organization.Groups,
 		}
 
 		_, span = trace.StartSpan(r.Context(), "repo.Add")
@@ -162,10 +195,11 @@ func (c *Handler) Create() http.HandlerFunc {
 		if err == ErrUserNotFound {
 			// create organization if it doesn't already exist
 			organizationConfig := &OrganizationConfig{
-				Organization:  organization.Organization,
-				Priority:      organization.Priority,
-				ContentPerDay: organization.ContentPerDay,
-				Config:        organization.Config,
+				Organization:    organization.Organization,
+				Priority:        organization.Priority,
+				ContentPerDay:   organization.ContentPerDay,
+				ContentPerMonth: organization.ContentPerMonth,
+				Config:          organization.Config,
 			}
 
 			if organization.Config == nil {
@@ -286,3 +320,49 @@ func (c *Handler) Delete() http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// Groups is the find groups handler
+func (c *Handler) Groups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+		_, span := trace.StartSpan(r.Context(), "repo.FindByUsername")
+		user, err := c.repo.FindByUsername(username)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		groups := user.Groups
+		if groups == nil {
+			groups = make([]string, 0)
+		}
+
+		render.JSON(w, http.StatusOK, groups)
+	}
+}
+
+// UpdateGroups is the replace groups handler
+func (c *Handler) UpdateGroups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := router.URLParam(r, "username")
+
+		groups := make([]string, 0)
+		if err := json.NewDecoder(r.Body).Decode(&groups); err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		_, span := trace.StartSpan(r.Context(), "repo.SetGroups")
+		err := c.repo.SetGroups(username, groups)
+		span.End()
+
+		if err != nil {
+			errors.Handler(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,136 @@
+package organization
+
+import (
+	"sync"
+
+	"github.com/hellofresh/janus/pkg/plugin/basic/encrypt"
+	log "github.com/sirupsen/logrus"
+)
+
+// InMemoryRepository represents a in memory repository
+type InMemoryRepository struct {
+	sync.RWMutex
+	users         map[string]*Organization
+	organizations map[string]*OrganizationConfig
+	hash          encrypt.Hash
+}
+
+// NewInMemoryRepository creates a in memory repository
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{
+		users:         make(map[string]*Organization),
+		organizations: make(map[string]*OrganizationConfig),
+	}
+}
+
+// FindAll fetches all the organization users available
+func (r *InMemoryRepository) FindAll() ([]*Organization, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	var users []*Organization
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// FindByUsername find an user by username
+func (r *InMemoryRepository) FindByUsername(username string) (*Organization, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.findByUsername(username)
+}
+
+// FindOrganization find an organization config by its name
+func (r *InMemoryRepository) FindOrganization(organization string) (*OrganizationConfig, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	organizationConfig, ok := r.organizations[organization]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	return organizationConfig, nil
+}
+
+// Add adds an user to the repository
+func (r *InMemoryRepository) Add(organization *Organization) error {
+	r.Lock()
+	defer r.Unlock()
+
+	hash, err := r.hash.Generate(organization.Password)
+	if err != nil {
+		log.Errorf("error hashing password: %v", err)
+		return err
+	}
+	organization.Password = hash
+
+	r.users[organization.Username] = organization
+
+	return nil
+}
+
+// AddOrganization adds an organization to the repository
+func (r *InMemoryRepository) AddOrganization(organization *OrganizationConfig) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.organizations[organization.Organization] = organization
+
+	return nil
+}
+
+// SetGroups replaces the groups the user is member of
+func (r *InMemoryRepository) SetGroups(username string, groups []string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	user, err := r.findByUsername(username)
+	if err != nil {
+		return err
+	}
+	user.Groups = groups
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the password hash of the user with an already hashed one
+func (r *InMemoryRepository) UpdatePasswordHash(username string, hash string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	user, err := r.findByUsername(username)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+
+	return nil
+}
+
+// Remove removes an user from the repository
+func (r *InMemoryRepository) Remove(username string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, err := r.findByUsername(username); err != nil {
+		return err
+	}
+
+	delete(r.users, username)
+
+	return nil
+}
+
+func (r *InMemoryRepository) findByUsername(username string) (*Organization, error) {
+	user, ok := r.users[username]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+
+	return user, nil
+}
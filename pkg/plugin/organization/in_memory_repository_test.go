@@ -0,0 +1,32 @@
+package organization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryRepository(t *testing.T) {
+	repo := setupRepo(t)
+
+	user, err := repo.FindByUsername("test")
+	require.NoError(t, err)
+	assert.Equal(t, "motiv", user.Organization)
+	assert.NotEqual(t, "test", user.Password)
+
+	_, err = repo.FindByUsername("wrong")
+	assert.Equal(t, ErrUserNotFound, err)
+
+	organizationConfig, err := repo.FindOrganization("motiv")
+	require.NoError(t, err)
+	assert.Equal(t, "motiv", organizationConfig.Organization)
+
+	require.NoError(t, repo.SetGroups("test", []string{"admin"}))
+	user, err = repo.FindByUsername("test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, user.Groups)
+
+	require.NoError(t, repo.Remove("test"))
+	assert.Equal(t, ErrUserNotFound, repo.Remove("test"))
+}
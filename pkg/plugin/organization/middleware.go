@@ -3,7 +3,9 @@ package organization
 import (
 	"encoding/json"
 	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin/basic"
 	"github.com/hellofresh/janus/pkg/plugin/basic/encrypt"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
 	log "github.com/sirupsen/logrus"
 	"net/http"
 )
@@ -12,7 +14,10 @@ const organizationHeader = "X-Organization"
 const organizationConfigHeader = "X-OrganizationConfig"
 
 // NewOrganization is a HTTP organization middleware
-func NewOrganization(organization Organization, repo Repository) func(handler http.Handler) http.Handler {
+func NewOrganization(defaultOrganization Organization, repo Repository) func(handler http.Handler) http.Handler {
+	cache := basic.NewCredentialCache(basic.DefaultCredentialCacheTTL, basic.DefaultCredentialCacheSize)
+	hash := encrypt.Hash{}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			query := r.URL.Query()
@@ -29,35 +34,40 @@ func NewOrganization(organization Organization, repo Repository) func(handler ht
 				return
 			}
 
-			var found bool
-			users, err := repo.FindAll()
+			user, err := repo.FindByUsername(username)
+			if err == ErrUserNotFound {
+				hash.CompareMissing(password)
+				logger.Debug("Invalid user/password provided.")
+				errors.Handler(w, r, ErrNotAuthorized)
+				return
+			}
 			if err != nil {
-				log.WithError(err).Error("Error when getting all users")
+				log.WithError(err).Error("Error when looking for the user")
 				errors.Handler(w, r, errors.New(http.StatusInternalServerError, "there was an error when looking for users"))
 				return
 			}
 
-			hash := encrypt.Hash{}
-			var organizationConfig *OrganizationConfig
+			passwordHash := user.Password
+			if !cache.Verified(username, password, passwordHash) {
+				if hash.Compare(passwordHash, password) != nil {
+					logger.Debug("Invalid user/password provided.")
+					errors.Handler(w, r, ErrNotAuthorized)
+					return
+				}
 
-			for _, u := range users {
-				//if username == u.Username && (subtle.ConstantTimeCompare([]byte(password), []byte(u.Password)) == 1) {
-				if username == u.Username && (hash.Compare(u.Password, password) == nil) {
-					found = true
-					organization.Organization = u.Organization
-					organizationConfig, err = repo.FindOrganization(u.Organization)
-					if err != nil {
-						log.WithError(err).Error("Error finding organization")
-						errors.Handler(w, r, errors.New(http.StatusInternalServerError, "there was an error when looking for organization"))
-						return
-					}
-					break
+				if hash.NeedsRehash(passwordHash) {
+					passwordHash = hash.Rehash(repo, username, password, passwordHash)
 				}
+
+				cache.Add(username, password, passwordHash)
 			}
 
-			if !found {
-				logger.Debug("Invalid user/password provided.")
-				errors.Handler(w, r, ErrNotAuthorized)
+			organization := defaultOrganization
+			organization.Organization = user.Organization
+			organizationConfig, err := repo.FindOrganization(user.Organization)
+			if err != nil {
+				log.WithError(err).Error("Error finding organization")
+				errors.Handler(w, r, errors.New(http.StatusInternalServerError, "there was an error when looking for organization"))
 				return
 			}
 
@@ -78,12 +88,13 @@ func NewOrganization(organization Organization, repo Repository) func(handler ht
 					return
 				}
 				r.Header.Add(organizationConfigHeader, string(bOrganizationConfig))
+				r = r.WithContext(NewContext(r.Context(), organizationConfig))
 			} else {
 				log.Debugf("No organization associated with user")
 			}
 
 			r.URL.RawQuery = query.Encode()
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, consumer.Identify(r, consumer.NewCredential(consumer.BasicCredential, username), user.Groups...))
 		})
 	}
 }
@@ -0,0 +1,63 @@
+package organization
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/test"
+)
+
+func TestAuthorizedAccess(t *testing.T) {
+	var organizationHeaderValue string
+	mw := NewOrganization(Organization{}, setupRepo(t))
+
+	w, err := test.Record(
+		"GET",
+		"/",
+		map[string]string{
+			"Authorization": "Basic " + basicAuth("test", "test"),
+		},
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			organizationHeaderValue = r.Header.Get(organizationHeader)
+		})),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "motiv", organizationHeaderValue)
+}
+
+func TestUnauthorizedAccess(t *testing.T) {
+	mw := NewOrganization(Organization{}, setupRepo(t))
+
+	for _, credentials := range []string{basicAuth("test", "wrong"), basicAuth("wrong", "test")} {
+		w, err := test.Record(
+			"GET",
+			"/",
+			map[string]string{
+				"Authorization": "Basic " + credentials,
+			},
+			mw(http.HandlerFunc(test.Ping)),
+		)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+}
+
+func basicAuth(username, password string) string {
+	auth := username + ":" + password
+	return base64.StdEncoding.EncodeToString([]byte(auth))
+}
+
+func setupRepo(t *testing.T) Repository {
+	repo := NewInMemoryRepository()
+	require.NoError(t, repo.Add(&Organization{Username: "test", Password: "test", Organization: "motiv"}))
+	require.NoError(t, repo.AddOrganization(&OrganizationConfig{Organization: "motiv", Config: map[string]interface{}{}}))
+
+	return repo
+}
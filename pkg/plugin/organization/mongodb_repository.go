@@ -0,0 +1,189 @@
+package organization
+
+import (
+	"context"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/plugin/basic/encrypt"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	collectionName       = "organization_auth"
+	configCollectionName = "organization_config"
+
+	mongoQueryTimeout = 10 * time.Second
+)
+
+// MongoRepository represents a mongodb repository
+type MongoRepository struct {
+	collection       *mongo.Collection
+	configCollection *mongo.Collection
+	hash             encrypt.Hash
+}
+
+// NewMongoRepository creates a mongo organization repo
+func NewMongoRepository(db *mongo.Database) (*MongoRepository, error) {
+	return &MongoRepository{
+		collection:       db.Collection(collectionName),
+		configCollection: db.Collection(configCollectionName),
+		hash:             encrypt.Hash{},
+	}, nil
+}
+
+// FindAll fetches all the organization users available
+func (r *MongoRepository) FindAll() ([]*Organization, error) {
+	var result []*Organization
+
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	cur, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "username", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		u := new(Organization)
+		if err := cur.Decode(u); err != nil {
+			return nil, err
+		}
+
+		result = append(result, u)
+	}
+
+	return result, cur.Err()
+}
+
+// FindByUsername find an user by username
+func (r *MongoRepository) FindByUsername(username string) (*Organization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	var result Organization
+	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
+	}
+
+	return &result, err
+}
+
+// FindOrganization find an organization config by its name
+func (r *MongoRepository) FindOrganization(organization string) (*OrganizationConfig, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	var result OrganizationConfig
+	err := r.configCollection.FindOne(ctx, bson.M{"organization": organization}).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
+	}
+
+	return &result, err
+}
+
+// Add adds an user to the repository
+func (r *MongoRepository) Add(organization *Organization) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	hash, err := r.hash.Generate(organization.Password)
+	if err != nil {
+		log.Errorf("error hashing password: %v", err)
+		return err
+	}
+
+	organization.Password = hash
+
+	if err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"username": organization.Username},
+		bson.M{"$set": organization},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Err(); err != nil && err != mongo.ErrNoDocuments {
+		log.WithField("username", organization.Username).Error("There was an error adding the organization user")
+		return err
+	}
+
+	log.WithField("username", organization.Username).Debug("Organization user added")
+	return nil
+}
+
+// AddOrganization adds an organization to the repository
+func (r *MongoRepository) AddOrganization(organization *OrganizationConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	if err := r.configCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"organization": organization.Organization},
+		bson.M{"$set": organization},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Err(); err != nil && err != mongo.ErrNoDocuments {
+		log.WithField("organization", organization.Organization).Error("There was an error adding the organization")
+		return err
+	}
+
+	log.WithField("organization", organization.Organization).Debug("Organization added")
+	return nil
+}
+
+// SetGroups replaces the groups the user is member of
+func (r *MongoRepository) SetGroups(username string, groups []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"username": username}, bson.M{"$set": bson.M{"groups": groups}})
+	if err != nil {
+		log.WithField("username", username).Error("There was an error updating the organization user groups")
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the password hash of the user with an already hashed one
+func (r *MongoRepository) UpdatePasswordHash(username string, hash string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"username": username}, bson.M{"$set": bson.M{"password": hash}})
+	if err != nil {
+		log.WithField("username", username).Error("There was an error updating the organization user password")
+		return err
+	}
+
+	if res.MatchedCount < 1 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Remove an user from the repository
+func (r *MongoRepository) Remove(username string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), mongoQueryTimeout)
+	defer cancel()
+
+	res, err := r.collection.DeleteOne(ctx, bson.M{"username": username})
+	if err != nil {
+		log.WithField("username", username).Error("There was an error removing the organization user")
+		return err
+	}
+
+	if res.DeletedCount < 1 {
+		return ErrUserNotFound
+	}
+
+	log.WithField("username", username).Debug("Organization user removed")
+	return nil
+}
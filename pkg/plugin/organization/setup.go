@@ -1,14 +1,23 @@
 package organization
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
+
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/plugin/basic"
 	"github.com/hellofresh/janus/pkg/proxy"
 	"github.com/hellofresh/janus/pkg/router"
 	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
+const mongoIdxTimeout = 10 * time.Second
+
 var (
 	repo        Repository
 	basicRepo   basic.Repository
@@ -17,26 +26,30 @@ var (
 
 // Organization represents the configuration to save the user and organization pair
 type Organization struct {
-	Username     string `json:"username"`
-	Organization string `json:"organization"`
-	Password     string `json:"password"`
+	Username     string   `json:"username"`
+	Organization string   `json:"organization"`
+	Password     string   `json:"password"`
+	Groups       []string `json:"groups"`
 }
 
 // OrganizationConfig represents the configuration to save the user and organization pair
 type OrganizationConfig struct {
-	Organization  string                 `json:"organization"`
-	Priority      int                    `json:"priority"`
-	ContentPerDay int                    `json:"contentPerDay"`
-	Config        map[string]interface{} `json:"config"`
+	Organization    string                 `json:"organization"`
+	Priority        int                    `json:"priority"`
+	ContentPerDay   int                    `json:"contentPerDay"`
+	ContentPerMonth int                    `json:"contentPerMonth"`
+	Config          map[string]interface{} `json:"config"`
 }
 
 type OrganizationUserAndConfig struct {
-	Username      string                 `json:"username"`
-	Organization  string                 `json:"organization"`
-	Password      string                 `json:"password"`
-	Priority      int                    `json:"priority"`
-	ContentPerDay int                    `json:"contentPerDay"`
-	Config        map[string]interface{} `json:"config"`
+	Username        string                 `json:"username"`
+	Organization    string                 `json:"organization"`
+	Password        string                 `json:"password"`
+	Groups          []string               `json:"groups"`
+	Priority        int                    `json:"priority"`
+	ContentPerDay   int                    `json:"contentPerDay"`
+	ContentPerMonth int                    `json:"contentPerMonth"`
+	Config          map[string]interface{} `json:"config"`
 }
 
 func init() {
@@ -83,11 +96,38 @@ func onStartup(event interface{}) error {
 
 	if e.MongoDB != nil {
 		log.Debug("Mongo DB is set, using mongo repository for organization plugin")
-		log.Debug("unimplemented")
-		//repo, err = NewMongoRepository(e.MongoDB)
+
+		repo, err = NewMongoRepository(e.MongoDB)
 		if err != nil {
 			return err
 		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), mongoIdxTimeout)
+		defer cancel()
+
+		if _, err := e.MongoDB.Collection(collectionName).Indexes().CreateOne(
+			ctx,
+			mongo.IndexModel{
+				Keys: bsonx.Doc{
+					{Key: "username", Value: bsonx.Int32(1)},
+				},
+				Options: options.Index().SetUnique(true).SetBackground(true),
+			},
+		); err != nil {
+			return fmt.Errorf("failed to create indexes for organization repository: %w", err)
+		}
+
+		if _, err := e.MongoDB.Collection(configCollectionName).Indexes().CreateOne(
+			ctx,
+			mongo.IndexModel{
+				Keys: bsonx.Doc{
+					{Key: "organization", Value: bsonx.Int32(1)},
+				},
+				Options: options.Index().SetUnique(true).SetBackground(true),
+			},
+		); err != nil {
+			return fmt.Errorf("failed to create indexes for organization config repository: %w", err)
+		}
 	} else if e.Cassandra != nil {
 		log.Debugf("Cassandra is set, using cassandra repository for organization plugin")
 
@@ -98,8 +138,8 @@ func onStartup(event interface{}) error {
 		}
 	} else {
 		log.Debug("No DB set, using memory repository for organization plugin")
-		log.Debug("unimplemented")
-		//repo = NewInMemoryRepository()
+
+		repo = NewInMemoryRepository()
 	}
 
 	if adminRouter == nil {
@@ -114,9 +154,12 @@ func onStartup(event interface{}) error {
 		group.POST("/organization", handlers.CreateOrganization())
 		group.GET("/{username}", handlers.Show())
 		group.GET("/organization/{organization}", handlers.ShowOrganization())
+		group.GET("/organization/{organization}/usage", handlers.Usage())
 		group.PUT("/{username}", handlers.Update())
 		group.PUT("/organization/{organization}", handlers.UpdateOrganization())
 		group.DELETE("/{username}", handlers.Delete())
+		group.GET("/{username}/groups", handlers.Groups())
+		group.PUT("/{username}/groups", handlers.UpdateGroups())
 	}
 
 	return nil
@@ -0,0 +1,32 @@
+package organization
+
+import (
+	"context"
+	"sync"
+)
+
+// UsageReporter reports how much of its quotas an organization used
+type UsageReporter interface {
+	Usage(ctx context.Context, config *OrganizationConfig) (interface{}, error)
+}
+
+var (
+	usageReporter   UsageReporter
+	usageReporterMu sync.RWMutex
+)
+
+// SetUsageReporter sets the reporter of the usage exposed by the admin API,
+// it is set by the plugin enforcing the quotas
+func SetUsageReporter(r UsageReporter) {
+	usageReporterMu.Lock()
+	defer usageReporterMu.Unlock()
+
+	usageReporter = r
+}
+
+func getUsageReporter() UsageReporter {
+	usageReporterMu.RLock()
+	defer usageReporterMu.RUnlock()
+
+	return usageReporter
+}
@@ -0,0 +1,44 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/hellofresh/janus/cassandra/wrapper"
+)
+
+// CassandraStore counts the usage in a Cassandra counter table
+type CassandraStore struct {
+	session wrapper.Holder
+}
+
+// NewCassandraStore creates a cassandra store
+func NewCassandraStore(session wrapper.Holder) *CassandraStore {
+	return &CassandraStore{session: session}
+}
+
+// Increment adds the units to the usage of the organization in the window and returns the new usage.
+// Counters can't be read in the same query they are updated with, so concurrent increments may be
+// included in the returned usage.
+func (s *CassandraStore) Increment(ctx context.Context, organization string, window Window, units int64) (int64, error) {
+	err := s.session.GetSession().Query(
+		"UPDATE quota_usage SET units = units + ? WHERE organization = ? AND period = ?",
+		units, organization, window.Name+":"+window.Period).Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Usage(ctx, organization, window)
+}
+
+// Usage returns the usage of the organization in the window
+func (s *CassandraStore) Usage(_ context.Context, organization string, window Window) (int64, error) {
+	var units int64
+	err := s.session.GetSession().Query(
+		"SELECT units FROM quota_usage WHERE organization = ? AND period = ?",
+		organization, window.Name+":"+window.Period).Scan(&units)
+	if err != nil && err.Error() == "not found" {
+		return 0, nil
+	}
+
+	return units, err
+}
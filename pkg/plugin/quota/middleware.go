@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/plugin/organization"
+)
+
+// ErrQuotaExceeded is used when the organization used all of its daily or monthly quota
+var ErrQuotaExceeded = errors.New(http.StatusTooManyRequests, "quota exceeded")
+
+// Settings are the quota settings shared by all the APIs
+type Settings struct {
+	Location *time.Location
+	// WarningThreshold is the percentage of a quota an organization has to use for a warning to be emitted
+	WarningThreshold int64
+}
+
+type windowLimit struct {
+	window Window
+	limit  int64
+}
+
+// NewQuotaMiddleware counts the units of the requests of the organization authenticated by the organization auth plugin,
+// and rejects them once its daily or monthly quota is used
+func NewQuotaMiddleware(config Config, store Store, settings Settings) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			org, ok := organization.FromContext(r.Context())
+			if !ok {
+				log.Debug("No organization authenticated, quota is not counted")
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			units := config.UnitsFor(r)
+			limits := windowLimits(org, time.Now().In(settings.Location))
+			if units == 0 || len(limits) == 0 {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			logger := log.WithField("organization", org.Organization)
+			incremented := make([]Window, 0, len(limits))
+			for _, l := range limits {
+				used, err := store.Increment(r.Context(), org.Organization, l.window, units)
+				if err != nil {
+					logger.WithError(err).WithField("window", l.window.Name).Error("Could not count quota usage")
+					continue
+				}
+				incremented = append(incremented, l.window)
+
+				if used > l.limit {
+					rollback(r, store, org.Organization, incremented, units)
+
+					logger.WithField("window", l.window.Name).Info("Quota exceeded")
+					w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(time.Until(l.window.End).Seconds())), 10))
+					errors.Handler(w, r, ErrQuotaExceeded)
+					return
+				}
+
+				threshold := int64(math.Ceil(float64(l.limit*settings.WarningThreshold) / 100))
+				if used-units < threshold && used >= threshold {
+					warn(org.Organization, l, used)
+				}
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// windowLimits returns the windows the organization has a quota for
+func windowLimits(org *organization.OrganizationConfig, now time.Time) []windowLimit {
+	limits := make([]windowLimit, 0, 2)
+	if org.ContentPerDay > 0 {
+		limits = append(limits, windowLimit{DayWindow(now), int64(org.ContentPerDay)})
+	}
+	if org.ContentPerMonth > 0 {
+		limits = append(limits, windowLimit{MonthWindow(now), int64(org.ContentPerMonth)})
+	}
+	return limits
+}
+
+// rollback takes the units of a rejected request back from the usage
+func rollback(r *http.Request, store Store, org string, windows []Window, units int64) {
+	for _, window := range windows {
+		if _, err := store.Increment(r.Context(), org, window, -units); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"organization": org,
+				"window":       window.Name,
+			}).Error("Could not take back the units of a rejected request")
+		}
+	}
+}
+
+func warn(org string, l windowLimit, used int64) {
+	log.WithFields(log.Fields{
+		"organization": org,
+		"window":       l.window.Name,
+		"used":         used,
+		"limit":        l.limit,
+	}).Warn("Organization is close to its quota")
+
+	go plugin.EmitEvent(plugin.QuotaWarningEvent, plugin.OnQuotaWarning{
+		Organization: org,
+		Window:       l.window.Name,
+		Period:       l.window.Period,
+		Used:         used,
+		Limit:        l.limit,
+	})
+}
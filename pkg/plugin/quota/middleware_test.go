@@ -0,0 +1,128 @@
+package quota
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/plugin/organization"
+	"github.com/hellofresh/janus/pkg/test"
+)
+
+var testSettings = Settings{Location: time.UTC, WarningThreshold: 80}
+
+func serve(handler http.Handler, org *organization.OrganizationConfig, method, path string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	if org != nil {
+		r = r.WithContext(organization.NewContext(r.Context(), org))
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestQuotaIsEnforcedPerDay(t *testing.T) {
+	store := NewInMemoryStore()
+	handler := NewQuotaMiddleware(Config{}, store, testSettings)(http.HandlerFunc(test.Ping))
+	acme := &organization.OrganizationConfig{Organization: "acme", ContentPerDay: 2}
+	other := &organization.OrganizationConfig{Organization: "other", ContentPerDay: 2}
+
+	assert.Equal(t, http.StatusOK, serve(handler, acme, http.MethodGet, "/").Code)
+	assert.Equal(t, http.StatusOK, serve(handler, acme, http.MethodGet, "/").Code)
+
+	w := serve(handler, acme, http.MethodGet, "/")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.JSONEq(t, `{"error": "quota exceeded"}`, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusOK, serve(handler, other, http.MethodGet, "/").Code)
+
+	// rejected requests are not counted
+	used, err := store.Usage(context.Background(), "acme", DayWindow(time.Now().UTC()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), used)
+}
+
+func TestQuotaIsEnforcedPerMonth(t *testing.T) {
+	store := NewInMemoryStore()
+	handler := NewQuotaMiddleware(Config{}, store, testSettings)(http.HandlerFunc(test.Ping))
+	acme := &organization.OrganizationConfig{Organization: "acme", ContentPerDay: 10, ContentPerMonth: 1}
+
+	assert.Equal(t, http.StatusOK, serve(handler, acme, http.MethodGet, "/").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(handler, acme, http.MethodGet, "/").Code)
+
+	// the daily usage of the request rejected by the monthly quota is taken back
+	used, err := store.Usage(context.Background(), "acme", DayWindow(time.Now().UTC()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), used)
+}
+
+func TestQuotaWeightedRoutes(t *testing.T) {
+	store := NewInMemoryStore()
+	config := Config{
+		Units: 2,
+		Routes: []Route{
+			{Methods: []string{"POST"}, Path: "/reports/*", Units: 10},
+			{Path: "/health", Units: 0},
+		},
+	}
+	handler := NewQuotaMiddleware(config, store, testSettings)(http.HandlerFunc(test.Ping))
+	acme := &organization.OrganizationConfig{Organization: "acme", ContentPerDay: 100}
+
+	serve(handler, acme, http.MethodPost, "/reports/monthly")
+	serve(handler, acme, http.MethodGet, "/reports/monthly")
+	serve(handler, acme, http.MethodGet, "/health")
+
+	used, err := store.Usage(context.Background(), "acme", DayWindow(time.Now().UTC()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), used)
+}
+
+func TestQuotaWithoutOrganization(t *testing.T) {
+	store := NewInMemoryStore()
+	handler := NewQuotaMiddleware(Config{}, store, testSettings)(http.HandlerFunc(test.Ping))
+
+	assert.Equal(t, http.StatusOK, serve(handler, nil, http.MethodGet, "/").Code)
+	assert.Equal(t, http.StatusOK, serve(handler, &organization.OrganizationConfig{Organization: "unlimited"}, http.MethodGet, "/").Code)
+	assert.Empty(t, store.usage)
+}
+
+func TestQuotaWarning(t *testing.T) {
+	warnings := make(chan plugin.OnQuotaWarning, 10)
+	plugin.RegisterEventHook(plugin.QuotaWarningEvent, func(event interface{}) error {
+		if warning := event.(plugin.OnQuotaWarning); warning.Organization == "warned" {
+			warnings <- warning
+		}
+		return nil
+	})
+
+	handler := NewQuotaMiddleware(Config{}, NewInMemoryStore(), testSettings)(http.HandlerFunc(test.Ping))
+	acme := &organization.OrganizationConfig{Organization: "warned", ContentPerDay: 5}
+
+	for i := 0; i < 3; i++ {
+		serve(handler, acme, http.MethodGet, "/")
+	}
+	assert.Len(t, warnings, 0)
+
+	// 4 out of 5 is 80% of the quota
+	serve(handler, acme, http.MethodGet, "/")
+	select {
+	case warning := <-warnings:
+		assert.Equal(t, Day, warning.Window)
+		assert.Equal(t, int64(4), warning.Used)
+		assert.Equal(t, int64(5), warning.Limit)
+	case <-time.After(time.Second):
+		t.Fatal("no warning emitted")
+	}
+
+	serve(handler, acme, http.MethodGet, "/")
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, warnings, 0)
+}
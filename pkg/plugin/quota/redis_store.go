@@ -0,0 +1,48 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// RedisStore counts the usage in Redis
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a redis store
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Increment adds the units to the usage of the organization in the window and returns the new usage
+func (s *RedisStore) Increment(ctx context.Context, organization string, window Window, units int64) (int64, error) {
+	key := s.key(organization, window)
+
+	var incr *redis.IntCmd
+	_, err := s.client.WithContext(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(key, units)
+		pipe.ExpireAt(key, window.End.Add(retention))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// Usage returns the usage of the organization in the window
+func (s *RedisStore) Usage(ctx context.Context, organization string, window Window) (int64, error) {
+	units, err := s.client.WithContext(ctx).Get(s.key(organization, window)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return units, err
+}
+
+func (s *RedisStore) key(organization string, window Window) string {
+	return s.prefix + ":" + window.Key(organization)
+}
@@ -0,0 +1,200 @@
+package quota
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/plugin/organization"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+const (
+	cassandraPolicy = "cassandra"
+	redisPolicy     = "redis"
+	localPolicy     = "local"
+
+	defaultWarningThreshold = 80
+)
+
+var (
+	store    Store
+	settings Settings
+
+	// ErrInvalidPolicy is used when an invalid policy was provided
+	ErrInvalidPolicy = errors.New(http.StatusBadRequest, "quota policy is not supported")
+	// ErrInvalidUnits is used when negative units were provided
+	ErrInvalidUnits = errors.New(http.StatusBadRequest, "units can not be negative")
+	// ErrInvalidRoute is used when a route path is not a valid pattern
+	ErrInvalidRoute = errors.New(http.StatusBadRequest, "route path is not a valid pattern")
+)
+
+// Config represents the quota configuration of an API
+type Config struct {
+	// Units counted for the requests not matching any of the routes, defaults to 1
+	Units  int64   `json:"units"`
+	Routes []Route `json:"routes"`
+}
+
+// Route represents the units counted for the requests matching the methods and path
+type Route struct {
+	Methods []string `json:"methods"`
+	Path    string   `json:"path"`
+	Units   int64    `json:"units"`
+}
+
+// UnitsFor returns the units counted for the request, from the first route matching it
+func (c Config) UnitsFor(r *http.Request) int64 {
+	for _, route := range c.Routes {
+		if route.Matches(r) {
+			return route.Units
+		}
+	}
+
+	if c.Units == 0 {
+		return 1
+	}
+	return c.Units
+}
+
+// Matches checks if the request method is one of the route methods, and the request path matches the route path
+func (r Route) Matches(req *http.Request) bool {
+	if len(r.Methods) > 0 {
+		found := false
+		for _, method := range r.Methods {
+			if strings.EqualFold(method, req.Method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if r.Path == "" {
+		return true
+	}
+	matched, _ := path.Match(r.Path, req.URL.Path)
+	return matched
+}
+
+func init() {
+	plugin.RegisterEventHook(plugin.StartupEvent, onStartup)
+	plugin.RegisterPlugin("quota", plugin.Plugin{
+		Action:   setupQuota,
+		Validate: validateConfig,
+	})
+}
+
+func onStartup(event interface{}) error {
+	e, ok := event.(plugin.OnStartup)
+	if !ok {
+		return errors.New(http.StatusInternalServerError, "could not convert event to startup type")
+	}
+
+	cfg := config.Quota{}
+	if e.Config != nil {
+		cfg = e.Config.Quota
+	}
+
+	var err error
+	settings, err = newSettings(cfg)
+	if err != nil {
+		return err
+	}
+
+	policy := cfg.Policy
+	if policy == "" {
+		policy = localPolicy
+		if e.Cassandra != nil {
+			policy = cassandraPolicy
+		}
+	}
+
+	switch policy {
+	case cassandraPolicy:
+		if e.Cassandra == nil {
+			return errors.New(http.StatusInternalServerError, "the cassandra quota policy requires the cassandra database")
+		}
+		log.Debug("Using cassandra store for quota plugin")
+		store = NewCassandraStore(e.Cassandra)
+	case redisPolicy:
+		option, err := redis.ParseURL(cfg.RedisDSN)
+		if err != nil {
+			return err
+		}
+		log.Debug("Using redis store for quota plugin")
+		store = NewRedisStore(redis.NewClient(option), cfg.RedisPrefix)
+	case localPolicy:
+		log.Debug("Using memory store for quota plugin")
+		store = NewInMemoryStore()
+	default:
+		return ErrInvalidPolicy
+	}
+
+	organization.SetUsageReporter(&usageReporter{store: store, location: settings.Location})
+	return nil
+}
+
+func newSettings(cfg config.Quota) (Settings, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	threshold := int64(cfg.WarningThreshold)
+	if threshold <= 0 {
+		threshold = defaultWarningThreshold
+	}
+
+	return Settings{Location: location, WarningThreshold: threshold}, nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if config.Units < 0 {
+		return false, ErrInvalidUnits
+	}
+
+	for _, route := range config.Routes {
+		if route.Units < 0 {
+			return false, ErrInvalidUnits
+		}
+		if _, err := path.Match(route.Path, ""); err != nil {
+			return false, ErrInvalidRoute
+		}
+	}
+
+	return true, nil
+}
+
+func setupQuota(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	if store == nil {
+		return errors.New(http.StatusInternalServerError, "the quota store was not set by onStartup event")
+	}
+
+	if _, err := validateConfig(rawConfig); err != nil {
+		return err
+	}
+
+	var config Config
+	if err := plugin.Decode(rawConfig, &config); err != nil {
+		return err
+	}
+
+	def.AddMiddleware(NewQuotaMiddleware(config, store, settings))
+	return nil
+}
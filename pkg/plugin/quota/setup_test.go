@@ -0,0 +1,60 @@
+package quota
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hellofresh/janus/pkg/config"
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+func TestQuotaConfig(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"units": 2,
+		"routes": []interface{}{
+			map[string]interface{}{"methods": []string{"GET"}, "path": "/reports/*", "units": 10},
+		},
+	}
+
+	valid, err := validateConfig(rawConfig)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	var config Config
+	assert.NoError(t, plugin.Decode(rawConfig, &config))
+	assert.Equal(t, int64(10), config.UnitsFor(httptest.NewRequest("get", "/reports/daily", nil)))
+	assert.Equal(t, int64(2), config.UnitsFor(httptest.NewRequest("GET", "/reports/daily/1", nil)))
+	assert.Equal(t, int64(2), config.UnitsFor(httptest.NewRequest("POST", "/reports/daily", nil)))
+	assert.Equal(t, int64(1), Config{}.UnitsFor(httptest.NewRequest("GET", "/", nil)))
+}
+
+func TestInvalidQuotaConfig(t *testing.T) {
+	valid, err := validateConfig(map[string]interface{}{"units": -1})
+	assert.False(t, valid)
+	assert.Equal(t, ErrInvalidUnits, err)
+
+	valid, err = validateConfig(map[string]interface{}{"routes": []interface{}{map[string]interface{}{"path": "/[", "units": 1}}})
+	assert.False(t, valid)
+	assert.Equal(t, ErrInvalidRoute, err)
+}
+
+func TestQuotaPluginStartup(t *testing.T) {
+	err := onStartup(plugin.OnStartup{Config: &config.Specification{Quota: config.Quota{Policy: "wrong"}}})
+	assert.Equal(t, ErrInvalidPolicy, err)
+
+	err = onStartup(plugin.OnStartup{Config: &config.Specification{Quota: config.Quota{Policy: "cassandra"}}})
+	assert.Error(t, err)
+
+	err = onStartup(plugin.OnStartup{Config: &config.Specification{Quota: config.Quota{Timezone: "Europe/Berlin", WarningThreshold: 90}}})
+	assert.NoError(t, err)
+	assert.IsType(t, &InMemoryStore{}, store)
+	assert.Equal(t, "Europe/Berlin", settings.Location.String())
+	assert.Equal(t, int64(90), settings.WarningThreshold)
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	assert.NoError(t, setupQuota(def, map[string]interface{}{}))
+	assert.Len(t, def.Middleware(), 1)
+}
@@ -0,0 +1,78 @@
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// retention is how long the usage is kept after the end of its window, to be reported by the admin API
+const retention = 24 * time.Hour
+
+// Store counts the units used by the organizations
+type Store interface {
+	// Increment adds the units to the usage of the organization in the window and returns the new usage
+	Increment(ctx context.Context, organization string, window Window, units int64) (int64, error)
+	// Usage returns the usage of the organization in the window
+	Usage(ctx context.Context, organization string, window Window) (int64, error)
+}
+
+type usage struct {
+	units   int64
+	expires time.Time
+}
+
+// InMemoryStore counts the usage in memory
+type InMemoryStore struct {
+	sync.Mutex
+	usage   map[string]*usage
+	cleaned time.Time
+}
+
+// NewInMemoryStore creates a in memory store
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{usage: make(map[string]*usage)}
+}
+
+// Increment adds the units to the usage of the organization in the window and returns the new usage
+func (s *InMemoryStore) Increment(_ context.Context, organization string, window Window, units int64) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.cleanup(time.Now())
+
+	key := window.Key(organization)
+	u, ok := s.usage[key]
+	if !ok {
+		u = &usage{expires: window.End.Add(retention)}
+		s.usage[key] = u
+	}
+	u.units += units
+
+	return u.units, nil
+}
+
+// Usage returns the usage of the organization in the window
+func (s *InMemoryStore) Usage(_ context.Context, organization string, window Window) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if u, ok := s.usage[window.Key(organization)]; ok {
+		return u.units, nil
+	}
+	return 0, nil
+}
+
+// cleanup drops the usage of the windows past their retention, once per hour
+func (s *InMemoryStore) cleanup(now time.Time) {
+	if now.Sub(s.cleaned) < time.Hour {
+		return
+	}
+	s.cleaned = now
+
+	for key, u := range s.usage {
+		if now.After(u.expires) {
+			delete(s.usage, key)
+		}
+	}
+}
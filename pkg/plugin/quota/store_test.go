@@ -0,0 +1,96 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/plugin/organization"
+)
+
+func TestWindows(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// 23:30 UTC on the last day of the month is already the next month in Berlin
+	now := time.Date(2020, time.January, 31, 23, 30, 0, 0, time.UTC)
+
+	day := DayWindow(now)
+	assert.Equal(t, "2020-01-31", day.Period)
+	assert.Equal(t, time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC), day.End)
+
+	month := MonthWindow(now.In(berlin))
+	assert.Equal(t, "2020-02", month.Period)
+	assert.Equal(t, time.Date(2020, time.March, 1, 0, 0, 0, 0, berlin), month.End)
+	assert.Equal(t, "acme:month:2020-02", month.Key("acme"))
+}
+
+func TestStores(t *testing.T) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	defer s.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+
+	stores := map[string]Store{
+		"memory": NewInMemoryStore(),
+		"redis":  NewRedisStore(client, "quota"),
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	day, month := DayWindow(now), MonthWindow(now)
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			used, err := store.Increment(ctx, "acme", day, 3)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), used)
+
+			used, err = store.Increment(ctx, "acme", day, 2)
+			require.NoError(t, err)
+			assert.Equal(t, int64(5), used)
+
+			used, err = store.Increment(ctx, "acme", day, -2)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), used)
+
+			used, err = store.Usage(ctx, "acme", day)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), used)
+
+			used, err = store.Usage(ctx, "acme", month)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), used)
+		})
+	}
+
+	assert.True(t, s.Exists("quota:acme:day:"+day.Period))
+	assert.NotZero(t, s.TTL("quota:acme:day:"+day.Period))
+}
+
+func TestUsageReporter(t *testing.T) {
+	store := NewInMemoryStore()
+	reporter := &usageReporter{store: store, location: time.UTC}
+	now := time.Now().UTC()
+
+	_, err := store.Increment(context.Background(), "acme", DayWindow(now), 4)
+	require.NoError(t, err)
+	_, err = store.Increment(context.Background(), "acme", MonthWindow(now), 40)
+	require.NoError(t, err)
+
+	data, err := reporter.Usage(context.Background(), &organization.OrganizationConfig{Organization: "acme", ContentPerDay: 10})
+	require.NoError(t, err)
+
+	usage := data.(*Usage)
+	assert.Equal(t, "acme", usage.Organization)
+	assert.Equal(t, WindowUsage{Period: DayWindow(now).Period, Used: 4, Limit: 10, Reset: DayWindow(now).End}, usage.Day)
+	assert.Equal(t, int64(40), usage.Month.Used)
+	assert.Equal(t, int64(0), usage.Month.Limit)
+}
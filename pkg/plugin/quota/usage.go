@@ -0,0 +1,50 @@
+package quota
+
+import (
+	"context"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/plugin/organization"
+)
+
+// Usage is the usage of the quotas of an organization
+type Usage struct {
+	Organization string      `json:"organization"`
+	Day          WindowUsage `json:"day"`
+	Month        WindowUsage `json:"month"`
+}
+
+// WindowUsage is the usage of a quota in the current window, a limit of 0 means unlimited
+type WindowUsage struct {
+	Period string    `json:"period"`
+	Used   int64     `json:"used"`
+	Limit  int64     `json:"limit"`
+	Reset  time.Time `json:"reset"`
+}
+
+// usageReporter reports the usage counted by the store to the organization admin API
+type usageReporter struct {
+	store    Store
+	location *time.Location
+}
+
+func (u *usageReporter) Usage(ctx context.Context, config *organization.OrganizationConfig) (interface{}, error) {
+	now := time.Now().In(u.location)
+
+	day, err := u.windowUsage(ctx, config.Organization, DayWindow(now), config.ContentPerDay)
+	if err != nil {
+		return nil, err
+	}
+
+	month, err := u.windowUsage(ctx, config.Organization, MonthWindow(now), config.ContentPerMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Usage{Organization: config.Organization, Day: day, Month: month}, nil
+}
+
+func (u *usageReporter) windowUsage(ctx context.Context, org string, window Window, limit int) (WindowUsage, error) {
+	used, err := u.store.Usage(ctx, org, window)
+	return WindowUsage{Period: window.Period, Used: used, Limit: int64(limit), Reset: window.End}, err
+}
@@ -0,0 +1,37 @@
+package quota
+
+import (
+	"time"
+)
+
+const (
+	// Day is the calendar day window
+	Day = "day"
+	// Month is the calendar month window
+	Month = "month"
+)
+
+// Window is a calendar day or month the usage is counted in
+type Window struct {
+	Name string
+	// Period identifies the day or month, i.e. 2006-01-02 or 2006-01
+	Period string
+	End    time.Time
+}
+
+// Key returns the key of the window usage of the organization
+func (w Window) Key(organization string) string {
+	return organization + ":" + w.Name + ":" + w.Period
+}
+
+// DayWindow returns the calendar day of t, in the location of t
+func DayWindow(t time.Time) Window {
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return Window{Name: Day, Period: start.Format("2006-01-02"), End: start.AddDate(0, 0, 1)}
+}
+
+// MonthWindow returns the calendar month of t, in the location of t
+func MonthWindow(t time.Time) Window {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return Window{Name: Month, Period: start.Format("2006-01"), End: start.AddDate(0, 1, 0)}
+}
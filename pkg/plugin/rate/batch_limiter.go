@@ -0,0 +1,205 @@
+package rate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ulule/limiter/v3"
+)
+
+// lease is the quota of a key pre-fetched by the node
+type lease struct {
+	available int64
+	expires   time.Time
+	// result is the state of the limit of the key across all the nodes at the last sync
+	result Result
+	// refill is the sync in flight, the requests finding no quota left wait for it instead of syncing again
+	refill *refill
+}
+
+// refill is a sync of a lease with the store, err is set once done is closed
+type refill struct {
+	done chan struct{}
+	err  error
+}
+
+// batchLimiter counts requests against quota pre-fetched in batches from the store, so that
+// only one request out of a batch reaches the store. The quota is refilled asynchronously once half
+// of the batch was used, which lets every node go over the limit by at most one and a half batch.
+// With the closed fail policy, the quota left is dropped when the refill fails, so that the requests
+// are rejected until the store is back instead of being let through by a lease that can't be renewed.
+type batchLimiter struct {
+	sync.Mutex
+	store    quotaStore
+	rate     limiter.Rate
+	size     int64
+	failOpen bool
+	leases   map[string]*lease
+	now      func() time.Time
+	cleaned  time.Time
+}
+
+// newBatchLimiter creates a Limiter taking units from the store in batches of the given size
+func newBatchLimiter(store quotaStore, rate limiter.Rate, size int64, failPolicy string) Limiter {
+	return &batchLimiter{
+		store:    store,
+		rate:     rate,
+		size:     size,
+		failOpen: failPolicy != FailClosed,
+		leases:   make(map[string]*lease),
+		now:      time.Now,
+	}
+}
+
+func (l *batchLimiter) Rate() limiter.Rate {
+	return l.rate
+}
+
+func (l *batchLimiter) Take(ctx context.Context, key string) (Result, error) {
+	l.Lock()
+	ls := l.lease(key)
+	if ls.available == 0 {
+		if ls.result.Reached && l.now().Before(ls.result.RetryAt) {
+			result := ls.result
+			l.Unlock()
+			return result, nil
+		}
+
+		if err := l.wait(ctx, key); err != nil {
+			l.Unlock()
+			return Result{}, err
+		}
+
+		ls = l.lease(key)
+		if ls.available == 0 {
+			result := ls.result
+			result.Reached = true
+			l.Unlock()
+			return result, nil
+		}
+	}
+	defer l.Unlock()
+
+	ls.available--
+	if ls.refill == nil && !ls.result.Reached && ls.available <= l.size/2 {
+		rf := ls.startRefill()
+		go func() {
+			if err := l.sync(context.Background(), key, rf); err != nil {
+				log.WithError(err).WithField("key", key).Warn("Could not pre-fetch rate limit quota")
+			}
+		}()
+	}
+
+	result := ls.result
+	result.Remaining += ls.available
+	result.Reached = false
+	return result, nil
+}
+
+func (l *batchLimiter) Peek(_ context.Context, key string) (Result, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	ls, ok := l.leases[key]
+	if !ok {
+		now := l.now()
+		return Result{Limit: l.rate.Limit, Remaining: l.rate.Limit, Reset: now, RetryAt: now}, nil
+	}
+
+	result := ls.result
+	result.Remaining += ls.available
+	result.Reached = result.Remaining == 0
+	return result, nil
+}
+
+// wait refills the lease of the key, or waits for the refill in flight. It is called with the lock held,
+// and returns with the lock held.
+func (l *batchLimiter) wait(ctx context.Context, key string) error {
+	rf := l.lease(key).refill
+	if rf == nil {
+		rf = l.lease(key).startRefill()
+		l.Unlock()
+		err := l.sync(ctx, key, rf)
+		l.Lock()
+		return err
+	}
+
+	l.Unlock()
+	defer l.Lock()
+
+	select {
+	case <-rf.done:
+		return rf.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startRefill marks the lease as being synced
+func (ls *lease) startRefill() *refill {
+	ls.refill = &refill{done: make(chan struct{})}
+	return ls.refill
+}
+
+// sync takes a batch from the store and adds it to the lease of the key
+func (l *batchLimiter) sync(ctx context.Context, key string, rf *refill) error {
+	granted, result, err := l.store.Take(ctx, key, l.size)
+
+	l.Lock()
+	defer l.Unlock()
+
+	ls := l.lease(key)
+	ls.refill = nil
+	rf.err = err
+	close(rf.done)
+	if err != nil {
+		if !l.failOpen {
+			ls.available = 0
+		}
+		return err
+	}
+
+	ls.available += granted
+	if granted > 0 {
+		// units granted in the previous window must not be used in the next one
+		ls.expires = l.now().Add(l.rate.Period)
+	}
+	result.Reached = result.Remaining == 0
+	ls.result = result
+
+	return nil
+}
+
+// lease returns the lease of the key, dropping the quota left once it expired
+func (l *batchLimiter) lease(key string) *lease {
+	now := l.now()
+	l.cleanup(now)
+
+	ls, ok := l.leases[key]
+	if !ok {
+		ls = &lease{}
+		l.leases[key] = ls
+	}
+
+	if ls.available > 0 && now.After(ls.expires) {
+		ls.available = 0
+	}
+
+	return ls
+}
+
+// cleanup drops the expired leases, once per period
+func (l *batchLimiter) cleanup(now time.Time) {
+	if now.Sub(l.cleaned) < l.rate.Period {
+		return
+	}
+	l.cleaned = now
+
+	for key, ls := range l.leases {
+		if ls.refill == nil && now.After(ls.expires) && now.After(ls.result.Reset) {
+			delete(l.leases, key)
+		}
+	}
+}
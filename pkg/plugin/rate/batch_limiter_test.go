@@ -0,0 +1,190 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulule/limiter/v3"
+)
+
+// countingStore counts the calls to the store
+type countingStore struct {
+	quotaStore
+	calls chan int64
+}
+
+func (s *countingStore) Take(ctx context.Context, key string, n int64) (int64, Result, error) {
+	granted, result, err := s.quotaStore.Take(ctx, key, n)
+	s.calls <- n
+	return granted, result, err
+}
+
+func TestBatchLimiter(t *testing.T) {
+	rate := limiter.Rate{Limit: 25, Period: time.Minute}
+	_, client := newMiniredis(t)
+
+	c := newClock()
+	remote := newSlidingWindowRedisStore(client, "test", rate)
+	remote.now = c.Now
+
+	nodes := make([]*batchLimiter, 2)
+	calls := make(chan int64, 100)
+	for i := range nodes {
+		nodes[i] = newBatchLimiter(&countingStore{quotaStore: remote, calls: calls}, rate, 10, FailOpen).(*batchLimiter)
+		nodes[i].now = c.Now
+	}
+
+	ctx := context.Background()
+	take := func(node *batchLimiter) Result {
+		result, err := node.Take(ctx, "key")
+		require.NoError(t, err)
+		return result
+	}
+
+	// the first request fetches a batch synchronously
+	result := take(nodes[0])
+	assert.False(t, result.Reached)
+	assert.Equal(t, int64(24), result.Remaining)
+	assert.Equal(t, int64(10), <-calls)
+
+	// the next requests are served from the batch until half of it is used
+	for i := 0; i < 4; i++ {
+		assert.False(t, take(nodes[0]).Reached)
+	}
+	assert.Len(t, calls, 0)
+
+	assert.False(t, take(nodes[0]).Reached)
+	assert.Equal(t, int64(10), <-calls)
+	waitSynced(t, nodes[0], "key")
+
+	// the other node gets what is left of the limit
+	assert.False(t, take(nodes[1]).Reached)
+	assert.Equal(t, int64(10), <-calls)
+	waitSynced(t, nodes[1], "key")
+
+	allowed := 7
+	for i := 0; i < 30; i++ {
+		if !take(nodes[i%2]).Reached {
+			allowed++
+		}
+	}
+	assert.Equal(t, 25, allowed)
+
+	// once the limit is reached, nodes reject requests locally until the window moves
+	for len(calls) > 0 {
+		<-calls
+	}
+	assert.True(t, take(nodes[0]).Reached)
+	assert.True(t, take(nodes[1]).Reached)
+	assert.Len(t, calls, 0)
+
+	c.Add(time.Minute)
+	assert.False(t, take(nodes[0]).Reached)
+}
+
+// gatedStore holds the calls to the store until the gate is opened, and fails them when unavailable
+type gatedStore struct {
+	quotaStore
+	gate        chan struct{}
+	calls       chan int64
+	unavailable int32
+}
+
+func (s *gatedStore) Take(ctx context.Context, key string, n int64) (int64, Result, error) {
+	s.calls <- n
+	<-s.gate
+	if atomic.LoadInt32(&s.unavailable) == 1 {
+		return 0, Result{}, errors.New("store unavailable")
+	}
+	return s.quotaStore.Take(ctx, key, n)
+}
+
+func TestBatchLimiterSingleRefill(t *testing.T) {
+	rate := limiter.Rate{Limit: 100, Period: time.Minute}
+	store := &gatedStore{quotaStore: newTokenBucketMemoryStore(rate), gate: make(chan struct{}), calls: make(chan int64, 100)}
+	lmt := newBatchLimiter(store, rate, 10, FailOpen).(*batchLimiter)
+
+	// the requests finding no quota left wait for the same refill
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := lmt.Take(context.Background(), "key")
+			assert.NoError(t, err)
+			assert.False(t, result.Reached)
+		}()
+	}
+
+	assert.Equal(t, int64(10), <-store.calls)
+	time.Sleep(10 * time.Millisecond)
+	close(store.gate)
+	wg.Wait()
+	waitSynced(t, lmt, "key")
+
+	// the first refill and the pre-fetch once half of the batch was used
+	assert.Len(t, store.calls, 1)
+}
+
+func TestBatchLimiterFailPolicy(t *testing.T) {
+	rate := limiter.Rate{Limit: 100, Period: time.Minute}
+	for _, policy := range []string{FailOpen, FailClosed} {
+		t.Run(policy, func(t *testing.T) {
+			store := &gatedStore{quotaStore: newTokenBucketMemoryStore(rate), gate: make(chan struct{}), calls: make(chan int64, 100)}
+			close(store.gate)
+			lmt := newBatchLimiter(store, rate, 10, policy).(*batchLimiter)
+			ctx := context.Background()
+
+			for i := 0; i < 4; i++ {
+				_, err := lmt.Take(ctx, "key")
+				require.NoError(t, err)
+			}
+			assert.Len(t, store.calls, 1)
+
+			// the pre-fetch fails
+			atomic.StoreInt32(&store.unavailable, 1)
+			_, err := lmt.Take(ctx, "key")
+			require.NoError(t, err)
+			waitSynced(t, lmt, "key")
+
+			// the quota left is only used with the open policy
+			_, err = lmt.Take(ctx, "key")
+			if policy == FailOpen {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestBatchLimiterPeek(t *testing.T) {
+	rate := limiter.Rate{Limit: 25, Period: time.Minute}
+	lmt := newBatchLimiter(newTokenBucketMemoryStore(rate), rate, 10, FailOpen)
+	ctx := context.Background()
+
+	result, err := lmt.Peek(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), result.Remaining)
+
+	_, err = lmt.Take(ctx, "key")
+	require.NoError(t, err)
+
+	result, err = lmt.Peek(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, int64(24), result.Remaining)
+}
+
+func waitSynced(t *testing.T, l *batchLimiter, key string) {
+	assert.Eventually(t, func() bool {
+		l.Lock()
+		defer l.Unlock()
+		return l.leases[key].refill == nil
+	}, time.Second, time.Millisecond)
+}
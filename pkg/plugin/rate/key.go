@@ -0,0 +1,113 @@
+package rate
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ulule/limiter/v3"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	"github.com/hellofresh/janus/pkg/router"
+)
+
+const (
+	keySourceConsumer  = "consumer"
+	keySourceIP        = "ip"
+	keySourceHeader    = "header"
+	keySourceClaim     = "claim"
+	keySourcePathParam = "path"
+)
+
+// ErrInvalidKey is used when an invalid rate limit key was provided
+var ErrInvalidKey = errors.New(http.StatusBadRequest, "rate limit key is not supported")
+
+// KeyFunc returns the key the request is limited on
+type KeyFunc func(r *http.Request) string
+
+type keyPart func(r *http.Request) (string, bool)
+
+// NewKeyFunc builds a KeyFunc combining the values of the given sources:
+// `consumer`, `ip`, `header:<name>`, `claim:<name>` and `path:<name>`.
+// Requests missing any of the values are limited on the client IP, as well as every request
+// when no sources are given and the request was not authenticated by one of the auth plugins.
+func NewKeyFunc(sources []string, trustForwardHeaders bool) (KeyFunc, error) {
+	ip := func(r *http.Request) (string, bool) {
+		return keySourceIP + ":" + limiter.GetIP(r, limiter.Options{TrustForwardHeader: trustForwardHeaders}).String(), true
+	}
+
+	if len(sources) == 0 {
+		sources = []string{keySourceConsumer}
+	}
+
+	parts := make([]keyPart, 0, len(sources))
+	for _, source := range sources {
+		part, err := newKeyPart(source, ip)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	return func(r *http.Request) string {
+		values := make([]string, 0, len(parts))
+		for _, part := range parts {
+			value, ok := part(r)
+			if !ok {
+				key, _ := ip(r)
+				return key
+			}
+			values = append(values, value)
+		}
+
+		return strings.Join(values, "|")
+	}, nil
+}
+
+func newKeyPart(source string, ip keyPart) (keyPart, error) {
+	kind, name := source, ""
+	if i := strings.Index(source, ":"); i >= 0 {
+		kind, name = source[:i], source[i+1:]
+	}
+
+	switch kind {
+	case keySourceConsumer:
+		return func(r *http.Request) (string, bool) {
+			c, ok := consumer.FromContext(r.Context())
+			if !ok {
+				return "", false
+			}
+			return keySourceConsumer + ":" + c.Username, true
+		}, nil
+	case keySourceIP:
+		return ip, nil
+	}
+
+	if name == "" {
+		return nil, ErrInvalidKey
+	}
+
+	switch kind {
+	case keySourceHeader:
+		return func(r *http.Request) (string, bool) {
+			value := r.Header.Get(name)
+			return source + "=" + value, value != ""
+		}, nil
+	case keySourceClaim:
+		return func(r *http.Request) (string, bool) {
+			claims, ok := consumer.ClaimsFromContext(r.Context())
+			if !ok || claims[name] == nil {
+				return "", false
+			}
+			return fmt.Sprintf("%s=%v", source, claims[name]), true
+		}, nil
+	case keySourcePathParam:
+		return func(r *http.Request) (string, bool) {
+			value := router.URLParam(r, name)
+			return source + "=" + value, value != ""
+		}, nil
+	default:
+		return nil, ErrInvalidKey
+	}
+}
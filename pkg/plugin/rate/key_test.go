@@ -0,0 +1,67 @@
+package rate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+)
+
+func TestKeyFunc(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/orders/42", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Tenant", "acme")
+	r = consumer.Identify(r, consumer.NewCredential(consumer.JWTCredential, "john"))
+	r = r.WithContext(consumer.NewClaimsContext(r.Context(), map[string]interface{}{"org": "hellofresh"}))
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "42")
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	tests := []struct {
+		sources  []string
+		expected string
+	}{
+		{nil, "consumer:jwt:john"},
+		{[]string{"ip"}, "ip:10.0.0.1"},
+		{[]string{"header:X-Tenant"}, "header:X-Tenant=acme"},
+		{[]string{"claim:org"}, "claim:org=hellofresh"},
+		{[]string{"path:id"}, "path:id=42"},
+		{[]string{"consumer", "path:id"}, "consumer:jwt:john|path:id=42"},
+		{[]string{"header:X-Missing"}, "ip:10.0.0.1"},
+		{[]string{"consumer", "claim:missing"}, "ip:10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		key, err := NewKeyFunc(tt.sources, false)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, key(r), "%v", tt.sources)
+	}
+}
+
+func TestKeyFuncFallsBackToIP(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "192.168.0.1")
+
+	key, err := NewKeyFunc(nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, "ip:10.0.0.1", key(r))
+
+	key, err = NewKeyFunc(nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, "ip:192.168.0.1", key(r))
+}
+
+func TestInvalidKeyFunc(t *testing.T) {
+	for _, sources := range [][]string{{"cookie:session"}, {"header"}, {"header:"}, {"consumer", "wrong"}} {
+		_, err := NewKeyFunc(sources, false)
+		assert.Equal(t, ErrInvalidKey, err, "%v", sources)
+	}
+}
@@ -0,0 +1,155 @@
+package rate
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/ulule/limiter/v3"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+const (
+	// FixedWindow counts requests in fixed windows of the limit period
+	FixedWindow = "fixed_window"
+	// SlidingWindow counts requests in the last limit period
+	SlidingWindow = "sliding_window"
+	// TokenBucket spaces requests evenly over the limit period, allowing bursts of the whole limit (GCRA)
+	TokenBucket = "token_bucket"
+
+	// FailOpen lets requests through when the limiter store is unavailable
+	FailOpen = "open"
+	// FailClosed rejects requests when the limiter store is unavailable
+	FailClosed = "closed"
+)
+
+// ErrLimiterUnavailable is used when the limiter store can't be reached and the fail policy is closed
+var ErrLimiterUnavailable = errors.New(http.StatusServiceUnavailable, "rate limiter is unavailable")
+
+// Result is the state of a limit for a key
+type Result struct {
+	Limit     int64
+	Remaining int64
+	// Reset is when the whole limit is available again
+	Reset time.Time
+	// RetryAt is when the next request is allowed once the limit was reached
+	RetryAt time.Time
+	Reached bool
+}
+
+// Limiter counts the requests of a key against a rate
+type Limiter interface {
+	// Rate returns the rate the requests are limited to
+	Rate() limiter.Rate
+	// Take counts a request of the key
+	Take(ctx context.Context, key string) (Result, error)
+	// Peek returns the state of the limit of the key without counting a request
+	Peek(ctx context.Context, key string) (Result, error)
+}
+
+// quotaStore hands out units of the limit of a key
+type quotaStore interface {
+	// Take takes up to n units of the limit of the key, as many as available
+	Take(ctx context.Context, key string, n int64) (granted int64, result Result, err error)
+}
+
+// fixedWindowLimiter adapts the ulule limiter
+type fixedWindowLimiter struct {
+	lmt *limiter.Limiter
+}
+
+// NewFixedWindowLimiter creates a Limiter counting requests in fixed windows with the given limiter
+func NewFixedWindowLimiter(lmt *limiter.Limiter) Limiter {
+	return &fixedWindowLimiter{lmt: lmt}
+}
+
+func (l *fixedWindowLimiter) Rate() limiter.Rate {
+	return l.lmt.Rate
+}
+
+func (l *fixedWindowLimiter) Take(ctx context.Context, key string) (Result, error) {
+	c, err := l.lmt.Get(ctx, key)
+	return fromLimiterContext(c), err
+}
+
+func (l *fixedWindowLimiter) Peek(ctx context.Context, key string) (Result, error) {
+	c, err := l.lmt.Peek(ctx, key)
+	return fromLimiterContext(c), err
+}
+
+func fromLimiterContext(c limiter.Context) Result {
+	reset := time.Unix(c.Reset, 0)
+	return Result{Limit: c.Limit, Remaining: c.Remaining, Reset: reset, RetryAt: reset, Reached: c.Reached}
+}
+
+// storeLimiter takes the units of every request from the quota store
+type storeLimiter struct {
+	store quotaStore
+	rate  limiter.Rate
+}
+
+// newStoreLimiter creates a Limiter taking the units of every request from the store
+func newStoreLimiter(store quotaStore, rate limiter.Rate) Limiter {
+	return &storeLimiter{store: store, rate: rate}
+}
+
+func (l *storeLimiter) Rate() limiter.Rate {
+	return l.rate
+}
+
+func (l *storeLimiter) Take(ctx context.Context, key string) (Result, error) {
+	granted, result, err := l.store.Take(ctx, key, 1)
+	result.Reached = granted == 0
+	return result, err
+}
+
+func (l *storeLimiter) Peek(ctx context.Context, key string) (Result, error) {
+	_, result, err := l.store.Take(ctx, key, 0)
+	result.Reached = result.Remaining == 0
+	return result, err
+}
+
+// failPolicyLimiter applies the fail policy when the limiter store can't be reached
+type failPolicyLimiter struct {
+	Limiter
+	open bool
+}
+
+// WithFailPolicy lets requests through when the limiter fails with the open policy,
+// and rejects them with ErrLimiterUnavailable with the closed policy
+func WithFailPolicy(l Limiter, policy string) Limiter {
+	return &failPolicyLimiter{Limiter: l, open: policy != FailClosed}
+}
+
+func (l *failPolicyLimiter) Take(ctx context.Context, key string) (Result, error) {
+	result, err := l.Limiter.Take(ctx, key)
+	if err != nil {
+		return l.fail(key, err)
+	}
+	return result, nil
+}
+
+func (l *failPolicyLimiter) Peek(ctx context.Context, key string) (Result, error) {
+	result, err := l.Limiter.Peek(ctx, key)
+	if err != nil {
+		return l.fail(key, err)
+	}
+	return result, nil
+}
+
+func (l *failPolicyLimiter) fail(key string, err error) (Result, error) {
+	log.WithError(err).WithFields(log.Fields{
+		"key":       key,
+		"fail_open": l.open,
+	}).Warn("Rate limiter is unavailable")
+
+	if !l.open {
+		return Result{}, ErrLimiterUnavailable
+	}
+
+	rate := l.Rate()
+	now := time.Now()
+	return Result{Limit: rate.Limit, Remaining: rate.Limit, Reset: now, RetryAt: now}, nil
+}
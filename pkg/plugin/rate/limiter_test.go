@@ -0,0 +1,178 @@
+package rate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulule/limiter/v3"
+)
+
+// clock is a fake time source for the stores
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newClock() *clock {
+	return &clock{now: time.Unix(1600000000, 0)}
+}
+
+func newMiniredis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	s, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return s, client
+}
+
+func newTestStores(t *testing.T, algorithm string, rate limiter.Rate, c *clock) map[string]quotaStore {
+	_, client := newMiniredis(t)
+
+	if algorithm == SlidingWindow {
+		memory := newSlidingWindowMemoryStore(rate)
+		memory.now = c.Now
+		remote := newSlidingWindowRedisStore(client, "test", rate)
+		remote.now = c.Now
+		return map[string]quotaStore{"memory": memory, "redis": remote}
+	}
+
+	memory := newTokenBucketMemoryStore(rate)
+	memory.now = c.Now
+	remote := newTokenBucketRedisStore(client, "test", rate)
+	remote.now = c.Now
+	return map[string]quotaStore{"memory": memory, "redis": remote}
+}
+
+func TestSlidingWindowStores(t *testing.T) {
+	rate := limiter.Rate{Limit: 10, Period: time.Minute}
+	c := newClock()
+
+	for name, store := range newTestStores(t, SlidingWindow, rate, c) {
+		t.Run(name, func(t *testing.T) {
+			start := c.now
+			ctx := context.Background()
+
+			granted, result, err := store.Take(ctx, "key", 6)
+			require.NoError(t, err)
+			assert.Equal(t, int64(6), granted)
+			assert.Equal(t, int64(4), result.Remaining)
+
+			c.Add(30 * time.Second)
+			granted, result, err = store.Take(ctx, "key", 6)
+			require.NoError(t, err)
+			assert.Equal(t, int64(4), granted)
+			assert.Equal(t, int64(0), result.Remaining)
+			assert.Equal(t, start.Add(time.Minute), result.RetryAt)
+			assert.Equal(t, start.Add(90*time.Second), result.Reset)
+
+			granted, _, err = store.Take(ctx, "key", 1)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), granted)
+
+			// the first grant leaves the window
+			c.Add(31 * time.Second)
+			granted, result, err = store.Take(ctx, "key", 0)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), granted)
+			assert.Equal(t, int64(6), result.Remaining)
+
+			granted, _, err = store.Take(ctx, "other", 10)
+			require.NoError(t, err)
+			assert.Equal(t, int64(10), granted)
+		})
+	}
+}
+
+func TestTokenBucketStores(t *testing.T) {
+	rate := limiter.Rate{Limit: 10, Period: 10 * time.Second}
+	c := newClock()
+
+	for name, store := range newTestStores(t, TokenBucket, rate, c) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// the whole limit is available as a burst
+			granted, result, err := store.Take(ctx, "key", 12)
+			require.NoError(t, err)
+			assert.Equal(t, int64(10), granted)
+			assert.Equal(t, int64(0), result.Remaining)
+			assert.Equal(t, c.now.Add(10*time.Second), result.Reset)
+			assert.Equal(t, c.now.Add(time.Second), result.RetryAt)
+
+			c.Add(500 * time.Millisecond)
+			granted, _, err = store.Take(ctx, "key", 1)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), granted)
+
+			// one unit is emitted every second
+			c.Add(2500 * time.Millisecond)
+			granted, result, err = store.Take(ctx, "key", 5)
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), granted)
+			assert.Equal(t, int64(0), result.Remaining)
+
+			c.Add(time.Minute)
+			_, result, err = store.Take(ctx, "key", 0)
+			require.NoError(t, err)
+			assert.Equal(t, int64(10), result.Remaining)
+		})
+	}
+}
+
+func TestStoreLimiter(t *testing.T) {
+	rate := limiter.Rate{Limit: 2, Period: time.Minute}
+	lmt := newStoreLimiter(newSlidingWindowMemoryStore(rate), rate)
+	ctx := context.Background()
+
+	result, err := lmt.Take(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, result.Reached)
+	assert.Equal(t, int64(1), result.Remaining)
+
+	result, err = lmt.Peek(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, result.Reached)
+	assert.Equal(t, int64(1), result.Remaining)
+
+	result, err = lmt.Take(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, result.Reached)
+
+	result, err = lmt.Take(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, result.Reached)
+}
+
+func TestFailPolicy(t *testing.T) {
+	rate := limiter.Rate{Limit: 10, Period: time.Minute}
+	s, client := newMiniredis(t)
+	s.Close()
+
+	lmt := newStoreLimiter(newTokenBucketRedisStore(client, "test", rate), rate)
+	ctx := context.Background()
+
+	_, err := lmt.Take(ctx, "key")
+	assert.Error(t, err)
+
+	result, err := WithFailPolicy(lmt, FailOpen).Take(ctx, "key")
+	assert.NoError(t, err)
+	assert.False(t, result.Reached)
+
+	_, err = WithFailPolicy(lmt, FailClosed).Take(ctx, "key")
+	assert.Equal(t, ErrLimiterUnavailable, err)
+}
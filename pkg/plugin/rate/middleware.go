@@ -0,0 +1,114 @@
+package rate
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+// ErrLimitExceeded is used when the rate limit was reached
+var ErrLimitExceeded = errors.New(http.StatusTooManyRequests, "Limit exceeded")
+
+// NewErrorHandler creates the handler of the requests that reached the limit responding with err, i.e. ErrLimitExceeded
+func NewErrorHandler(err *errors.Error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		errors.Handler(w, r, err)
+	})
+}
+
+// NewRateLimitMiddleware limits requests of the same key with every given limiter, serving them with
+// limited once any of the limits is reached. A rejected request is not counted by the stacked limits checked before. The headers sent to the client describe the limit closest to be reached.
+func NewRateLimitMiddleware(limiters []Limiter, key KeyFunc, limited http.Handler) func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			now := time.Now()
+
+			// A request rejected by one of the stacked limits must not count against the other ones,
+			// so the reached limits reject it before any is taken
+			if len(limiters) > 1 {
+				for _, lmt := range limiters {
+					result, err := lmt.Peek(r.Context(), limitKey(lmt, k))
+					if err != nil {
+						errors.Handler(w, r, err)
+						return
+					}
+
+					if result.Remaining == 0 && result.RetryAt.After(now) {
+						result.Reached = true
+						setHeaders(w.Header(), result, now)
+						limited.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			var closest Result
+			for i, lmt := range limiters {
+				result, err := lmt.Take(r.Context(), limitKey(lmt, k))
+				if err != nil {
+					errors.Handler(w, r, err)
+					return
+				}
+
+				if i == 0 || result.Reached || result.Remaining < closest.Remaining {
+					closest = result
+				}
+				if result.Reached {
+					break
+				}
+			}
+
+			setHeaders(w.Header(), closest, now)
+
+			if closest.Reached {
+				limited.ServeHTTP(w, r)
+				return
+			}
+
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// setHeaders sets the rate limit headers of the IETF draft, the legacy X-RateLimit-* headers,
+// and Retry-After once the limit was reached
+func setHeaders(h http.Header, result Result, now time.Time) {
+	limit := strconv.FormatInt(result.Limit, 10)
+	remaining := strconv.FormatInt(result.Remaining, 10)
+
+	h.Set("RateLimit-Limit", limit)
+	h.Set("RateLimit-Remaining", remaining)
+	h.Set("RateLimit-Reset", strconv.FormatInt(secondsUntil(result.Reset, now), 10))
+
+	h.Set("X-RateLimit-Limit", limit)
+	h.Set("X-RateLimit-Remaining", remaining)
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+
+	if result.Reached {
+		retryAfter := secondsUntil(result.RetryAt, now)
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		h.Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	}
+}
+
+// secondsUntil returns the number of seconds until t, rounded up
+func secondsUntil(t time.Time, now time.Time) int64 {
+	if !t.After(now) {
+		return 0
+	}
+	return int64(math.Ceil(t.Sub(now).Seconds()))
+}
+
+// limitKey returns the store key of the request key for the given limiter,
+// so that limits stacked on the same API do not share counters
+func limitKey(lmt Limiter, key string) string {
+	rate := lmt.Rate()
+	return fmt.Sprintf("%s:%d-%s", key, rate.Limit, rate.Period)
+}
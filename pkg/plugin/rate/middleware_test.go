@@ -0,0 +1,140 @@
+package rate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ulule/limiter/v3"
+	"github.com/ulule/limiter/v3/drivers/store/memory"
+
+	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	"github.com/hellofresh/janus/pkg/test"
+)
+
+func fixedWindowLimiters(t *testing.T, formatted ...string) []Limiter {
+	store := memory.NewStore()
+
+	limiters := make([]Limiter, 0, len(formatted))
+	for _, f := range formatted {
+		rate, err := limiter.NewRateFromFormatted(f)
+		assert.NoError(t, err)
+		limiters = append(limiters, NewFixedWindowLimiter(limiter.New(store, rate)))
+	}
+
+	return limiters
+}
+
+func TestRateLimitIsKeyedByConsumer(t *testing.T) {
+	key, _ := NewKeyFunc(nil, false)
+	mw := NewRateLimitMiddleware(fixedWindowLimiters(t, "1-M"), key, NewErrorHandler(ErrLimitExceeded))
+	handler := mw(http.HandlerFunc(test.Ping))
+
+	serve := func(username string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = consumer.Identify(r, consumer.NewCredential(consumer.BasicCredential, username))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("john"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("john"))
+	assert.Equal(t, http.StatusOK, serve("jane"))
+}
+
+func TestRateLimitStackedLimits(t *testing.T) {
+	key, _ := NewKeyFunc([]string{"header:X-Tenant"}, false)
+	mw := NewRateLimitMiddleware(fixedWindowLimiters(t, "3-S", "2-H"), key, NewErrorHandler(ErrLimitExceeded))
+	handler := mw(http.HandlerFunc(test.Ping))
+
+	serve := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Tenant", "acme")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, serve().Code)
+
+	w = serve()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+}
+
+func TestRateLimitRejectedRequestsDoNotDrainStackedLimits(t *testing.T) {
+	key, _ := NewKeyFunc([]string{"header:X-Tenant"}, false)
+	limiters := fixedWindowLimiters(t, "5-H", "1-M")
+	mw := NewRateLimitMiddleware(limiters, key, NewErrorHandler(ErrLimitExceeded))
+	handler := mw(http.HandlerFunc(test.Ping))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Tenant", "acme")
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve().Code)
+	for i := 0; i < 3; i++ {
+		w := serve()
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	}
+
+	hourly, err := limiters[0].Peek(r.Context(), limitKey(limiters[0], key(r)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), hourly.Remaining, "the rejected requests are not counted by the hourly limit")
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	h := make(http.Header)
+	now := time.Unix(1600000000, 0)
+
+	setHeaders(h, Result{Limit: 10, Remaining: 4, Reset: now.Add(1500 * time.Millisecond), RetryAt: now}, now)
+	assert.Equal(t, "10", h.Get("RateLimit-Limit"))
+	assert.Equal(t, "4", h.Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", h.Get("RateLimit-Reset"))
+	assert.Equal(t, "1600000001", h.Get("X-RateLimit-Reset"))
+	assert.Empty(t, h.Get("Retry-After"))
+
+	setHeaders(h, Result{Limit: 10, Reset: now.Add(time.Minute), RetryAt: now.Add(6 * time.Second), Reached: true}, now)
+	assert.Equal(t, "0", h.Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", h.Get("RateLimit-Reset"))
+	assert.Equal(t, "6", h.Get("Retry-After"))
+
+	setHeaders(h, Result{Limit: 10, Reset: now, RetryAt: now, Reached: true}, now)
+	assert.Equal(t, "1", h.Get("Retry-After"))
+}
+
+func TestRateLimitExceededResponse(t *testing.T) {
+	key, _ := NewKeyFunc([]string{"ip"}, false)
+	limitErr := errors.New(http.StatusTooManyRequests, "Slow down, see https://example.com/limits")
+	mw := NewRateLimitMiddleware(fixedWindowLimiters(t, "1-M"), key, NewErrorHandler(limitErr))
+	handler := mw(http.HandlerFunc(test.Ping))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error": "Slow down, see https://example.com/limits"}`, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+}
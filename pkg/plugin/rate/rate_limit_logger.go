@@ -2,7 +2,6 @@ package rate
 
 import (
 	"context"
-	"net"
 	"net/http"
 
 	"github.com/felixge/httpsnoop"
@@ -17,39 +16,47 @@ const (
 	limiterMetric  = "state"
 )
 
-// NewRateLimitLogger logs the IP of blocked users with rate limit
-func NewRateLimitLogger(lmt *limiter.Limiter, statsClient client.Client, trustForwardHeaders bool) func(handler http.Handler) http.Handler {
+// NewRateLimitLogger logs the key of blocked users with rate limit
+func NewRateLimitLogger(limiters []Limiter, key KeyFunc, statsClient client.Client, trustForwardHeaders bool) func(handler http.Handler) http.Handler {
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log.Debug("Starting RateLimitLogger.WriterWrapper middleware")
 
 			m := httpsnoop.CaptureMetrics(handler, w, r)
 
+			k := key(r)
 			limiterIP := limiter.GetIP(r, limiter.Options{TrustForwardHeader: trustForwardHeaders})
 			if m.Code == http.StatusTooManyRequests {
 				log.WithFields(log.Fields{
 					"ip_address":  limiterIP.String(),
+					"key":         k,
 					"request_uri": r.RequestURI,
-				}).Warning("Rate Limit exceeded for this IP")
+				}).Warning("Rate Limit exceeded for this key")
 			}
 
-			trackLimitState(lmt, statsClient, limiterIP, r)
+			trackLimitState(limiters, statsClient, k, r)
 		})
 	}
 }
 
-func trackLimitState(lmt *limiter.Limiter, statsClient client.Client, limiterIP net.IP, r *http.Request) {
-	ctx, err := lmt.Peek(context.Background(), limiterIP.String())
-	if err != nil {
-		log.WithError(err).WithFields(log.Fields{
-			"ip_address":  limiterIP.String(),
-			"request_uri": r.RequestURI,
-		}).Error("Failed to get limiter ctx from request")
-		return
-	}
+// trackLimitState reports the usage in percent of the limit closest to be reached
+func trackLimitState(limiters []Limiter, statsClient client.Client, key string, r *http.Request) {
+	var limitState int64
+	for _, lmt := range limiters {
+		result, err := lmt.Peek(context.Background(), limitKey(lmt, key))
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"key":         key,
+				"request_uri": r.RequestURI,
+			}).Error("Failed to get limiter ctx from request")
+			return
+		}
 
-	requestsPerformed := ctx.Limit - ctx.Remaining
-	limitState := requestsPerformed * 100 / ctx.Limit
+		requestsPerformed := result.Limit - result.Remaining
+		if state := requestsPerformed * 100 / result.Limit; state > limitState {
+			limitState = state
+		}
+	}
 
 	operation := bucket.BuildHTTPRequestMetricOperation(r, statsClient.GetHTTPMetricCallback())
 	// replace request method with fixed section name
@@ -18,7 +18,9 @@ func TestSuccessfulRateLimitLog(t *testing.T) {
 	rate, _ := limiter.NewRateFromFormatted("100-M")
 	limiterInstance := limiter.New(limiterStore, rate)
 
-	mw := NewRateLimitLogger(limiterInstance, statsClient, false)
+	key, _ := NewKeyFunc(nil, false)
+
+	mw := NewRateLimitLogger([]Limiter{NewFixedWindowLimiter(limiterInstance)}, key, statsClient, false)
 	w, err := test.Record(
 		"GET",
 		"/",
@@ -1,14 +1,15 @@
 package rate
 
 import (
+	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/go-redis/redis/v7"
 	"github.com/hellofresh/stats-go/client"
 	"github.com/ulule/limiter/v3"
-	"github.com/ulule/limiter/v3/drivers/middleware/stdlib"
 	storeMemory "github.com/ulule/limiter/v3/drivers/store/memory"
 	storeRedis "github.com/ulule/limiter/v3/drivers/store/redis"
 
@@ -21,6 +22,17 @@ var (
 	statsClient client.Client
 	// ErrInvalidPolicy is used when an invalid policy was provided
 	ErrInvalidPolicy = errors.New(http.StatusBadRequest, "policy is not supported")
+	// ErrEmptyLimits is used when no limit was provided
+	ErrEmptyLimits = errors.New(http.StatusBadRequest, "at least one limit is required")
+	// ErrInvalidAlgorithm is used when an invalid algorithm was provided
+	ErrInvalidAlgorithm = errors.New(http.StatusBadRequest, "algorithm is not supported")
+	// ErrInvalidFailPolicy is used when an invalid fail policy was provided
+	ErrInvalidFailPolicy = errors.New(http.StatusBadRequest, "fail policy is not supported")
+	// ErrInvalidBatchSize is used when batching was enabled for an algorithm not supporting it
+	ErrInvalidBatchSize = errors.New(http.StatusBadRequest, "batch size is not supported by the fixed window algorithm")
+
+	redisClients   = make(map[string]*redis.Client)
+	redisClientsMu sync.Mutex
 )
 
 const (
@@ -30,15 +42,63 @@ const (
 
 // Config represents a rate limit config
 type Config struct {
-	Limit               string      `json:"limit"`
-	Policy              string      `json:"policy"`
-	RedisConfig         redisConfig `json:"redis"`
-	TrustForwardHeaders bool        `json:"trust_forward_headers"`
+	Limit        string   `json:"limit"`
+	Limits       []string `json:"limits"`
+	Key          []string `json:"key"`
+	Policy       string   `json:"policy"`
+	ErrorMessage string   `json:"error_message"`
+	// Response is sent instead of the error once the limit is reached, its status code defaults to 429
+	Response            *proxy.Response `json:"response"`
+	Algorithm           string          `json:"algorithm"`
+	BatchSize           int64           `json:"batch_size"`
+	FailPolicy          string          `json:"fail_policy"`
+	RedisConfig         redisConfig     `json:"redis"`
+	TrustForwardHeaders bool            `json:"trust_forward_headers"`
+}
+
+// limitedHandler creates the handler of the configured response, or of the error message when there is none
+func (c Config) limitedHandler() (http.Handler, error) {
+	if c.Response != nil {
+		resp := *c.Response
+		if resp.StatusCode == 0 {
+			resp.StatusCode = http.StatusTooManyRequests
+		}
+		return proxy.NewResponseHandler(&resp)
+	}
+
+	limitErr := ErrLimitExceeded
+	if c.ErrorMessage != "" {
+		limitErr = errors.New(http.StatusTooManyRequests, c.ErrorMessage)
+	}
+	return NewErrorHandler(limitErr), nil
+}
+
+// rates returns the limit and the limits stacked on top of it
+func (c Config) rates() ([]limiter.Rate, error) {
+	formatted := c.Limits
+	if c.Limit != "" {
+		formatted = append([]string{c.Limit}, formatted...)
+	}
+	if len(formatted) == 0 {
+		return nil, ErrEmptyLimits
+	}
+
+	rates := make([]limiter.Rate, 0, len(formatted))
+	for _, f := range formatted {
+		rate, err := limiter.NewRateFromFormatted(f)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
 }
 
 type redisConfig struct {
-	DSN    string `json:"dsn"`
-	Prefix string `json:"prefix"`
+	DSN      string `json:"dsn"`
+	Prefix   string `json:"prefix"`
+	PoolSize int    `json:"pool_size"`
 }
 
 func init() {
@@ -66,6 +126,24 @@ func validateConfig(rawConfig plugin.Config) (bool, error) {
 		return false, err
 	}
 
+	if _, err := config.rates(); err != nil {
+		return false, err
+	}
+
+	if _, err := NewKeyFunc(config.Key, config.TrustForwardHeaders); err != nil {
+		return false, err
+	}
+
+	if err := validateAlgorithm(config); err != nil {
+		return false, err
+	}
+
+	if config.Response != nil {
+		if err := config.Response.Validate(); err != nil {
+			return false, err
+		}
+	}
+
 	return govalidator.ValidateStruct(config)
 }
 
@@ -76,47 +154,146 @@ func setupRateLimit(def *proxy.RouterDefinition, rawConfig plugin.Config) error
 		return err
 	}
 
-	rate, err := limiter.NewRateFromFormatted(config.Limit)
+	rates, err := config.rates()
 	if err != nil {
 		return err
 	}
 
-	limiterStore, err := getLimiterStore(config.Policy, config.RedisConfig)
+	key, err := NewKeyFunc(config.Key, config.TrustForwardHeaders)
 	if err != nil {
 		return err
 	}
 
-	limiterInstance := limiter.New(limiterStore, rate, limiter.WithTrustForwardHeader(config.TrustForwardHeaders))
-	def.AddMiddleware(NewRateLimitLogger(limiterInstance, statsClient, config.TrustForwardHeaders))
-	def.AddMiddleware(stdlib.NewMiddleware(limiterInstance).Handler)
+	limiters, err := newLimiters(config, rates)
+	if err != nil {
+		return err
+	}
+
+	limited, err := config.limitedHandler()
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(NewRateLimitLogger(limiters, key, statsClient, config.TrustForwardHeaders))
+	def.AddMiddleware(NewRateLimitMiddleware(limiters, key, limited))
 
 	return nil
 }
 
-func getLimiterStore(policy string, config redisConfig) (limiter.Store, error) {
-	switch policy {
+func newLimiters(config Config, rates []limiter.Rate) ([]Limiter, error) {
+	if err := validateAlgorithm(config); err != nil {
+		return nil, err
+	}
+
+	var client *redis.Client
+	switch config.Policy {
 	case "redis":
-		option, err := redis.ParseURL(config.DSN)
+		var err error
+		if client, err = getRedisClient(config.RedisConfig); err != nil {
+			return nil, err
+		}
+		if config.RedisConfig.Prefix == "" {
+			config.RedisConfig.Prefix = DefaultPrefix
+		}
+	case "local":
+	default:
+		return nil, ErrInvalidPolicy
+	}
+
+	if config.Algorithm == "" || config.Algorithm == FixedWindow {
+		limiterStore, err := getLimiterStore(client, config.RedisConfig.Prefix)
 		if err != nil {
 			return nil, err
 		}
-		option.PoolSize = 3
-		option.IdleTimeout = 240 * time.Second
-		redisClient := redis.NewClient(option)
 
-		if config.Prefix == "" {
-			config.Prefix = DefaultPrefix
+		limiters := make([]Limiter, 0, len(rates))
+		for _, rate := range rates {
+			lmt := limiter.New(limiterStore, rate, limiter.WithTrustForwardHeader(config.TrustForwardHeaders))
+			limiters = append(limiters, WithFailPolicy(NewFixedWindowLimiter(lmt), config.FailPolicy))
 		}
+		return limiters, nil
+	}
 
-		return storeRedis.NewStoreWithOptions(redisClient, limiter.StoreOptions{
-			Prefix:   config.Prefix,
-			MaxRetry: limiter.DefaultMaxRetry,
-		})
+	limiters := make([]Limiter, 0, len(rates))
+	for _, rate := range rates {
+		store := getQuotaStore(config.Algorithm, client, config.RedisConfig.Prefix, rate)
 
-	case "local":
+		var lmt Limiter
+		if client != nil && config.BatchSize > 1 {
+			lmt = newBatchLimiter(store, rate, config.BatchSize, config.FailPolicy)
+		} else {
+			lmt = newStoreLimiter(store, rate)
+		}
+		limiters = append(limiters, WithFailPolicy(lmt, config.FailPolicy))
+	}
+
+	return limiters, nil
+}
+
+func validateAlgorithm(config Config) error {
+	switch config.Algorithm {
+	case "", FixedWindow:
+		if config.BatchSize > 1 {
+			return ErrInvalidBatchSize
+		}
+	case SlidingWindow, TokenBucket:
+	default:
+		return ErrInvalidAlgorithm
+	}
+
+	switch config.FailPolicy {
+	case "", FailOpen, FailClosed:
+		return nil
+	default:
+		return ErrInvalidFailPolicy
+	}
+}
+
+func getLimiterStore(client *redis.Client, prefix string) (limiter.Store, error) {
+	if client == nil {
 		return storeMemory.NewStore(), nil
+	}
 
+	return storeRedis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix:   prefix,
+		MaxRetry: limiter.DefaultMaxRetry,
+	})
+}
+
+func getQuotaStore(algorithm string, client *redis.Client, prefix string, rate limiter.Rate) quotaStore {
+	switch {
+	case algorithm == SlidingWindow && client != nil:
+		return newSlidingWindowRedisStore(client, prefix, rate)
+	case algorithm == SlidingWindow:
+		return newSlidingWindowMemoryStore(rate)
+	case client != nil:
+		return newTokenBucketRedisStore(client, prefix, rate)
 	default:
-		return nil, ErrInvalidPolicy
+		return newTokenBucketMemoryStore(rate)
 	}
 }
+
+// getRedisClient returns the client of the redis instance, shared by all the APIs limited with the same config
+func getRedisClient(config redisConfig) (*redis.Client, error) {
+	option, err := redis.ParseURL(config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	option.IdleTimeout = 240 * time.Second
+	if config.PoolSize > 0 {
+		option.PoolSize = config.PoolSize
+	}
+
+	redisClientsMu.Lock()
+	defer redisClientsMu.Unlock()
+
+	id := fmt.Sprintf("%s#%d", config.DSN, config.PoolSize)
+	if client, ok := redisClients[id]; ok {
+		return client, nil
+	}
+
+	client := redis.NewClient(option)
+	redisClients[id] = client
+
+	return client, nil
+}
@@ -1,11 +1,15 @@
 package rate
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRateLimitConfig(t *testing.T) {
@@ -95,3 +99,128 @@ func TestRateLimitPluginInvalidTrustForwardHeaders(t *testing.T) {
 	err := plugin.Decode(rawConfig, &config)
 	assert.Error(t, err)
 }
+
+func TestRateLimitPluginStackedLimits(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"limits": []string{"10-S", "1000-H"},
+		"key":    []string{"consumer", "header:X-Tenant"},
+		"policy": "local",
+	}
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	err := setupRateLimit(def, rawConfig)
+
+	assert.NoError(t, err)
+	assert.Len(t, def.Middleware(), 2)
+}
+
+func TestRateLimitPluginResponse(t *testing.T) {
+	rawConfig := map[string]interface{}{
+		"limit":  "1-M",
+		"policy": "local",
+		"response": map[string]interface{}{
+			"headers": map[string]string{"Content-Type": "application/problem+json"},
+			"body":    `{"title": "Too many requests"}`,
+		},
+	}
+
+	config := Config{}
+	require.NoError(t, plugin.Decode(rawConfig, &config))
+	limited, err := config.limitedHandler()
+	require.NoError(t, err)
+
+	key, _ := NewKeyFunc(nil, false)
+	handler := NewRateLimitMiddleware(fixedWindowLimiters(t, "1-M"), key, limited)(http.HandlerFunc(test.Ping))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"title": "Too many requests"}`, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	valid, err := validateConfig(map[string]interface{}{
+		"limit":    "1-M",
+		"policy":   "local",
+		"response": map[string]interface{}{"body": "{{", "template": true},
+	})
+	assert.False(t, valid)
+	assert.Error(t, err)
+}
+
+func TestRateLimitPluginValidation(t *testing.T) {
+	valid, err := validateConfig(map[string]interface{}{"policy": "local"})
+	assert.False(t, valid)
+	assert.Equal(t, ErrEmptyLimits, err)
+
+	valid, err = validateConfig(map[string]interface{}{"limits": []string{"10-S", "wrong"}, "policy": "local"})
+	assert.False(t, valid)
+	assert.Error(t, err)
+
+	valid, err = validateConfig(map[string]interface{}{"limit": "10-S", "key": []string{"cookie:session"}, "policy": "local"})
+	assert.False(t, valid)
+	assert.Equal(t, ErrInvalidKey, err)
+
+	valid, err = validateConfig(map[string]interface{}{"limit": "10-S", "limits": []string{"1000-H"}, "key": []string{"path:id"}, "policy": "local"})
+	assert.True(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestRateLimitPluginAlgorithms(t *testing.T) {
+	s, _ := newMiniredis(t)
+
+	for _, algorithm := range []string{FixedWindow, SlidingWindow, TokenBucket} {
+		for _, policy := range []string{"local", "redis"} {
+			rawConfig := map[string]interface{}{
+				"limits":      []string{"2-M", "1000-H"},
+				"policy":      policy,
+				"algorithm":   algorithm,
+				"fail_policy": FailClosed,
+				"redis": map[string]interface{}{
+					"dsn":       "redis://" + s.Addr(),
+					"pool_size": 5,
+				},
+			}
+			if algorithm != FixedWindow {
+				rawConfig["batch_size"] = 5
+			}
+
+			def := proxy.NewRouterDefinition(proxy.NewDefinition())
+			err := setupRateLimit(def, rawConfig)
+			require.NoError(t, err, "%s %s", algorithm, policy)
+
+			// the second middleware is the limiter itself, after the logger
+			handler := def.Middleware()[1](http.HandlerFunc(test.Ping))
+			codes := make([]int, 0, 3)
+			for i := 0; i < 3; i++ {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				codes = append(codes, w.Code)
+			}
+			assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes, "%s %s", algorithm, policy)
+
+			s.FlushAll()
+		}
+	}
+}
+
+func TestRateLimitPluginAlgorithmValidation(t *testing.T) {
+	tests := []struct {
+		config   map[string]interface{}
+		expected error
+	}{
+		{map[string]interface{}{"algorithm": "leaky_bucket"}, ErrInvalidAlgorithm},
+		{map[string]interface{}{"algorithm": SlidingWindow, "fail_policy": "maybe"}, ErrInvalidFailPolicy},
+		{map[string]interface{}{"batch_size": 10}, ErrInvalidBatchSize},
+	}
+
+	for _, tt := range tests {
+		tt.config["limit"] = "10-S"
+		tt.config["policy"] = "local"
+
+		valid, err := validateConfig(tt.config)
+		assert.False(t, valid)
+		assert.Equal(t, tt.expected, err)
+	}
+}
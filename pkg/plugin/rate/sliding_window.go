@@ -0,0 +1,205 @@
+package rate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/gofrs/uuid"
+	"github.com/ulule/limiter/v3"
+)
+
+// slidingWindowScript keeps a log of the units granted in the last period in a sorted set,
+// scored by the grant time, every member holding the number of units granted at once
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - period)
+
+local entries = redis.call('ZRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+local used = 0
+local oldest = now
+local newest = now - period
+for i = 1, #entries, 2 do
+	used = used + tonumber(string.match(entries[i], ':(%d+)$'))
+	if i == 1 then
+		oldest = tonumber(entries[i + 1])
+	end
+	newest = tonumber(entries[i + 1])
+end
+
+local granted = math.max(0, math.min(n, limit - used))
+if granted > 0 then
+	redis.call('ZADD', KEYS[1], now, ARGV[5] .. ':' .. granted)
+	redis.call('PEXPIRE', KEYS[1], period)
+	if used == 0 then
+		oldest = now
+	end
+	newest = now
+	used = used + granted
+end
+
+return {granted, limit - used, oldest, newest}
+`)
+
+type slidingWindowEntry struct {
+	at time.Time
+	n  int64
+}
+
+// slidingWindowMemoryStore keeps the sliding window logs in memory
+type slidingWindowMemoryStore struct {
+	sync.Mutex
+	rate    limiter.Rate
+	logs    map[string][]slidingWindowEntry
+	now     func() time.Time
+	cleaned time.Time
+}
+
+func newSlidingWindowMemoryStore(rate limiter.Rate) *slidingWindowMemoryStore {
+	return &slidingWindowMemoryStore{rate: rate, logs: make(map[string][]slidingWindowEntry), now: time.Now}
+}
+
+func (s *slidingWindowMemoryStore) Take(_ context.Context, key string, n int64) (int64, Result, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	s.cleanup(now)
+
+	log := s.prune(s.logs[key], now)
+	var used int64
+	for _, e := range log {
+		used += e.n
+	}
+
+	granted := max(0, min(n, s.rate.Limit-used))
+	if granted > 0 {
+		log = append(log, slidingWindowEntry{at: now, n: granted})
+		used += granted
+	}
+	s.logs[key] = log
+
+	result := Result{Limit: s.rate.Limit, Remaining: s.rate.Limit - used, Reset: now, RetryAt: now}
+	if len(log) > 0 {
+		result.RetryAt = log[0].at.Add(s.rate.Period)
+		result.Reset = log[len(log)-1].at.Add(s.rate.Period)
+	}
+
+	return granted, result, nil
+}
+
+func (s *slidingWindowMemoryStore) prune(log []slidingWindowEntry, now time.Time) []slidingWindowEntry {
+	start := now.Add(-s.rate.Period)
+	i := 0
+	for i < len(log) && !log[i].at.After(start) {
+		i++
+	}
+	return log[i:]
+}
+
+// cleanup drops the logs of the keys without requests in the last period, once per period
+func (s *slidingWindowMemoryStore) cleanup(now time.Time) {
+	if now.Sub(s.cleaned) < s.rate.Period {
+		return
+	}
+	s.cleaned = now
+
+	for key, log := range s.logs {
+		if len(s.prune(log, now)) == 0 {
+			delete(s.logs, key)
+		}
+	}
+}
+
+// slidingWindowRedisStore keeps the sliding window logs in Redis, shared by all the nodes
+type slidingWindowRedisStore struct {
+	client *redis.Client
+	prefix string
+	rate   limiter.Rate
+	node   string
+	seq    uint64
+	now    func() time.Time
+}
+
+func newSlidingWindowRedisStore(client *redis.Client, prefix string, rate limiter.Rate) *slidingWindowRedisStore {
+	return &slidingWindowRedisStore{
+		client: client,
+		prefix: prefix,
+		rate:   rate,
+		node:   uuid.Must(uuid.NewV4()).String(),
+		now:    time.Now,
+	}
+}
+
+func (s *slidingWindowRedisStore) Take(ctx context.Context, key string, n int64) (int64, Result, error) {
+	now := s.now()
+	// members of the same grant time have to be unique across the nodes
+	member := fmt.Sprintf("%s:%d", s.node, atomic.AddUint64(&s.seq, 1))
+
+	reply, err := slidingWindowScript.Run(
+		s.client.WithContext(ctx),
+		[]string{s.prefix + ":" + key},
+		toMillis(now), s.rate.Period.Milliseconds(), s.rate.Limit, n, member,
+	).Result()
+	if err != nil {
+		return 0, Result{}, err
+	}
+
+	values, err := int64s(reply, 4)
+	if err != nil {
+		return 0, Result{}, err
+	}
+
+	return values[0], Result{
+		Limit:     s.rate.Limit,
+		Remaining: values[1],
+		RetryAt:   fromMillis(values[2]).Add(s.rate.Period),
+		Reset:     fromMillis(values[3]).Add(s.rate.Period),
+	}, nil
+}
+
+func min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// int64s converts the reply of a script returning a list of numbers
+func int64s(reply interface{}, n int) ([]int64, error) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != n {
+		return nil, fmt.Errorf("unexpected script reply %v", reply)
+	}
+
+	result := make([]int64, n)
+	for i, v := range values {
+		if result[i], ok = v.(int64); !ok {
+			return nil, fmt.Errorf("unexpected script reply %v", reply)
+		}
+	}
+
+	return result, nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
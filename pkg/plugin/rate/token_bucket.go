@@ -0,0 +1,126 @@
+package rate
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/ulule/limiter/v3"
+)
+
+// tokenBucketScript implements GCRA: the key holds the theoretical arrival time (TAT) of the next unit,
+// each unit pushing it by the emission interval, and units are available while the TAT is less than
+// a period ahead of now
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+local interval = period / limit
+
+local tat = math.max(tonumber(redis.call('GET', KEYS[1])) or now, now)
+
+local granted = math.max(0, math.min(n, math.floor((period - (tat - now)) / interval)))
+if granted > 0 then
+	tat = tat + granted * interval
+	redis.call('SET', KEYS[1], string.format('%.3f', tat), 'PX', math.ceil(tat - now))
+end
+
+return {granted, math.ceil(tat)}
+`)
+
+// tokenBucketMemoryStore keeps the theoretical arrival times in memory
+type tokenBucketMemoryStore struct {
+	sync.Mutex
+	rate    limiter.Rate
+	tats    map[string]time.Time
+	now     func() time.Time
+	cleaned time.Time
+}
+
+func newTokenBucketMemoryStore(rate limiter.Rate) *tokenBucketMemoryStore {
+	return &tokenBucketMemoryStore{rate: rate, tats: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *tokenBucketMemoryStore) Take(_ context.Context, key string, n int64) (int64, Result, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	now := s.now()
+	s.cleanup(now)
+
+	interval := s.rate.Period / time.Duration(s.rate.Limit)
+	tat := s.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+
+	granted := max(0, min(n, int64((s.rate.Period-tat.Sub(now))/interval)))
+	if granted > 0 {
+		tat = tat.Add(time.Duration(granted) * interval)
+		s.tats[key] = tat
+	}
+
+	return granted, tokenBucketResult(s.rate, now, tat), nil
+}
+
+// cleanup drops the keys with a full bucket, once per period
+func (s *tokenBucketMemoryStore) cleanup(now time.Time) {
+	if now.Sub(s.cleaned) < s.rate.Period {
+		return
+	}
+	s.cleaned = now
+
+	for key, tat := range s.tats {
+		if tat.Before(now) {
+			delete(s.tats, key)
+		}
+	}
+}
+
+// tokenBucketRedisStore keeps the theoretical arrival times in Redis, shared by all the nodes
+type tokenBucketRedisStore struct {
+	client *redis.Client
+	prefix string
+	rate   limiter.Rate
+	now    func() time.Time
+}
+
+func newTokenBucketRedisStore(client *redis.Client, prefix string, rate limiter.Rate) *tokenBucketRedisStore {
+	return &tokenBucketRedisStore{client: client, prefix: prefix, rate: rate, now: time.Now}
+}
+
+func (s *tokenBucketRedisStore) Take(ctx context.Context, key string, n int64) (int64, Result, error) {
+	now := s.now()
+
+	reply, err := tokenBucketScript.Run(
+		s.client.WithContext(ctx),
+		[]string{s.prefix + ":" + key},
+		toMillis(now), s.rate.Period.Milliseconds(), s.rate.Limit, n,
+	).Result()
+	if err != nil {
+		return 0, Result{}, err
+	}
+
+	values, err := int64s(reply, 2)
+	if err != nil {
+		return 0, Result{}, err
+	}
+
+	return values[0], tokenBucketResult(s.rate, now, fromMillis(values[1])), nil
+}
+
+func tokenBucketResult(rate limiter.Rate, now time.Time, tat time.Time) Result {
+	interval := rate.Period / time.Duration(rate.Limit)
+	if tat.Before(now) {
+		tat = now
+	}
+
+	return Result{
+		Limit:     rate.Limit,
+		Remaining: max(0, int64((rate.Period-tat.Sub(now))/interval)),
+		Reset:     tat,
+		RetryAt:   tat.Add(interval - rate.Period),
+	}
+}
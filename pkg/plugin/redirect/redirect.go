@@ -0,0 +1,204 @@
+package redirect
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type rule struct {
+	regex      *regexp.Regexp
+	to         string
+	statusCode int
+}
+
+// redirector redirects the requests matching a rule, the plain HTTP ones and the ones with a path to normalize,
+// with a single redirect to the final URL
+type redirector struct {
+	rules         []rule
+	https         HTTPS
+	trailingSlash string
+	lowercasePath bool
+	statusCode    int
+}
+
+func newRedirector(config Config) (*redirector, error) {
+	r := &redirector{
+		https:         config.HTTPS,
+		trailingSlash: config.TrailingSlash,
+		lowercasePath: config.LowercasePath,
+	}
+
+	var err error
+	if r.statusCode, err = statusCode(config.StatusCode); err != nil {
+		return nil, err
+	}
+	if r.https.StatusCode, err = statusCode(config.HTTPS.StatusCode); err != nil {
+		return nil, fmt.Errorf("https: %w", err)
+	}
+	if r.https.Port < 0 || r.https.Port > 65535 {
+		return nil, fmt.Errorf("https: invalid port %d", r.https.Port)
+	}
+
+	switch config.TrailingSlash {
+	case "", trailingSlashAdd, trailingSlashRemove:
+	default:
+		return nil, fmt.Errorf("trailing_slash must be %q or %q", trailingSlashAdd, trailingSlashRemove)
+	}
+
+	for i, cfg := range config.Rules {
+		regex, err := regexp.Compile(cfg.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		if cfg.To == "" {
+			return nil, fmt.Errorf("rule %d must have a target", i)
+		}
+
+		code, err := statusCode(cfg.StatusCode)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+
+		r.rules = append(r.rules, rule{regex: regex, to: cfg.To, statusCode: code})
+	}
+
+	return r, nil
+}
+
+func statusCode(code int) (int, error) {
+	switch code {
+	case 0:
+		return http.StatusMovedPermanently, nil
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return code, nil
+	default:
+		return 0, fmt.Errorf("invalid redirect status code %d", code)
+	}
+}
+
+// Handler is the middleware function
+func (rd *redirector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target, code, ok := rd.redirect(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		log.WithFields(log.Fields{"path": r.URL.Path, "location": target}).Debug("Redirecting the request")
+		http.Redirect(w, r, target, code)
+	})
+}
+
+// redirect returns the location and the status of the redirect, ok is false when the request is not redirected
+func (rd *redirector) redirect(r *http.Request) (location string, code int, ok bool) {
+	target := url.URL{Path: rd.normalize(r.URL.Path), RawQuery: r.URL.RawQuery}
+	if target.Path != r.URL.Path {
+		code, ok = rd.statusCode, true
+	}
+
+	// The rules match the normalized path
+	if rule, match := rd.match(target.Path); match != nil {
+		// The captured parts of the path must not turn a path into a protocol relative location
+		to := collapseLeadingSlashes(string(rule.regex.ExpandString(nil, rule.to, target.Path, match)))
+
+		u, err := url.Parse(to)
+		if err != nil {
+			log.WithError(err).WithField("location", to).Error("Invalid redirect location")
+			return "", 0, false
+		}
+		if u.RawQuery == "" {
+			u.RawQuery = r.URL.RawQuery
+		} else if r.URL.RawQuery != "" {
+			u.RawQuery += "&" + r.URL.RawQuery
+		}
+
+		// The locations with a scheme are used as they are, the paths replace the matched part of the path
+		if u.IsAbs() {
+			return u.String(), rule.statusCode, true
+		}
+		target.Path = target.Path[:match[0]] + u.Path + target.Path[match[1]:]
+		target.RawQuery = u.RawQuery
+		code, ok = rule.statusCode, true
+	}
+
+	if rd.https.Enabled && !isHTTPS(r) {
+		target.Scheme = "https"
+		target.Host = rd.httpsHost(r.Host)
+		if !ok {
+			code, ok = rd.https.StatusCode, true
+		}
+	}
+
+	// A location starting with `//` or `/\` is protocol relative, it would send the client to another host
+	if target.Host == "" {
+		target.Path = collapseLeadingSlashes(target.Path)
+	}
+
+	// A rule rewriting the path to itself would redirect the client forever
+	if !ok || target.Scheme == "" && target.Path == r.URL.Path && target.RawQuery == r.URL.RawQuery {
+		return "", 0, false
+	}
+	return target.String(), code, true
+}
+
+// match returns the first rule matching the path and the indexes of its submatches
+func (rd *redirector) match(path string) (rule, []int) {
+	for _, rule := range rd.rules {
+		if match := rule.regex.FindStringSubmatchIndex(path); match != nil {
+			return rule, match
+		}
+	}
+	return rule{}, nil
+}
+
+func (rd *redirector) normalize(path string) string {
+	if rd.lowercasePath {
+		path = strings.ToLower(path)
+	}
+
+	switch {
+	case rd.trailingSlash == trailingSlashAdd && !strings.HasSuffix(path, "/"):
+		path += "/"
+	case rd.trailingSlash == trailingSlashRemove && path != "/":
+		path = strings.TrimRight(path, "/")
+		if path == "" {
+			path = "/"
+		}
+	}
+
+	return path
+}
+
+// collapseLeadingSlashes replaces the slashes and backslashes the path starts with by a single slash
+func collapseLeadingSlashes(path string) string {
+	trimmed := strings.TrimLeft(path, `/\`)
+	if len(trimmed) == len(path) {
+		return path
+	}
+	return "/" + trimmed
+}
+
+func (rd *redirector) httpsHost(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+
+	if rd.https.Port == 0 || rd.https.Port == 443 {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(rd.https.Port))
+}
+
+// isHTTPS checks whether the client used HTTPS, to Janus or to the load balancer in front of it
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
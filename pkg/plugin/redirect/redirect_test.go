@@ -0,0 +1,192 @@
+package redirect
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirect(t *testing.T) {
+	tests := []struct {
+		scenario string
+		config   Config
+		url      string
+		https    bool
+		code     int
+		location string
+	}{
+		{
+			scenario: "rule with capture groups",
+			config:   Config{Rules: []Rule{{Regex: `^/v1/users/(\d+)$`, To: "/v2/accounts/$1"}}},
+			url:      "http://example.com/v1/users/42?fields=name",
+			code:     http.StatusMovedPermanently,
+			location: "/v2/accounts/42?fields=name",
+		},
+		{
+			scenario: "rule replacing a part of the path",
+			config:   Config{Rules: []Rule{{Regex: `^/legacy`, To: "/api", StatusCode: http.StatusPermanentRedirect}}},
+			url:      "http://example.com/legacy/recipes",
+			code:     http.StatusPermanentRedirect,
+			location: "/api/recipes",
+		},
+		{
+			scenario: "rule with a URL and a query string",
+			config:   Config{Rules: []Rule{{Regex: `^/docs/(?P<page>.*)$`, To: "https://docs.example.com/${page}?from=api", StatusCode: http.StatusFound}}},
+			url:      "http://example.com/docs/intro?lang=de",
+			code:     http.StatusFound,
+			location: "https://docs.example.com/intro?from=api&lang=de",
+		},
+		{
+			scenario: "first matching rule",
+			config: Config{Rules: []Rule{
+				{Regex: `^/a$`, To: "/first", StatusCode: http.StatusTemporaryRedirect},
+				{Regex: `^/a`, To: "/second"},
+			}},
+			url:      "http://example.com/a",
+			code:     http.StatusTemporaryRedirect,
+			location: "/first",
+		},
+		{
+			scenario: "rule not matching",
+			config:   Config{Rules: []Rule{{Regex: `^/legacy`, To: "/api"}}},
+			url:      "http://example.com/api/recipes",
+			code:     http.StatusOK,
+		},
+		{
+			scenario: "rule to the same path",
+			config:   Config{Rules: []Rule{{Regex: `^/api`, To: "/api"}}},
+			url:      "http://example.com/api/recipes",
+			code:     http.StatusOK,
+		},
+		{
+			scenario: "https upgrade",
+			config:   Config{HTTPS: HTTPS{Enabled: true}},
+			url:      "http://example.com:8080/recipes?page=2",
+			code:     http.StatusMovedPermanently,
+			location: "https://example.com/recipes?page=2",
+		},
+		{
+			scenario: "https upgrade with a port",
+			config:   Config{HTTPS: HTTPS{Enabled: true, Port: 8443, StatusCode: http.StatusPermanentRedirect}},
+			url:      "http://example.com/recipes",
+			code:     http.StatusPermanentRedirect,
+			location: "https://example.com:8443/recipes",
+		},
+		{
+			scenario: "already https",
+			config:   Config{HTTPS: HTTPS{Enabled: true}},
+			url:      "https://example.com/recipes",
+			https:    true,
+			code:     http.StatusOK,
+		},
+		{
+			scenario: "trailing slash added",
+			config:   Config{TrailingSlash: trailingSlashAdd},
+			url:      "http://example.com/recipes?page=2",
+			code:     http.StatusMovedPermanently,
+			location: "/recipes/?page=2",
+		},
+		{
+			scenario: "trailing slash removed",
+			config:   Config{TrailingSlash: trailingSlashRemove, StatusCode: http.StatusFound},
+			url:      "http://example.com/recipes//",
+			code:     http.StatusFound,
+			location: "/recipes",
+		},
+		{
+			scenario: "protocol relative path with a trailing slash added",
+			config:   Config{TrailingSlash: trailingSlashAdd},
+			url:      "http://example.com//evil.com",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com/",
+		},
+		{
+			scenario: "escaped protocol relative path with a trailing slash added",
+			config:   Config{TrailingSlash: trailingSlashAdd},
+			url:      "http://example.com/%2fevil.com",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com/",
+		},
+		{
+			scenario: "protocol relative path with a trailing slash removed",
+			config:   Config{TrailingSlash: trailingSlashRemove},
+			url:      "http://example.com//evil.com/",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com",
+		},
+		{
+			scenario: "backslash path with a trailing slash added",
+			config:   Config{TrailingSlash: trailingSlashAdd},
+			url:      "http://example.com/%5Cevil.com",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com/",
+		},
+		{
+			scenario: "rule to a protocol relative path",
+			config:   Config{Rules: []Rule{{Regex: `^/go/(.*)$`, To: "/$1"}}},
+			url:      "http://example.com/go//evil.com",
+			code:     http.StatusMovedPermanently,
+			location: "/evil.com",
+		},
+		{
+			scenario: "root path kept",
+			config:   Config{TrailingSlash: trailingSlashRemove},
+			url:      "http://example.com/",
+			code:     http.StatusOK,
+		},
+		{
+			scenario: "lower case path",
+			config:   Config{LowercasePath: true},
+			url:      "http://example.com/Recipes/ABC",
+			code:     http.StatusMovedPermanently,
+			location: "/recipes/abc",
+		},
+		{
+			scenario: "single redirect to the final URL",
+			config: Config{
+				Rules:         []Rule{{Regex: `^/old`, To: "/New", StatusCode: http.StatusPermanentRedirect}},
+				HTTPS:         HTTPS{Enabled: true},
+				TrailingSlash: trailingSlashAdd,
+				LowercasePath: true,
+			},
+			url:      "http://example.com/Old/Recipes",
+			code:     http.StatusPermanentRedirect,
+			location: "https://example.com/New/recipes/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			rd, err := newRedirector(tt.config)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.https {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			w := httptest.NewRecorder()
+			rd.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRedirectForwardedProto(t *testing.T) {
+	rd, err := newRedirector(Config{HTTPS: HTTPS{Enabled: true}})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/recipes", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	_, _, ok := rd.redirect(req)
+	assert.False(t, ok)
+}
@@ -0,0 +1,81 @@
+package redirect
+
+import (
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+const (
+	trailingSlashAdd    = "add"
+	trailingSlashRemove = "remove"
+)
+
+// Config represents the redirect configuration
+type Config struct {
+	// Rules are tried in order, the first one matching the path redirects the request
+	Rules []Rule `json:"rules"`
+	// HTTPS redirects the plain HTTP requests to HTTPS
+	HTTPS HTTPS `json:"https"`
+	// TrailingSlash adds or removes the trailing slash of the paths
+	TrailingSlash string `json:"trailing_slash"`
+	// LowercasePath redirects the paths with upper case letters to their lower case form
+	LowercasePath bool `json:"lowercase_path"`
+	// StatusCode is the status of the path normalization redirects, 301 by default
+	StatusCode int `json:"status_code"`
+}
+
+// Rule redirects the requests whose path matches Regex
+type Rule struct {
+	Regex string `json:"regex"`
+	// To is the path or the URL to redirect to. It can have a query string,
+	// and refer to the capture groups of the regex with $1 or ${name}.
+	To string `json:"to"`
+	// StatusCode is the status of the redirect, 301 by default
+	StatusCode int `json:"status_code"`
+}
+
+// HTTPS upgrades the plain HTTP requests
+type HTTPS struct {
+	Enabled bool `json:"enabled"`
+	// Port is the HTTPS port, the default one when zero
+	Port int `json:"port"`
+	// StatusCode is the status of the redirect, 301 by default
+	StatusCode int `json:"status_code"`
+}
+
+func init() {
+	plugin.RegisterPlugin("redirect", plugin.Plugin{
+		Action:   setupRedirect,
+		Validate: validateConfig,
+	})
+}
+
+func setupRedirect(def *proxy.RouterDefinition, rawConfig plugin.Config) error {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return err
+	}
+
+	r, err := newRedirector(config)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(r.Handler)
+	return nil
+}
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := newRedirector(config); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
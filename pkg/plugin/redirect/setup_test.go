@@ -0,0 +1,52 @@
+package redirect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hellofresh/janus/pkg/plugin"
+	"github.com/hellofresh/janus/pkg/proxy"
+)
+
+func TestRedirectConfig(t *testing.T) {
+	var config Config
+	rawConfig := map[string]interface{}{
+		"rules": []map[string]interface{}{
+			{"regex": "^/v1/(.*)$", "to": "/v2/$1", "status_code": 308},
+		},
+		"https":          map[string]interface{}{"enabled": true, "port": 8443},
+		"trailing_slash": "remove",
+		"lowercase_path": true,
+	}
+
+	err := plugin.Decode(rawConfig, &config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Rule{{Regex: "^/v1/(.*)$", To: "/v2/$1", StatusCode: 308}}, config.Rules)
+	assert.Equal(t, HTTPS{Enabled: true, Port: 8443}, config.HTTPS)
+	assert.Equal(t, "remove", config.TrailingSlash)
+	assert.True(t, config.LowercasePath)
+
+	def := proxy.NewRouterDefinition(proxy.NewDefinition())
+	assert.NoError(t, setupRedirect(def, rawConfig))
+	assert.Len(t, def.Middleware(), 1)
+}
+
+func TestRedirectValidation(t *testing.T) {
+	for _, rawConfig := range []plugin.Config{
+		{"rules": []map[string]interface{}{{"regex": "(", "to": "/"}}},
+		{"rules": []map[string]interface{}{{"regex": "^/v1"}}},
+		{"rules": []map[string]interface{}{{"regex": "^/v1", "to": "/v2", "status_code": 200}}},
+		{"https": map[string]interface{}{"enabled": true, "status_code": 303}},
+		{"trailing_slash": "keep"},
+	} {
+		valid, err := validateConfig(rawConfig)
+		assert.False(t, valid)
+		assert.Error(t, err)
+	}
+
+	valid, err := validateConfig(plugin.Config{"https": map[string]interface{}{"enabled": true}})
+	assert.True(t, valid)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,210 @@
+package requesttransformer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/bytefmt"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/jsonbody"
+)
+
+// options are the parsed templates of the values of a transformation
+type options struct {
+	headers templates
+	query   templates
+	body    map[string]interface{}
+}
+
+// transformer holds the transformations of the config, parsed once
+type transformer struct {
+	remove  options
+	rename  options
+	replace options
+	add     options
+	append  options
+	json    *jsonbody.Transformer
+	limit   int
+}
+
+func newTransformer(config Config) (*transformer, error) {
+	t := &transformer{}
+
+	var err error
+	for _, o := range []struct {
+		dst *options
+		src Options
+	}{
+		{&t.remove, config.Remove},
+		{&t.rename, config.Rename},
+		{&t.replace, config.Replace},
+		{&t.add, config.Add},
+		{&t.append, config.Append},
+	} {
+		if *o.dst, err = parseOptions(o.src); err != nil {
+			return nil, err
+		}
+	}
+
+	rename := make(map[string]string, len(config.Rename.Body))
+	for path, value := range config.Rename.Body {
+		name, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("the new name of %q must be a string", path)
+		}
+		rename[path] = name
+	}
+
+	t.json, err = jsonbody.NewTransformer(jsonbody.Operations{
+		Remove:  keys(config.Remove.Body),
+		Rename:  rename,
+		Replace: t.replace.body,
+		Add:     t.add.body,
+		Append:  t.append.body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	size := config.MaxBodySize
+	if size == "" {
+		size = defaultMaxBodySize
+	}
+	limit, err := bytefmt.ToBytes(size)
+	if err != nil {
+		return nil, err
+	}
+	t.limit = int(limit)
+
+	return t, nil
+}
+
+func parseOptions(o Options) (options, error) {
+	var result options
+	var err error
+
+	if result.headers, err = parseTemplates(o.Headers); err != nil {
+		return result, err
+	}
+	if result.query, err = parseTemplates(o.QueryString); err != nil {
+		return result, err
+	}
+
+	result.body = make(map[string]interface{}, len(o.Body))
+	for path, value := range o.Body {
+		s, ok := value.(string)
+		if !ok {
+			result.body[path] = value
+			continue
+		}
+
+		if result.body[path], err = parseTemplate(s); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// transformBody applies the body transformations to the JSON and form-encoded bodies,
+// the request is left alone when there are none
+func (t *transformer) transformBody(r *http.Request, src *source) {
+	if t.json.Empty() || r.Body == nil || r.Body == http.NoBody || r.Header.Get("Content-Encoding") != "" {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	isForm := mediaType == "application/x-www-form-urlencoded"
+	if !isJSON && !isForm {
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(t.limit)+1))
+	if err != nil || len(body) > t.limit {
+		log.WithError(err).WithField("limit", t.limit).Warn("Could not read the request body, sending it untransformed")
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return
+	}
+	r.Body.Close()
+
+	var transformed []byte
+	if isJSON {
+		transformed, err = t.json.TransformWith(body, func(value interface{}) interface{} {
+			if tpl, ok := value.(*template); ok {
+				return tpl.execute(src)
+			}
+			return value
+		})
+	} else {
+		transformed, err = t.transformForm(body, src)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Could not transform the request body, sending it untransformed")
+		transformed = body
+	}
+
+	setBody(r, transformed)
+}
+
+// transformForm applies the body transformations to the fields of a form-encoded body
+func (t *transformer) transformForm(body []byte, src *source) ([]byte, error) {
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	transform(t.remove.formValues(src), removeQueryString(form))
+	transform(t.rename.formValues(src), renameQueryString(form))
+	transform(t.replace.formValues(src), replaceQueryString(form))
+	transform(t.add.formValues(src), addQueryString(form))
+	transform(t.append.formValues(src), appendQueryString(form))
+
+	return []byte(form.Encode()), nil
+}
+
+// formValues returns the body values as form fields
+func (o options) formValues(src *source) map[string]string {
+	values := make(map[string]string, len(o.body))
+	for name, value := range o.body {
+		if tpl, ok := value.(*template); ok {
+			values[name] = tpl.execute(src)
+		} else {
+			values[name] = fmt.Sprint(value)
+		}
+	}
+	return values
+}
+
+func setBody(r *http.Request, body []byte) {
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	r.TransferEncoding = nil
+	r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func keys(m map[string]interface{}) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
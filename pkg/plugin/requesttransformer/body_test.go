@@ -0,0 +1,105 @@
+package requesttransformer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstreamRequest runs the request through the transformer and returns the request the upstream gets, with its body
+func upstreamRequest(t *testing.T, config Config, r *http.Request) (*http.Request, string) {
+	t.Helper()
+
+	var upstream *http.Request
+	var body []byte
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		upstream = r
+		body, err = ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+	})
+
+	NewRequestTransformer(config)(handler).ServeHTTP(httptest.NewRecorder(), r)
+	require.NotNil(t, upstream)
+	return upstream, string(body)
+}
+
+func TestTransformJSONBody(t *testing.T) {
+	config := Config{
+		Remove: Options{Body: map[string]interface{}{"password": ""}},
+		Rename: Options{Body: map[string]interface{}{"user.mail": "email"}},
+		Add: Options{Body: map[string]interface{}{
+			"country":    "$(header.X-Country)",
+			"request_id": "$(request_id)",
+			"source":     map[string]interface{}{"channel": "web"},
+		}},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"user":{"mail":"jane@example.com"},"password":"secret"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Country", "DE")
+
+	upstream, body := upstreamRequest(t, config, r)
+	assert.JSONEq(t, `{"user":{"email":"jane@example.com"},"country":"DE","request_id":"","source":{"channel":"web"}}`, body)
+	assert.Equal(t, int64(len(body)), upstream.ContentLength)
+	assert.Equal(t, strconv.Itoa(len(body)), upstream.Header.Get("Content-Length"))
+}
+
+func TestTransformFormBody(t *testing.T) {
+	config := Config{
+		Remove:  Options{Body: map[string]interface{}{"csrf": ""}},
+		Rename:  Options{Body: map[string]interface{}{"mail": "email"}},
+		Replace: Options{Body: map[string]interface{}{"lang": "$(query.lang)"}},
+		Append:  Options{Body: map[string]interface{}{"tags": 1}},
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/?lang=de", strings.NewReader("csrf=token&mail=jane%40example.com&lang=en&tags=0"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	upstream, body := upstreamRequest(t, config, r)
+	form, err := url.ParseQuery(body)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"email": {"jane@example.com"}, "lang": {"de"}, "tags": {"0", "1"}}, form)
+	assert.Equal(t, int64(len(body)), upstream.ContentLength)
+}
+
+func TestBodyLeftAlone(t *testing.T) {
+	bodyRules := Config{
+		Add:         Options{Body: map[string]interface{}{"added": true}},
+		MaxBodySize: "16B",
+	}
+
+	tests := []struct {
+		scenario    string
+		config      Config
+		contentType string
+		body        string
+	}{
+		{scenario: "no body rules", config: Config{Add: Options{Headers: map[string]string{"X-Test": "test"}}}, contentType: "application/json", body: `{"a":1}`},
+		{scenario: "other content type", config: bodyRules, contentType: "text/plain", body: `{"a":1}`},
+		{scenario: "body over the size cap", config: bodyRules, contentType: "application/json", body: `{"name":"a long enough name"}`},
+		{scenario: "invalid JSON", config: bodyRules, contentType: "application/json", body: `{"a":`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			original := ioutil.NopCloser(strings.NewReader(tt.body))
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Body = original
+			r.Header.Set("Content-Type", tt.contentType)
+
+			upstream, body := upstreamRequest(t, tt.config, r)
+			assert.Equal(t, tt.body, body)
+			if tt.scenario == "no body rules" {
+				assert.Equal(t, original, upstream.Body)
+			}
+		})
+	}
+}
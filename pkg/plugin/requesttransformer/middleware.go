@@ -3,14 +3,20 @@ package requesttransformer
 import (
 	"net/http"
 	"net/url"
+
+	log "github.com/sirupsen/logrus"
 )
 
+const defaultMaxBodySize = "1M"
+
 type headerFn func(headerName string, headerValue string)
 
 // Options represents the available options to transform
 type Options struct {
 	Headers     map[string]string `json:"headers"`
 	QueryString map[string]string `json:"querystring"`
+	// Body maps the fields of JSON bodies, by JSON path, or of form-encoded bodies, by name, to their values
+	Body map[string]interface{} `json:"body"`
 }
 
 // Config represent the configuration of the modify headers middleware
@@ -18,32 +24,52 @@ type Config struct {
 	Add     Options `json:"add"`
 	Append  Options `json:"append"`
 	Remove  Options `json:"remove"`
+	Rename  Options `json:"rename"`
 	Replace Options `json:"replace"`
+	// MaxBodySize is the size of the bodies buffered to be transformed, in the bytefmt format.
+	// Larger bodies are sent untransformed.
+	MaxBodySize string `json:"max_body_size"`
 }
 
-// NewRequestTransformer creates a new instance of RequestTransformer
+// NewRequestTransformer creates a new instance of RequestTransformer.
+// The requests go through untransformed when the configuration is invalid, the plugin rejects such configurations.
 func NewRequestTransformer(config Config) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			query := r.URL.Query()
+	t, err := newTransformer(config)
+	if err != nil {
+		log.WithError(err).Error("Could not create the request transformations")
+		return func(next http.Handler) http.Handler { return next }
+	}
 
-			transform(config.Remove.Headers, removeHeaders(r))
-			transform(config.Remove.QueryString, removeQueryString(query))
+	return t.Handler
+}
 
-			transform(config.Replace.Headers, replaceHeaders(r))
-			transform(config.Replace.QueryString, replaceQueryString(query))
+// Handler is the middleware function
+func (t *transformer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src := newSource(r)
+		query := r.URL.Query()
 
-			transform(config.Add.Headers, addHeaders(r))
-			transform(config.Add.QueryString, addQueryString(query))
+		transform(t.remove.headers.execute(src), removeHeaders(r))
+		transform(t.remove.query.execute(src), removeQueryString(query))
 
-			transform(config.Append.Headers, appendHeaders(r))
-			transform(config.Append.QueryString, appendQueryString(query))
+		transform(t.rename.headers.execute(src), renameHeaders(r))
+		transform(t.rename.query.execute(src), renameQueryString(query))
 
-			r.URL.RawQuery = query.Encode()
+		transform(t.replace.headers.execute(src), replaceHeaders(r))
+		transform(t.replace.query.execute(src), replaceQueryString(query))
 
-			next.ServeHTTP(w, r)
-		})
-	}
+		transform(t.add.headers.execute(src), addHeaders(r))
+		transform(t.add.query.execute(src), addQueryString(query))
+
+		transform(t.append.headers.execute(src), appendHeaders(r))
+		transform(t.append.query.execute(src), appendQueryString(query))
+
+		r.URL.RawQuery = query.Encode()
+
+		t.transformBody(r, src)
+
+		next.ServeHTTP(w, r)
+	})
 }
 
 // If and only if the header is not already set, set a new header with the given value. Ignored if the header is already set.
@@ -69,6 +95,21 @@ func removeHeaders(r *http.Request) headerFn {
 	}
 }
 
+// If the header is set, move its values to the header with the new name. Ignored if the header is not set.
+func renameHeaders(r *http.Request) headerFn {
+	return func(headerName string, newName string) {
+		values := r.Header.Values(headerName)
+		if len(values) == 0 {
+			return
+		}
+
+		r.Header.Del(headerName)
+		for _, value := range values {
+			r.Header.Add(newName, value)
+		}
+	}
+}
+
 // If and only if the header is already set, replace its old value with the new one. Ignored if the header is not already set.
 func replaceHeaders(r *http.Request) headerFn {
 	return func(headerName string, headerValue string) {
@@ -108,6 +149,18 @@ func removeQueryString(query url.Values) headerFn {
 	}
 }
 
+func renameQueryString(query url.Values) headerFn {
+	return func(name string, newName string) {
+		values, ok := query[name]
+		if !ok {
+			return
+		}
+
+		query.Del(name)
+		query[newName] = append(query[newName], values...)
+	}
+}
+
 func replaceQueryString(query url.Values) headerFn {
 	return func(name string, value string) {
 		if query.Get(name) != "" {
@@ -7,7 +7,8 @@ import (
 
 func init() {
 	plugin.RegisterPlugin("request_transformer", plugin.Plugin{
-		Action: setupRequestTransformer,
+		Action:   setupRequestTransformer,
+		Validate: validateConfig,
 	})
 }
 
@@ -18,6 +19,25 @@ func setupRequestTransformer(def *proxy.RouterDefinition, rawConfig plugin.Confi
 		return err
 	}
 
-	def.AddMiddleware(NewRequestTransformer(config))
+	t, err := newTransformer(config)
+	if err != nil {
+		return err
+	}
+
+	def.AddMiddleware(t.Handler)
 	return nil
 }
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := newTransformer(config); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
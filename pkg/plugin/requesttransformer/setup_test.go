@@ -49,3 +49,29 @@ func TestRequestTransformerPlugin(t *testing.T) {
 
 	assert.Len(t, def.Middleware(), 1)
 }
+
+func TestRequestTransformerValidation(t *testing.T) {
+	valid, err := validateConfig(map[string]interface{}{
+		"add": map[string]interface{}{
+			"headers": map[string]string{"X-Consumer": "$(consumer.username)"},
+			"body":    map[string]interface{}{"user.id": "$(path.id)"},
+		},
+	})
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	for _, rawConfig := range []map[string]interface{}{
+		{"add": map[string]interface{}{"headers": map[string]string{"X-Test": "$(cookie.id)"}}},
+		{"add": map[string]interface{}{"body": map[string]interface{}{"a..b": "value"}}},
+		{"rename": map[string]interface{}{"body": map[string]interface{}{"name": 1}}},
+		{"max_body_size": "big"},
+	} {
+		valid, err := validateConfig(rawConfig)
+		assert.False(t, valid)
+		assert.Error(t, err)
+
+		def := proxy.NewRouterDefinition(proxy.NewDefinition())
+		assert.Error(t, setupRequestTransformer(def, rawConfig))
+		assert.Empty(t, def.Middleware())
+	}
+}
@@ -0,0 +1,169 @@
+package requesttransformer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+	"github.com/hellofresh/janus/pkg/router"
+)
+
+const (
+	refHeader    = "header"
+	refQuery     = "query"
+	refPath      = "path"
+	refConsumer  = "consumer"
+	refClaim     = "claim"
+	refRequestID = "request_id"
+)
+
+// source is what the references of the templates read from, the request as it came in
+type source struct {
+	r      *http.Request
+	header http.Header
+	query  url.Values
+}
+
+func newSource(r *http.Request) *source {
+	return &source{r: r, header: r.Header.Clone(), query: r.URL.Query()}
+}
+
+type reference func(s *source) string
+
+// template is a value with references to the request such as `$(header.X-Country)`
+type template struct {
+	literals   []string
+	references []reference
+}
+
+// parseTemplate splits the value into literals and references, a value without references is kept as it is
+func parseTemplate(value string) (*template, error) {
+	t := &template{}
+
+	for {
+		start := strings.Index(value, "$(")
+		if start < 0 {
+			t.literals = append(t.literals, value)
+			return t, nil
+		}
+
+		end := strings.IndexByte(value[start:], ')')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated reference in %q", value)
+		}
+
+		ref, err := parseReference(value[start+2 : start+end])
+		if err != nil {
+			return nil, err
+		}
+
+		t.literals = append(t.literals, value[:start])
+		t.references = append(t.references, ref)
+		value = value[start+end+1:]
+	}
+}
+
+func parseReference(s string) (reference, error) {
+	kind, name := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		kind, name = s[:i], s[i+1:]
+	}
+
+	switch kind {
+	case refRequestID:
+		return func(s *source) string {
+			return obs.RequestIDFromContext(s.r.Context())
+		}, nil
+	case refConsumer:
+		return consumerReference(name)
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("reference %q needs a name", s)
+	}
+
+	switch kind {
+	case refHeader:
+		return func(s *source) string { return s.header.Get(name) }, nil
+	case refQuery:
+		return func(s *source) string { return s.query.Get(name) }, nil
+	case refPath:
+		return func(s *source) string { return router.URLParam(s.r, name) }, nil
+	case refClaim:
+		return func(s *source) string {
+			claims, ok := consumer.ClaimsFromContext(s.r.Context())
+			if !ok || claims[name] == nil {
+				return ""
+			}
+			return fmt.Sprint(claims[name])
+		}, nil
+	default:
+		return nil, fmt.Errorf("reference %q is not supported", s)
+	}
+}
+
+func consumerReference(field string) (reference, error) {
+	var get func(c *consumer.Consumer) string
+	switch field {
+	case "", "username":
+		get = func(c *consumer.Consumer) string { return c.Username }
+	case "custom_id":
+		get = func(c *consumer.Consumer) string { return c.CustomID }
+	default:
+		return nil, fmt.Errorf("consumer field %q is not supported", field)
+	}
+
+	return func(s *source) string {
+		c, ok := consumer.FromContext(s.r.Context())
+		if !ok {
+			return ""
+		}
+		return get(c)
+	}, nil
+}
+
+func (t *template) isStatic() bool {
+	return len(t.references) == 0
+}
+
+// execute replaces the references with their values, the missing ones being empty
+func (t *template) execute(s *source) string {
+	if t.isStatic() {
+		return t.literals[0]
+	}
+
+	var b strings.Builder
+	for i, ref := range t.references {
+		b.WriteString(t.literals[i])
+		b.WriteString(ref(s))
+	}
+	b.WriteString(t.literals[len(t.literals)-1])
+	return b.String()
+}
+
+// templates are the templates of the values of a transformation
+type templates map[string]*template
+
+func parseTemplates(values map[string]string) (templates, error) {
+	result := make(templates, len(values))
+	for name, value := range values {
+		t, err := parseTemplate(value)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = t
+	}
+	return result, nil
+}
+
+// execute returns the values of the templates for the request
+func (t templates) execute(s *source) map[string]string {
+	values := make(map[string]string, len(t))
+	for name, tpl := range t {
+		values[name] = tpl.execute(s)
+	}
+	return values
+}
@@ -0,0 +1,53 @@
+package requesttransformer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	obs "github.com/hellofresh/janus/pkg/observability"
+	"github.com/hellofresh/janus/pkg/plugin/consumer"
+)
+
+func TestTemplate(t *testing.T) {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "42")
+
+	r := httptest.NewRequest(http.MethodGet, "/recipes/42?lang=en", nil)
+	r.Header.Set("X-Country", "DE")
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+	ctx = obs.RequestIDToContext(ctx, "request-1")
+	ctx = consumer.NewContext(ctx, &consumer.Consumer{Username: "jane", CustomID: "c-1"})
+	ctx = consumer.NewClaimsContext(ctx, map[string]interface{}{"sub": "user-1"})
+	src := newSource(r.WithContext(ctx))
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "static", expected: "static"},
+		{value: "$(header.X-Country)", expected: "DE"},
+		{value: "$(query.lang)-$(header.X-Country)", expected: "en-DE"},
+		{value: "recipe $(path.id)", expected: "recipe 42"},
+		{value: "$(consumer)/$(consumer.custom_id)", expected: "jane/c-1"},
+		{value: "$(claim.sub)", expected: "user-1"},
+		{value: "$(request_id)", expected: "request-1"},
+		{value: "[$(header.X-Missing)]", expected: "[]"},
+	}
+
+	for _, tt := range tests {
+		tpl, err := parseTemplate(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, tpl.execute(src), tt.value)
+	}
+
+	for _, invalid := range []string{"$(header.X", "$(header)", "$(cookie.id)", "$(consumer.password)"} {
+		_, err := parseTemplate(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -1,14 +1,24 @@
 package responsetransformer
 
 import (
+	"fmt"
 	"net/http"
+
+	"code.cloudfoundry.org/bytefmt"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/jsonbody"
 )
 
+const defaultMaxBodySize = "1M"
+
 type headerFn func(headerName string, headerValue string)
 
 // Options represents the available options to transform
 type Options struct {
 	Headers map[string]string `json:"headers"`
+	// Body maps the paths of the JSON body fields to their values
+	Body map[string]interface{} `json:"body"`
 }
 
 // Config represent the configuration of the modify headers middleware
@@ -16,23 +26,88 @@ type Config struct {
 	Add     Options `json:"add"`
 	Append  Options `json:"append"`
 	Remove  Options `json:"remove"`
+	Rename  Options `json:"rename"`
 	Replace Options `json:"replace"`
+	// Filter keeps the elements of the JSON arrays matching the predicates
+	Filter []jsonbody.Filter `json:"filter"`
+	// Envelope wraps the JSON body in an object with this key
+	Envelope string `json:"envelope"`
+	// MaxBodySize is the size of the JSON bodies buffered to be transformed, in the bytefmt format.
+	// Larger bodies are sent untransformed.
+	MaxBodySize string `json:"max_body_size"`
+}
+
+// operations returns the transformations of the JSON body
+func (c Config) operations() (jsonbody.Operations, error) {
+	ops := jsonbody.Operations{
+		Replace:  c.Replace.Body,
+		Add:      c.Add.Body,
+		Append:   c.Append.Body,
+		Filter:   c.Filter,
+		Envelope: c.Envelope,
+	}
+
+	for path := range c.Remove.Body {
+		ops.Remove = append(ops.Remove, path)
+	}
+
+	if len(c.Rename.Body) > 0 {
+		ops.Rename = make(map[string]string, len(c.Rename.Body))
+		for path, value := range c.Rename.Body {
+			key, ok := value.(string)
+			if !ok {
+				return ops, fmt.Errorf("the new name of %q must be a string", path)
+			}
+			ops.Rename[path] = key
+		}
+	}
+
+	return ops, nil
 }
 
-// NewResponseTransformer creates a new instance of RequestTransformer
+// NewResponseTransformer creates a new instance of ResponseTransformer
 func NewResponseTransformer(config Config) func(http.Handler) http.Handler {
+	var transformer *jsonbody.Transformer
+	ops, err := config.operations()
+	if err == nil {
+		transformer, err = jsonbody.NewTransformer(ops)
+	}
+	if err != nil {
+		log.WithError(err).Error("Could not create the body transformations, only the headers are transformed")
+		transformer = nil
+	}
+	if transformer != nil && transformer.Empty() {
+		transformer = nil
+	}
+
+	limit, err := bytefmt.ToBytes(config.MaxBodySize)
+	if err != nil {
+		limit, _ = bytefmt.ToBytes(defaultMaxBodySize)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			next.ServeHTTP(w, r)
+			tw := &transformWriter{
+				ResponseWriter: w,
+				headers:        func() { transformHeaders(config, w) },
+				transformer:    transformer,
+				limit:          int(limit),
+			}
 
-			transform(config.Remove.Headers, removeHeaders(w))
-			transform(config.Replace.Headers, replaceHeaders(w))
-			transform(config.Add.Headers, addHeaders(w))
-			transform(config.Append.Headers, appendHeaders(w))
+			next.ServeHTTP(tw, r)
+			tw.finish()
 		})
 	}
 }
 
+func transformHeaders(config Config, w http.ResponseWriter) {
+	transform(config.Remove.Headers, removeHeaders(w))
+	transform(config.Rename.Headers, renameHeaders(w))
+	transform(config.Replace.Headers, replaceHeaders(w))
+	transform(config.Add.Headers, addHeaders(w))
+	transform(config.Append.Headers, appendHeaders(w))
+}
+
 // If and only if the header is not already set, set a new header with the given value. Ignored if the header is already set.
 func addHeaders(w http.ResponseWriter) headerFn {
 	return func(headerName string, headerValue string) {
@@ -56,6 +131,21 @@ func removeHeaders(w http.ResponseWriter) headerFn {
 	}
 }
 
+// If the header is set, move its values to the header with the new name. Ignored if the header is not set.
+func renameHeaders(w http.ResponseWriter) headerFn {
+	return func(headerName string, newName string) {
+		values := w.Header().Values(headerName)
+		if len(values) == 0 {
+			return
+		}
+
+		w.Header().Del(headerName)
+		for _, value := range values {
+			w.Header().Add(newName, value)
+		}
+	}
+}
+
 // If and only if the header is already set, replace its old value with the new one. Ignored if the header is not already set.
 func replaceHeaders(w http.ResponseWriter) headerFn {
 	return func(headerName string, headerValue string) {
@@ -3,8 +3,10 @@ package responsetransformer
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
+	"github.com/hellofresh/janus/pkg/jsonbody"
 	"github.com/hellofresh/janus/pkg/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -80,3 +82,112 @@ func TestAppendHeader(t *testing.T) {
 
 	assert.Equal(t, "test", w.Header().Get("Test"))
 }
+
+func TestHeadersBeforeWriteHeader(t *testing.T) {
+	config := Config{
+		Add: Options{
+			Headers: map[string]string{"X-Added": "yes"},
+		},
+		Remove: Options{
+			Headers: map[string]string{"X-Internal": ""},
+		},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Internal", "secret")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	w := httptest.NewRecorder()
+	NewResponseTransformer(config)(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "yes", resp.Header.Get("X-Added"))
+	assert.Empty(t, resp.Header.Get("X-Internal"))
+	assert.Equal(t, "created", w.Body.String())
+}
+
+func TestRenameHeader(t *testing.T) {
+	config := Config{
+		Rename: Options{
+			Headers: map[string]string{"Content-Type": "X-Content-Type"},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewResponseTransformer(config)(http.HandlerFunc(test.Ping)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	resp := w.Result()
+	assert.Empty(t, resp.Header.Get("Content-Type"))
+	assert.Equal(t, "application/json", resp.Header.Get("X-Content-Type"))
+}
+
+func jsonHandler(contentType string, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	})
+}
+
+func TestTransformBody(t *testing.T) {
+	config := Config{
+		Remove: Options{
+			Body: map[string]interface{}{"items[*].secret": ""},
+		},
+		Rename: Options{
+			Body: map[string]interface{}{"total_count": "total"},
+		},
+		Add: Options{
+			Headers: map[string]string{"X-Transformed": "true"},
+			Body:    map[string]interface{}{"meta.version": "v1"},
+		},
+		Filter:   []jsonbody.Filter{{Path: "items", Predicate: "active == true"}},
+		Envelope: "data",
+	}
+
+	body := `{"total_count":2,"items":[{"id":1,"active":true,"secret":"a"},{"id":2,"active":false,"secret":"b"}]}`
+
+	w := httptest.NewRecorder()
+	NewResponseTransformer(config)(jsonHandler("application/json; charset=utf-8", body)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("X-Transformed"))
+	assert.Equal(t, strconv.Itoa(w.Body.Len()), resp.Header.Get("Content-Length"))
+	assert.JSONEq(t, `{"data":{"total":2,"meta":{"version":"v1"},"items":[{"id":1,"active":true}]}}`, w.Body.String())
+}
+
+func TestTransformBodyPassthrough(t *testing.T) {
+	config := Config{
+		Add: Options{
+			Headers: map[string]string{"X-Transformed": "true"},
+		},
+		Envelope:    "data",
+		MaxBodySize: "16B",
+	}
+
+	tests := []struct {
+		scenario    string
+		contentType string
+		body        string
+	}{
+		{scenario: "non JSON content type", contentType: "text/plain", body: `{"a":1}`},
+		{scenario: "body over the size cap", contentType: "application/json", body: `{"name":"a long enough name"}`},
+		{scenario: "invalid JSON", contentType: "application/json", body: `{"a":`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			NewResponseTransformer(config)(jsonHandler(tt.contentType, tt.body)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			resp := w.Result()
+			assert.Equal(t, "true", resp.Header.Get("X-Transformed"))
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package responsetransformer
 
 import (
+	"code.cloudfoundry.org/bytefmt"
+
+	"github.com/hellofresh/janus/pkg/jsonbody"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/proxy"
 )
 
 func init() {
 	plugin.RegisterPlugin("response_transformer", plugin.Plugin{
-		Action: setupResponseTransformer,
+		Action:   setupResponseTransformer,
+		Validate: validateConfig,
 	})
 }
 
@@ -21,3 +25,27 @@ func setupResponseTransformer(def *proxy.RouterDefinition, rawConfig plugin.Conf
 	def.AddMiddleware(NewResponseTransformer(config))
 	return nil
 }
+
+func validateConfig(rawConfig plugin.Config) (bool, error) {
+	var config Config
+	err := plugin.Decode(rawConfig, &config)
+	if err != nil {
+		return false, err
+	}
+
+	if config.MaxBodySize != "" {
+		if _, err := bytefmt.ToBytes(config.MaxBodySize); err != nil {
+			return false, err
+		}
+	}
+
+	ops, err := config.operations()
+	if err != nil {
+		return false, err
+	}
+	if _, err := jsonbody.NewTransformer(ops); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -43,3 +43,23 @@ func TestResponseTransformerPlugin(t *testing.T) {
 
 	assert.Len(t, def.Middleware(), 1)
 }
+
+func TestResponseTransformerValidation(t *testing.T) {
+	valid, err := validateConfig(map[string]interface{}{
+		"remove": map[string]interface{}{"body": map[string]interface{}{"items[*].secret": ""}},
+		"filter": []map[string]interface{}{{"path": "items", "predicate": "active == true"}},
+	})
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	for _, rawConfig := range []map[string]interface{}{
+		{"remove": map[string]interface{}{"body": map[string]interface{}{"items[x]": ""}}},
+		{"rename": map[string]interface{}{"body": map[string]interface{}{"name": 1}}},
+		{"filter": []map[string]interface{}{{"path": "items", "predicate": "active =="}}},
+		{"max_body_size": "big"},
+	} {
+		valid, err := validateConfig(rawConfig)
+		assert.False(t, valid)
+		assert.Error(t, err)
+	}
+}
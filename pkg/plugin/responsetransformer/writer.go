@@ -0,0 +1,132 @@
+package responsetransformer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/hellofresh/janus/pkg/jsonbody"
+)
+
+// transformWriter applies the header transformations before the status code is written.
+// JSON bodies are buffered to be transformed, the other ones are streamed to the client.
+type transformWriter struct {
+	http.ResponseWriter
+	headers     func()
+	transformer *jsonbody.Transformer
+	limit       int
+
+	code      int
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *transformWriter) WriteHeader(code int) {
+	if w.code != 0 {
+		return
+	}
+	w.code = code
+
+	if w.transformer != nil && code != http.StatusNoContent && code != http.StatusNotModified && isTransformable(w.Header()) {
+		w.buffering = true
+		return
+	}
+
+	w.writeHeader()
+}
+
+func (w *transformWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.buffering {
+		return w.ResponseWriter.Write(p)
+	}
+
+	if w.body.Len()+len(p) <= w.limit {
+		return w.body.Write(p)
+	}
+
+	log.WithField("limit", w.limit).Warn("Response body is too large to be transformed, sending it untransformed")
+	w.buffering = false
+	w.writeHeader()
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		return 0, err
+	}
+	w.body.Reset()
+
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher, the buffered bodies are only written once transformed
+func (w *transformWriter) Flush() {
+	if w.buffering {
+		return
+	}
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, so that the upgraded connections go through
+func (w *transformWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// finish writes the transformed body, or the headers when nothing was written
+func (w *transformWriter) finish() {
+	if w.code == 0 {
+		w.headers()
+		return
+	}
+	if !w.buffering {
+		return
+	}
+
+	body := w.body.Bytes()
+	if len(body) > 0 {
+		transformed, err := w.transformer.Transform(body)
+		if err != nil {
+			log.WithError(err).Warn("Could not transform the response body, sending it untransformed")
+		} else {
+			body = transformed
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	w.writeHeader()
+	w.ResponseWriter.Write(body)
+}
+
+func (w *transformWriter) writeHeader() {
+	w.headers()
+	w.ResponseWriter.WriteHeader(w.code)
+}
+
+// isTransformable checks whether the body is plain JSON
+func isTransformable(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+const budgetWindow = 10 * time.Second
+
+// budget caps the retries to a percentage of the requests of the last seconds, so that retries
+// don't multiply the load of an upstream that is already failing
+type budget struct {
+	sync.Mutex
+	percent      int64
+	minPerSecond int64
+	requests     [budgetWindow / time.Second]int64
+	retries      [budgetWindow / time.Second]int64
+	second       int64
+	now          func() time.Time
+}
+
+func newBudget(percent int, minPerSecond int) *budget {
+	return &budget{percent: int64(percent), minPerSecond: int64(minPerSecond), now: time.Now}
+}
+
+// request counts a request against the budget
+func (b *budget) request() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.requests[b.slot()]++
+}
+
+// withdraw counts a retry when the budget allows it
+func (b *budget) withdraw() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	slot := b.slot()
+
+	var requests, retries int64
+	for i := range b.requests {
+		requests += b.requests[i]
+		retries += b.retries[i]
+	}
+
+	allowed := requests * b.percent / 100
+	if min := b.minPerSecond * int64(budgetWindow/time.Second); allowed < min {
+		allowed = min
+	}
+	if retries >= allowed {
+		return false
+	}
+
+	b.retries[slot]++
+	return true
+}
+
+// slot returns the counters index of the current second, resetting the ones of the seconds passed since the last call
+func (b *budget) slot() int {
+	second := b.now().Unix()
+	size := int64(len(b.requests))
+
+	for s := b.second + 1; s <= second && s <= b.second+size; s++ {
+		b.requests[s%size] = 0
+		b.retries[s%size] = 0
+	}
+	if second > b.second {
+		b.second = second
+	}
+
+	return int(b.second % size)
+}
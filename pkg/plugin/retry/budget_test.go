@@ -0,0 +1,30 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudget(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := newBudget(20, 1)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		b.request()
+	}
+
+	for i := 0; i < 20; i++ {
+		assert.True(t, b.withdraw())
+	}
+	assert.False(t, b.withdraw())
+
+	// the requests and retries leave the window
+	now = now.Add(budgetWindow)
+	for i := 0; i < 10; i++ {
+		assert.True(t, b.withdraw(), "min retries per second are always allowed")
+	}
+	assert.False(t, b.withdraw())
+}
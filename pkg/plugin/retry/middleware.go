@@ -1,18 +1,20 @@
 package retry
 
 import (
-	"errors"
-	"fmt"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Knetic/govaluate"
-	"github.com/felixge/httpsnoop"
-	"github.com/rafaeljesus/retry-go"
 	log "github.com/sirupsen/logrus"
 
 	janusErr "github.com/hellofresh/janus/pkg/errors"
 	"github.com/hellofresh/janus/pkg/metrics"
+	"github.com/hellofresh/janus/pkg/proxy"
 )
 
 const (
@@ -20,8 +22,24 @@ const (
 	proxySection     = "proxy"
 )
 
-// NewRetryMiddleware creates a new retry middleware
+// NewRetryMiddleware creates a new retry middleware.
+// The responses of the attempts are buffered until one of them succeeds, and the request body is
+// replayed on every attempt, the next attempts going to the targets that were not tried yet.
+// Requests with a body or a response over the limits are not retried.
 func NewRetryMiddleware(cfg Config) func(http.Handler) http.Handler {
+	cfg = cfg.withDefaults()
+	b := newBudget(cfg.Budget.Percent, cfg.Budget.MinPerSecond)
+
+	expression, err := govaluate.NewEvaluableExpression(cfg.Predicate)
+	if err != nil {
+		log.WithError(err).WithField("predicate", cfg.Predicate).Error("could not create an expression with this predicate")
+	}
+
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, method := range cfg.Methods {
+		methods[strings.ToUpper(method)] = true
+	}
+
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log.WithFields(log.Fields{
@@ -29,39 +47,147 @@ func NewRetryMiddleware(cfg Config) func(http.Handler) http.Handler {
 				"backoff":  cfg.Backoff,
 			}).Debug("Starting retry middleware")
 
-			if cfg.Predicate == "" {
-				cfg.Predicate = defaultPredicate
+			b.request()
+			if expression == nil || !methods[r.Method] || isUpgrade(r) {
+				handler.ServeHTTP(w, r)
+				return
 			}
 
-			expression, err := govaluate.NewEvaluableExpression(cfg.Predicate)
+			body, replayable, err := bufferBody(r, cfg.maxRequestBody)
 			if err != nil {
-				log.WithError(err).Error("could not create an expression with this predicate")
+				janusErr.Handler(w, r, janusErr.New(http.StatusBadRequest, "could not read the request body"))
+				return
+			}
+			if !replayable {
 				handler.ServeHTTP(w, r)
 				return
 			}
 
-			if err := retry.Do(func() error {
-				m := httpsnoop.CaptureMetrics(handler, w, r)
+			ctx, attempts := proxy.WithAttempts(r.Context())
+			r = r.WithContext(ctx)
+
+			for attempt := 1; ; attempt++ {
+				if body != nil {
+					r.Body = ioutil.NopCloser(bytes.NewReader(body))
+				}
 
-				params := make(map[string]interface{}, 8)
-				params["statusCode"] = m.Code
-				params["request"] = r
+				resp := newBufferedResponse(w, cfg.maxResponseBody)
+				handler.ServeHTTP(resp, r)
 
-				result, err := expression.Evaluate(params)
+				failed, err := evaluate(expression, resp.StatusCode(), r)
 				if err != nil {
-					return errors.New("cannot evaluate the expression")
+					log.WithError(err).Error("cannot evaluate the expression")
 				}
 
-				if result.(bool) {
-					return fmt.Errorf("%s %s request failed", r.Method, r.URL)
+				if !failed || resp.Flushed() || attempt >= cfg.Attempts || r.Context().Err() != nil {
+					if failed {
+						trackFailure(r)
+					}
+					if err := resp.flush(); err != nil {
+						log.WithError(err).Debug("Could not write the response")
+					}
+					return
 				}
 
-				return nil
-			}, cfg.Attempts, time.Duration(cfg.Backoff)); err != nil {
-				statsClient := metrics.WithContext(r.Context())
-				statsClient.SetHTTPRequestSection(proxySection).TrackRequest(r, nil, false).ResetHTTPRequestSection()
-				janusErr.Handler(w, r, fmt.Errorf("request failed too many times: %w", err))
+				if !b.withdraw() {
+					log.WithField("targets", attempts.Targets()).Debug("Retry budget exhausted")
+					trackFailure(r)
+					resp.flush()
+					return
+				}
+
+				log.WithFields(log.Fields{
+					"attempt":     attempt,
+					"status_code": resp.StatusCode(),
+					"targets":     attempts.Targets(),
+				}).Debug("Retrying request")
+
+				if !sleep(r, backoff(cfg, attempt)) {
+					trackFailure(r)
+					resp.flush()
+					return
+				}
 			}
 		})
 	}
 }
+
+// bufferBody reads the request body when it fits in the limit. Otherwise the request body is
+// left readable from the start and the request can't be retried.
+func bufferBody(r *http.Request, limit int) ([]byte, bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true, nil
+	}
+	if r.ContentLength > int64(limit) {
+		return nil, false, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(body) > limit {
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, false, nil
+	}
+
+	r.Body.Close()
+	return body, true, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func evaluate(expression *govaluate.EvaluableExpression, statusCode int, r *http.Request) (bool, error) {
+	params := make(map[string]interface{}, 2)
+	params["statusCode"] = statusCode
+	params["request"] = r
+
+	result, err := expression.Evaluate(params)
+	if err != nil {
+		return false, err
+	}
+
+	failed, _ := result.(bool)
+	return failed, nil
+}
+
+// backoff returns the delay before the next attempt, exponential with full jitter
+func backoff(cfg Config, attempt int) time.Duration {
+	delay := time.Duration(cfg.MaxBackoff)
+	if shift := uint(attempt - 1); shift < 32 {
+		if d := time.Duration(cfg.Backoff) << shift; d > 0 && d < delay {
+			delay = d
+		}
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// sleep waits for the delay, unless the client went away
+func sleep(r *http.Request, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func isUpgrade(r *http.Request) bool {
+	return r.Header.Get("Upgrade") != ""
+}
+
+func trackFailure(r *http.Request) {
+	statsClient := metrics.WithContext(r.Context())
+	statsClient.SetHTTPRequestSection(proxySection).TrackRequest(r, nil, false).ResetHTTPRequestSection()
+}
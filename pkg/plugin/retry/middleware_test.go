@@ -1,13 +1,20 @@
 package retry
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/hellofresh/janus/pkg/test"
+	"github.com/hellofresh/stats-go/client"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/proxy"
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+	"github.com/hellofresh/janus/pkg/test"
 )
 
 func TestMiddleware(t *testing.T) {
@@ -60,9 +67,125 @@ func testSuccessfulUpstreamRetry(t *testing.T, r *http.Request, w *httptest.Resp
 }
 
 func testFailedUpstreamRetry(t *testing.T, r *http.Request, w *httptest.ResponseRecorder) {
-	mw := NewRetryMiddleware(Config{Attempts: 2, Backoff: Duration(time.Second)})
+	mw := NewRetryMiddleware(Config{Attempts: 2, Backoff: Duration(time.Millisecond)})
 
 	mw(test.FailWith(http.StatusBadGateway)).ServeHTTP(w, r)
 
 	assert.Equal(t, http.StatusBadGateway, w.Code)
 }
+
+// flakyHandler fails the first requests, echoing the request body
+type flakyHandler struct {
+	failures int
+	calls    int
+	bodies   []string
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	body, _ := ioutil.ReadAll(r.Body)
+	h.bodies = append(h.bodies, string(body))
+
+	if h.calls <= h.failures {
+		w.Header().Set("X-Failed", "true")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("failed"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(body)
+}
+
+func TestRetryReplaysBodyAndBuffersResponse(t *testing.T) {
+	h := &flakyHandler{failures: 2}
+	mw := NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond)})
+
+	w := httptest.NewRecorder()
+	mw(h).ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload")))
+
+	assert.Equal(t, 3, h.calls)
+	assert.Equal(t, []string{"payload", "payload", "payload"}, h.bodies)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "payload", w.Body.String())
+	assert.Empty(t, w.Header().Get("X-Failed"))
+}
+
+func TestRetryOnlyIdempotentMethods(t *testing.T) {
+	h := &flakyHandler{failures: 1}
+	mw := NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond)})
+
+	w := httptest.NewRecorder()
+	mw(h).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload")))
+	assert.Equal(t, 1, h.calls)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	h = &flakyHandler{failures: 1}
+	mw = NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond), Methods: []string{"post"}})
+
+	w = httptest.NewRecorder()
+	mw(h).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("payload")))
+	assert.Equal(t, 2, h.calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestRetryOverBufferLimits(t *testing.T) {
+	h := &flakyHandler{failures: 1}
+	mw := NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond), MaxRequestBody: "4B"})
+
+	w := httptest.NewRecorder()
+	mw(h).ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("payload")))
+	assert.Equal(t, 1, h.calls)
+	assert.Equal(t, []string{"payload"}, h.bodies)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	h = &flakyHandler{failures: 1}
+	mw = NewRetryMiddleware(Config{Attempts: 3, Backoff: Duration(time.Millisecond), MaxResponseBody: "4B"})
+
+	w = httptest.NewRecorder()
+	mw(h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 1, h.calls)
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, "failed", w.Body.String())
+}
+
+func TestRetryAcrossTargets(t *testing.T) {
+	var hits []string
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, "failing")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits = append(hits, "healthy")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	def := proxy.NewDefinition()
+	def.ListenPath = "/"
+	def.Upstreams.Targets = proxy.Targets{{Target: failing.URL}, {Target: healthy.URL}}
+	b, err := balancer.New("roundrobin")
+	require.NoError(t, err)
+	handler := proxy.NewBalancedReverseProxy(def, b, client.NewNoop())
+
+	mw := NewRetryMiddleware(Config{Attempts: 2, Backoff: Duration(time.Millisecond)})
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		mw(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	// the failing target is never tried twice for the same request
+	assert.NotContains(t, strings.Join(hits, ","), "failing,failing")
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := Config{Backoff: Duration(100 * time.Millisecond), MaxBackoff: Duration(300 * time.Millisecond)}
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, backoff(cfg, 1) <= 100*time.Millisecond)
+		assert.True(t, backoff(cfg, 2) <= 200*time.Millisecond)
+		assert.True(t, backoff(cfg, 10) <= 300*time.Millisecond)
+	}
+}
@@ -0,0 +1,94 @@
+package retry
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// bufferedResponse holds the response of an attempt until it is known whether the request is retried.
+// Once the body goes over the size limit, the response is written through and can't be retried anymore.
+type bufferedResponse struct {
+	w       http.ResponseWriter
+	header  http.Header
+	code    int
+	body    bytes.Buffer
+	limit   int
+	flushed bool
+}
+
+func newBufferedResponse(w http.ResponseWriter, limit int) *bufferedResponse {
+	return &bufferedResponse{w: w, header: make(http.Header), limit: limit}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	if b.flushed {
+		return b.w.Header()
+	}
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.flushed {
+		return
+	}
+	if b.code == 0 {
+		b.code = code
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.code == 0 {
+		b.WriteHeader(http.StatusOK)
+	}
+
+	if !b.flushed && b.body.Len()+len(p) > b.limit {
+		if err := b.flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	if b.flushed {
+		return b.w.Write(p)
+	}
+	return b.body.Write(p)
+}
+
+// Flush implements http.Flusher, the response is flushed only once written through
+func (b *bufferedResponse) Flush() {
+	if !b.flushed {
+		return
+	}
+	if f, ok := b.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// StatusCode returns the status code of the response, zero when nothing was written
+func (b *bufferedResponse) StatusCode() int {
+	return b.code
+}
+
+// Flushed is true once the response was written to the client
+func (b *bufferedResponse) Flushed() bool {
+	return b.flushed
+}
+
+// flush writes the buffered response to the client
+func (b *bufferedResponse) flush() error {
+	if b.flushed {
+		return nil
+	}
+	b.flushed = true
+
+	header := b.w.Header()
+	for k, v := range b.header {
+		header[k] = v
+	}
+
+	if b.code != 0 {
+		b.w.WriteHeader(b.code)
+	}
+
+	_, err := b.w.Write(b.body.Bytes())
+	return err
+}
@@ -2,9 +2,11 @@ package retry
 
 import (
 	"errors"
+	"net/http"
 	"strconv"
 	"time"
 
+	"code.cloudfoundry.org/bytefmt"
 	"github.com/asaskevich/govalidator"
 
 	"github.com/hellofresh/janus/pkg/plugin"
@@ -13,14 +15,43 @@ import (
 
 const (
 	strNull = "null"
+
+	defaultAttempts    = 3
+	defaultBackoff     = Duration(time.Second)
+	defaultMaxBackoff  = Duration(10 * time.Second)
+	defaultMaxBodySize = "1M"
 )
 
+// defaultMethods are the idempotent methods
+var defaultMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete,
+}
+
 type (
-	// Config represents the Body Limit configuration
+	// Config represents the Retry configuration
 	Config struct {
-		Attempts  int      `json:"attempts"`
-		Backoff   Duration `json:"backoff"`
-		Predicate string   `json:"predicate"`
+		// Attempts is the number of times the request is sent, the first attempt included
+		Attempts   int      `json:"attempts"`
+		Backoff    Duration `json:"backoff"`
+		MaxBackoff Duration `json:"max_backoff"`
+		Predicate  string   `json:"predicate"`
+		// Methods are the methods of the requests that are retried, the idempotent ones by default
+		Methods []string `json:"methods"`
+		// MaxRequestBody is the size of the request bodies buffered to be replayed, in the bytefmt format
+		MaxRequestBody string `json:"max_request_body"`
+		// MaxResponseBody is the size of the response bodies buffered until an attempt succeeds, in the bytefmt format
+		MaxResponseBody string `json:"max_response_body"`
+		Budget          Budget `json:"budget"`
+
+		maxRequestBody  int
+		maxResponseBody int
+	}
+
+	// Budget caps the retries to a percentage of the requests of the last 10 seconds
+	Budget struct {
+		Percent int `json:"percent"`
+		// MinPerSecond are the retries allowed regardless of the percentage, for APIs with low traffic
+		MinPerSecond int `json:"min_per_second"`
 	}
 
 	// Duration is a wrapper for time.Duration so we can use human readable configs
@@ -56,6 +87,48 @@ func (d *Duration) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// withDefaults returns the config with the defaults of the missing values
+func (c Config) withDefaults() Config {
+	if c.Attempts <= 0 {
+		c.Attempts = defaultAttempts
+	}
+	if c.Backoff <= 0 {
+		c.Backoff = defaultBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = defaultMaxBackoff
+	}
+	if c.Predicate == "" {
+		c.Predicate = defaultPredicate
+	}
+	if len(c.Methods) == 0 {
+		c.Methods = defaultMethods
+	}
+	if c.Budget.Percent <= 0 {
+		c.Budget.Percent = 20
+	}
+	if c.Budget.MinPerSecond <= 0 {
+		c.Budget.MinPerSecond = 10
+	}
+
+	c.maxRequestBody = bodySize(c.MaxRequestBody)
+	c.maxResponseBody = bodySize(c.MaxResponseBody)
+
+	return c
+}
+
+func bodySize(size string) int {
+	if size == "" {
+		size = defaultMaxBodySize
+	}
+
+	bytes, err := bytefmt.ToBytes(size)
+	if err != nil {
+		bytes, _ = bytefmt.ToBytes(defaultMaxBodySize)
+	}
+	return int(bytes)
+}
+
 func init() {
 	plugin.RegisterPlugin("retry", plugin.Plugin{
 		Action:   setupRetry,
@@ -81,5 +154,14 @@ func validateConfig(rawConfig plugin.Config) (bool, error) {
 		return false, err
 	}
 
+	for _, size := range []string{config.MaxRequestBody, config.MaxResponseBody} {
+		if size == "" {
+			continue
+		}
+		if _, err := bytefmt.ToBytes(size); err != nil {
+			return false, err
+		}
+	}
+
 	return govalidator.ValidateStruct(config)
 }
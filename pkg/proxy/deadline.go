@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+
+	janusErr "github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/observability"
+)
+
+const (
+	// RequestTimeoutHeader is the header the clients can set to shorten the timeout of their requests,
+	// in the time.Duration format or in milliseconds. The remaining time is sent to the upstreams in it.
+	RequestTimeoutHeader = "X-Request-Timeout"
+	// GRPCTimeoutHeader is the timeout of the gRPC requests
+	GRPCTimeoutHeader = "Grpc-Timeout"
+
+	proxyErrorTimeout    = "timeout"
+	proxyErrorBadGateway = "bad_gateway"
+)
+
+// ErrGatewayTimeout is used when the upstream did not respond in time
+var ErrGatewayTimeout = janusErr.New(http.StatusGatewayTimeout, "upstream request timed out")
+
+// grpcTimeoutUnits are the units of the gRPC timeouts, from the most precise one
+var grpcTimeoutUnits = []struct {
+	unit     string
+	duration time.Duration
+}{
+	{"n", time.Nanosecond},
+	{"u", time.Microsecond},
+	{"m", time.Millisecond},
+	{"S", time.Second},
+	{"M", time.Minute},
+	{"H", time.Hour},
+}
+
+// NewDeadlineMiddleware sets the deadline of the requests of an API. The timeout asked by the client
+// in the X-Request-Timeout or grpc-timeout header is used when shorter than the given timeout.
+// The requests have no deadline when neither is set.
+func NewDeadlineMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestTimeout := timeout
+			if clientTimeout, ok := clientTimeout(r); ok && (requestTimeout <= 0 || clientTimeout < requestTimeout) {
+				requestTimeout = clientTimeout
+			}
+
+			if requestTimeout <= 0 {
+				handler.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+			defer cancel()
+
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// withTryTimeout limits the time of every attempt of a request to reach the upstreams
+func withTryTimeout(handler http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// clientTimeout returns the timeout asked by the client
+func clientTimeout(r *http.Request) (time.Duration, bool) {
+	if value := r.Header.Get(RequestTimeoutHeader); value != "" {
+		if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond, true
+		}
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d, true
+		}
+		log.WithField("value", value).Debug("Invalid request timeout header")
+	}
+
+	if value := r.Header.Get(GRPCTimeoutHeader); value != "" {
+		d, err := parseGRPCTimeout(value)
+		if err == nil && d > 0 {
+			return d, true
+		}
+		log.WithError(err).WithField("value", value).Debug("Invalid gRPC timeout header")
+	}
+
+	return 0, false
+}
+
+// propagateDeadline sends the time left before the deadline of the request to the upstream
+func propagateDeadline(req *http.Request) {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return
+	}
+
+	remaining := time.Until(deadline).Truncate(time.Millisecond)
+	if remaining < time.Millisecond {
+		remaining = time.Millisecond
+	}
+
+	req.Header.Set(RequestTimeoutHeader, remaining.String())
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+		req.Header.Set(GRPCTimeoutHeader, formatGRPCTimeout(remaining))
+	}
+}
+
+func parseGRPCTimeout(value string) (time.Duration, error) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, fmt.Errorf("invalid grpc timeout %q", value)
+	}
+
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid grpc timeout %q: %w", value, err)
+	}
+
+	unit := value[len(value)-1:]
+	for _, u := range grpcTimeoutUnits {
+		if u.unit == unit {
+			return time.Duration(n) * u.duration, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid grpc timeout unit %q", unit)
+}
+
+// formatGRPCTimeout formats the timeout with the most precise unit that fits in the 8 digits of a gRPC timeout
+func formatGRPCTimeout(d time.Duration) string {
+	for _, u := range grpcTimeoutUnits {
+		if n := d / u.duration; n < 100000000 {
+			return strconv.FormatInt(int64(n), 10) + u.unit
+		}
+	}
+	return "99999999H"
+}
+
+// newErrorHandler responds with 504 when the upstream timed out and 502 otherwise, counting both separately.
+// The fallback response is served instead when there is one.
+func newErrorHandler(fallback *Response) func(http.ResponseWriter, *http.Request, error) {
+	var fallbackHandler http.Handler
+	if fallback != nil {
+		var err error
+		if fallbackHandler, err = NewResponseHandler(fallback); err != nil {
+			log.WithError(err).Error("Could not create the fallback response")
+		}
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		if rejection, ok := rejectionFromContext(r.Context()); ok {
+			rejection.Handler.ServeHTTP(w, r)
+			return
+		}
+
+		kind := proxyErrorBadGateway
+		if isTimeout(err) {
+			kind = proxyErrorTimeout
+		}
+
+		if ctx, tagErr := tag.New(r.Context(), tag.Insert(observability.KeyProxyError, kind)); tagErr == nil {
+			stats.Record(ctx, observability.MProxyErrors.M(1))
+		}
+
+		logger := log.WithError(err).WithField("request-id", observability.RequestIDFromContext(r.Context()))
+		if kind == proxyErrorTimeout {
+			logger.Warn("Upstream request timed out")
+		} else {
+			logger.Warn("Could not reach the upstream")
+		}
+
+		switch {
+		case fallbackHandler != nil:
+			fallbackHandler.ServeHTTP(w, r)
+		case kind == proxyErrorTimeout:
+			janusErr.Handler(w, r, ErrGatewayTimeout)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
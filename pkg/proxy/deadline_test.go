@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellofresh/stats-go/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+func deadlineOf(t *testing.T, timeout time.Duration, headers map[string]string) (time.Duration, bool) {
+	t.Helper()
+
+	var remaining time.Duration
+	var ok bool
+	handler := NewDeadlineMiddleware(timeout)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, ok = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	return remaining, ok
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	_, ok := deadlineOf(t, 0, nil)
+	assert.False(t, ok)
+
+	tests := []struct {
+		timeout  time.Duration
+		headers  map[string]string
+		expected time.Duration
+	}{
+		{timeout: 10 * time.Second, expected: 10 * time.Second},
+		{timeout: 10 * time.Second, headers: map[string]string{RequestTimeoutHeader: "1s"}, expected: time.Second},
+		{timeout: 2 * time.Second, headers: map[string]string{RequestTimeoutHeader: "5000"}, expected: 2 * time.Second},
+		{timeout: 0, headers: map[string]string{RequestTimeoutHeader: "5000"}, expected: 5 * time.Second},
+		{timeout: 10 * time.Second, headers: map[string]string{GRPCTimeoutHeader: "3S"}, expected: 3 * time.Second},
+		{timeout: 10 * time.Second, headers: map[string]string{RequestTimeoutHeader: "soon"}, expected: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		remaining, ok := deadlineOf(t, tt.timeout, tt.headers)
+		assert.True(t, ok)
+		assert.InDelta(t, float64(tt.expected), float64(remaining), float64(100*time.Millisecond), "%v", tt.headers)
+	}
+}
+
+func TestPropagateDeadline(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	propagateDeadline(r)
+	assert.Empty(t, r.Header.Get(RequestTimeoutHeader))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/grpc+proto")
+	propagateDeadline(r)
+
+	remaining, err := time.ParseDuration(r.Header.Get(RequestTimeoutHeader))
+	require.NoError(t, err)
+	assert.InDelta(t, float64(time.Minute), float64(remaining), float64(time.Second))
+
+	remaining, err = parseGRPCTimeout(r.Header.Get(GRPCTimeoutHeader))
+	require.NoError(t, err)
+	assert.InDelta(t, float64(time.Minute), float64(remaining), float64(time.Second))
+}
+
+func TestGRPCTimeout(t *testing.T) {
+	assert.Equal(t, "1500000u", formatGRPCTimeout(1500*time.Millisecond))
+	assert.Equal(t, "3600000m", formatGRPCTimeout(time.Hour))
+
+	d, err := parseGRPCTimeout("100m")
+	require.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, d)
+
+	_, err = parseGRPCTimeout("100x")
+	assert.Error(t, err)
+	_, err = parseGRPCTimeout("123456789S")
+	assert.Error(t, err)
+}
+
+func TestUpstreamTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	def := NewDefinition()
+	def.ListenPath = "/"
+	def.Upstreams.Targets = Targets{{Target: slow.URL}}
+	b, err := balancer.New("roundrobin")
+	require.NoError(t, err)
+	handler := withTryTimeout(NewBalancedReverseProxy(def, b, client.NewNoop()), 10*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.JSONEq(t, `{"error":"upstream request timed out"}`, w.Body.String())
+
+	slow.Close()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
@@ -25,11 +25,23 @@ type Definition struct {
 	Methods            []string           `bson:"methods" json:"methods"`
 	Hosts              []string           `bson:"hosts" json:"hosts"`
 	ForwardingTimeouts ForwardingTimeouts `bson:"forwarding_timeouts" json:"forwarding_timeouts" mapstructure:"forwarding_timeouts"`
+	Bulkhead           Bulkhead           `bson:"bulkhead" json:"bulkhead" mapstructure:"bulkhead"`
+	// Response is served instead of proxying the requests, the upstreams are not needed then
+	Response *Response `bson:"response" json:"response,omitempty"`
+	// Fallback is served when the upstreams could not be reached or their circuits are open
+	Fallback *Response `bson:"fallback" json:"fallback,omitempty"`
+	// Match are the conditions, besides the listen path and the methods, the requests must meet to be routed to the definition
+	Match Match `bson:"match" json:"match"`
+	// Rewrite are the rules rewriting the path sent to the upstreams, the first matching rule is applied
+	// instead of strip_path and append_path
+	Rewrite []RewriteRule `bson:"rewrite" json:"rewrite,omitempty"`
 }
 
 // RouterDefinition represents an API that you want to proxy with internal router routines
 type RouterDefinition struct {
 	*Definition
+	// Name is the name of the API, empty for the routes that are not APIs
+	Name       string
 	middleware []router.Constructor
 }
 
@@ -109,6 +121,35 @@ func (d *Duration) SetBSON(raw bson.RawValue) error {
 type ForwardingTimeouts struct {
 	DialTimeout           Duration `bson:"dial_timeout" json:"dial_timeout"`
 	ResponseHeaderTimeout Duration `bson:"response_header_timeout" json:"response_header_timeout"`
+	// RequestTimeout is the time the whole request can take, retries included.
+	// It is also the maximum of the timeout the client can ask for.
+	RequestTimeout Duration `bson:"request_timeout" json:"request_timeout"`
+	// TryTimeout is the time every attempt to reach the upstreams can take
+	TryTimeout Duration `bson:"try_timeout" json:"try_timeout"`
+}
+
+// Bulkhead caps the number of concurrent requests to the upstreams of a route,
+// the requests over the cap waiting in a bounded queue.
+type Bulkhead struct {
+	// MaxConcurrent is the number of requests in flight to the upstreams, zero disables the bulkhead
+	MaxConcurrent int `bson:"max_concurrent" json:"max_concurrent"`
+	// MaxQueue is the number of requests waiting for one of the requests in flight to finish
+	MaxQueue int `bson:"max_queue" json:"max_queue"`
+	// QueueTimeout is how long a request waits in the queue, until the client goes away when zero
+	QueueTimeout Duration `bson:"queue_timeout" json:"queue_timeout"`
+}
+
+// Validate checks the bulkhead limits are not negative
+func (b Bulkhead) Validate() error {
+	switch {
+	case b.MaxConcurrent < 0:
+		return fmt.Errorf("max_concurrent can't be negative")
+	case b.MaxQueue < 0:
+		return fmt.Errorf("max_queue can't be negative")
+	case b.QueueTimeout < 0:
+		return fmt.Errorf("queue_timeout can't be negative")
+	}
+	return nil
 }
 
 // NewDefinition creates a new Proxy Definition with default values
@@ -139,7 +180,45 @@ func (d *RouterDefinition) AddMiddleware(m router.Constructor) {
 
 // Validate validates proxy data
 func (d *Definition) Validate() (bool, error) {
-	return govalidator.ValidateStruct(d)
+	if ok, err := govalidator.ValidateStruct(d); !ok {
+		return ok, err
+	}
+
+	for _, host := range d.Hosts {
+		if _, err := parseHostPattern(host); err != nil {
+			return false, fmt.Errorf("proxy.hosts: %w", err)
+		}
+	}
+
+	if _, err := newRequestMatcher(d.Match); err != nil {
+		return false, fmt.Errorf("proxy.match: %w", err)
+	}
+
+	if _, err := newRewriter(d.Rewrite); err != nil {
+		return false, fmt.Errorf("proxy.rewrite: %w", err)
+	}
+
+	if err := d.Bulkhead.Validate(); err != nil {
+		return false, fmt.Errorf("proxy.bulkhead: %w", err)
+	}
+
+	if d.Response != nil {
+		if err := d.Response.Validate(); err != nil {
+			return false, fmt.Errorf("proxy.response: %w", err)
+		}
+	}
+	if d.Fallback != nil {
+		if err := d.Fallback.Validate(); err != nil {
+			return false, fmt.Errorf("proxy.fallback: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// IsMock checks if the route serves a static response instead of proxying the requests
+func (d *Definition) IsMock() bool {
+	return d.Response != nil
 }
 
 // IsBalancerDefined checks if load balancer is defined
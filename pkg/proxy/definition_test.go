@@ -33,6 +33,10 @@ func TestDefinition(t *testing.T) {
 			scenario: "invalid target url validation",
 			function: testInvalidTargetURLValidation,
 		},
+		{
+			scenario: "negative bulkhead validation",
+			function: testNegativeBulkheadValidation,
+		},
 		{
 			scenario: "is balancer defined",
 			function: testIsBalancerDefined,
@@ -89,6 +93,29 @@ func testEmptyListenPathValidation(t *testing.T) {
 	assert.False(t, isValid)
 }
 
+func testNegativeBulkheadValidation(t *testing.T) {
+	for _, bulkhead := range []Bulkhead{
+		{MaxConcurrent: -1},
+		{MaxConcurrent: 10, MaxQueue: -1},
+		{MaxConcurrent: 10, QueueTimeout: Duration(-time.Second)},
+	} {
+		definition := NewDefinition()
+		definition.ListenPath = "/"
+		definition.Bulkhead = bulkhead
+
+		isValid, err := definition.Validate()
+		assert.Error(t, err)
+		assert.False(t, isValid)
+	}
+
+	definition := NewDefinition()
+	definition.ListenPath = "/"
+	definition.Bulkhead = Bulkhead{MaxConcurrent: 10, MaxQueue: 5, QueueTimeout: Duration(time.Second)}
+	isValid, err := definition.Validate()
+	assert.NoError(t, err)
+	assert.True(t, isValid)
+}
+
 func testInvalidTargetURLValidation(t *testing.T) {
 	definition := Definition{
 		ListenPath: " ",
@@ -162,7 +189,9 @@ func testUnmarshalForwardingTimeoutsFromJSON(t *testing.T) {
     ],
     "forwarding_timeouts": {
       "dial_timeout": "30s",
-      "response_header_timeout": "31s"
+      "response_header_timeout": "31s",
+      "request_timeout": "10s",
+      "try_timeout": "2s"
     }
   }
 `)
@@ -172,4 +201,6 @@ func testUnmarshalForwardingTimeoutsFromJSON(t *testing.T) {
 
 	assert.Equal(t, 30*time.Second, time.Duration(definition.ForwardingTimeouts.DialTimeout))
 	assert.Equal(t, 31*time.Second, time.Duration(definition.ForwardingTimeouts.ResponseHeaderTimeout))
+	assert.Equal(t, 10*time.Second, time.Duration(definition.ForwardingTimeouts.RequestTimeout))
+	assert.Equal(t, 2*time.Second, time.Duration(definition.ForwardingTimeouts.TryTimeout))
 }
@@ -0,0 +1,280 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/idna"
+
+	"github.com/hellofresh/janus/pkg/router"
+)
+
+const (
+	hostExact = iota
+	hostSuffix
+	hostPrefix
+	hostRegex
+
+	hostRegexPrefix = "~"
+)
+
+// hostPattern is one of the hosts of a definition: an exact hostname, a wildcard on its leftmost
+// label such as `*.example.com`, a wildcard on its rightmost label such as `example.*`,
+// or a regular expression starting with `~`. All but the regular expressions can have a port.
+type hostPattern struct {
+	kind int
+	// name is the exact host, the suffix of a leftmost wildcard or the prefix of a rightmost one
+	name  string
+	port  string
+	regex *regexp.Regexp
+}
+
+func parseHostPattern(raw string) (*hostPattern, error) {
+	if strings.HasPrefix(raw, hostRegexPrefix) {
+		regex, err := regexp.Compile(raw[len(hostRegexPrefix):])
+		if err != nil {
+			return nil, fmt.Errorf("host %q: %w", raw, err)
+		}
+		return &hostPattern{kind: hostRegex, regex: regex}, nil
+	}
+
+	host, port := splitHostPort(raw)
+	p := &hostPattern{port: port}
+
+	var err error
+	switch {
+	case strings.HasPrefix(host, "*.") && !strings.Contains(host[2:], "*"):
+		p.kind = hostSuffix
+		p.name, err = normalizeHost(host[2:])
+		p.name = "." + p.name
+	case strings.HasSuffix(host, ".*") && !strings.Contains(host[:len(host)-2], "*"):
+		p.kind = hostPrefix
+		p.name, err = normalizeHost(host[:len(host)-2])
+		p.name += "."
+	case strings.Contains(host, "*"):
+		// Asterisks anywhere else match any characters, as they always did
+		parts := strings.Split(strings.ToLower(host), "*")
+		for i := range parts {
+			parts[i] = regexp.QuoteMeta(parts[i])
+		}
+		p.kind = hostRegex
+		p.regex = regexp.MustCompile("^" + strings.Join(parts, ".+") + "$")
+	default:
+		p.kind = hostExact
+		p.name, err = normalizeHost(host)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("host %q: %w", raw, err)
+	}
+
+	return p, nil
+}
+
+// key identifies the patterns matching the same hosts
+func (p *hostPattern) key() string {
+	if p.regex != nil {
+		return fmt.Sprintf("%d %s", p.kind, p.regex)
+	}
+	return fmt.Sprintf("%d %s %s", p.kind, p.name, p.port)
+}
+
+// ShareHost checks whether the hosts have a pattern in common once normalized, or both have none
+func ShareHost(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+
+	keys := make(map[string]bool, len(a))
+	for _, host := range a {
+		keys[hostKey(host)] = true
+	}
+	for _, host := range b {
+		if keys[hostKey(host)] {
+			return true
+		}
+	}
+	return false
+}
+
+// hostKey is the key of the host pattern, or the lower cased host when it is not valid
+func hostKey(host string) string {
+	pattern, err := parseHostPattern(host)
+	if err != nil {
+		return strings.ToLower(host)
+	}
+	return pattern.key()
+}
+
+// precedes orders the patterns from the most specific: exact hosts, the longest leftmost wildcards,
+// the longest rightmost wildcards and then regular expressions
+func (p *hostPattern) precedes(o *hostPattern) bool {
+	if p.kind != o.kind {
+		return p.kind < o.kind
+	}
+	if len(p.name) != len(o.name) {
+		return len(p.name) > len(o.name)
+	}
+	return p.port != "" && o.port == ""
+}
+
+// matches checks the normalized host and the port of a request
+func (p *hostPattern) matches(host, port string) bool {
+	if p.port != "" && p.port != port {
+		return false
+	}
+
+	switch p.kind {
+	case hostExact:
+		return host == p.name
+	case hostSuffix:
+		return strings.HasSuffix(host, p.name)
+	case hostPrefix:
+		return len(host) > len(p.name) && strings.HasPrefix(host, p.name)
+	default:
+		return p.regex.MatchString(host)
+	}
+}
+
+// requestHost returns the normalized host of a request and its port, the default one of the scheme when missing
+func requestHost(r *http.Request) (string, string) {
+	host, port := splitHostPort(r.Host)
+	if normalized, err := normalizeHost(host); err == nil {
+		host = normalized
+	} else {
+		host = strings.ToLower(host)
+	}
+
+	if port == "" {
+		port = "80"
+		if r.TLS != nil {
+			port = "443"
+		}
+	}
+
+	return host, port
+}
+
+func splitHostPort(hostport string) (string, string) {
+	if host, port, err := net.SplitHostPort(hostport); err == nil {
+		return host, port
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]"), ""
+}
+
+// normalizeHost lower cases the host, removes its trailing dot and converts internationalized names to punycode,
+// so that `bücher.example` and `xn--bcher-kva.example.` are the same host
+func normalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("empty host")
+	}
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	return idna.ToASCII(host)
+}
+
+// hostTree holds the routes of the definitions with a host pattern, or of the ones without hosts
+type hostTree struct {
+	pattern *hostPattern
+	router  *router.ChiRouter
+	groups  map[string]*routeGroup
+}
+
+func newHostTree(pattern *hostPattern) *hostTree {
+	return &hostTree{
+		pattern: pattern,
+		router:  router.NewChiRouterWithOptions(router.DefaultOptions),
+		groups:  make(map[string]*routeGroup),
+	}
+}
+
+// group returns the group of the definitions sharing a listen path, registering it on the tree the first time
+func (t *hostTree) group(listenPath string, methods []string) *routeGroup {
+	group, ok := t.groups[listenPath]
+	if !ok {
+		group = &routeGroup{}
+		t.groups[listenPath] = group
+	}
+
+	for _, method := range methods {
+		if strings.ToUpper(method) == methodAll {
+			t.router.Any(listenPath, group.ServeHTTP)
+		} else {
+			t.router.Handle(strings.ToUpper(method), listenPath, group.ServeHTTP)
+		}
+	}
+
+	return group
+}
+
+// hostRouter dispatches the requests to the tree of the most specific host pattern they match
+// that has a route for their method and path, or to the tree of the definitions without hosts
+type hostRouter struct {
+	sync.RWMutex
+	trees    []*hostTree
+	patterns map[string]*hostTree
+	fallback *hostTree
+}
+
+func newHostRouter() *hostRouter {
+	h := &hostRouter{patterns: make(map[string]*hostTree), fallback: newHostTree(nil)}
+
+	// chi panics when serving a router without routes, there may be no definitions without hosts
+	h.fallback.router.Any("/", router.DefaultOptions.NotFoundHandler)
+
+	return h
+}
+
+// tree returns the tree of a host pattern, the one of the definitions without hosts for nil
+func (h *hostRouter) tree(pattern *hostPattern) *hostTree {
+	if pattern == nil {
+		return h.fallback
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	if tree, ok := h.patterns[pattern.key()]; ok {
+		return tree
+	}
+
+	tree := newHostTree(pattern)
+	h.patterns[pattern.key()] = tree
+
+	trees := append(append(make([]*hostTree, 0, len(h.trees)+1), h.trees...), tree)
+	sort.SliceStable(trees, func(i, j int) bool {
+		return trees[i].pattern.precedes(trees[j].pattern)
+	})
+	h.trees = trees
+
+	return tree
+}
+
+func (h *hostRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.RLock()
+	trees := h.trees
+	h.RUnlock()
+
+	if len(trees) > 0 {
+		host, port := requestHost(r)
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+
+		for _, tree := range trees {
+			if tree.pattern.matches(host, port) && tree.router.Match(r.Method, path) {
+				tree.router.ServeHTTP(w, r)
+				return
+			}
+		}
+	}
+
+	h.fallback.router.ServeHTTP(w, r)
+}
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/router"
+)
+
+func TestHostPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		host     string
+		port     string
+		expected bool
+	}{
+		{pattern: "example.com", host: "example.com", port: "80", expected: true},
+		{pattern: "Example.COM.", host: "example.com", port: "80", expected: true},
+		{pattern: "example.com", host: "api.example.com", port: "80", expected: false},
+		{pattern: "example.com:8080", host: "example.com", port: "8080", expected: true},
+		{pattern: "example.com:8080", host: "example.com", port: "80", expected: false},
+		{pattern: "*.example.com", host: "api.example.com", port: "80", expected: true},
+		{pattern: "*.example.com", host: "x.y.example.com", port: "80", expected: true},
+		{pattern: "*.example.com", host: "example.com", port: "80", expected: false},
+		{pattern: "example.*", host: "example.org", port: "80", expected: true},
+		{pattern: "example.*", host: "example.co.uk", port: "80", expected: true},
+		{pattern: "example.*", host: "api.example.org", port: "80", expected: false},
+		{pattern: `~^api-\d+\.example\.com$`, host: "api-1.example.com", port: "80", expected: true},
+		{pattern: `~^api-\d+\.example\.com$`, host: "api-x.example.com", port: "80", expected: false},
+		{pattern: "api.*.example.com", host: "api.eu.example.com", port: "80", expected: true},
+		{pattern: "bücher.example", host: "xn--bcher-kva.example", port: "80", expected: true},
+		{pattern: "[::1]:8080", host: "::1", port: "8080", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.host, func(t *testing.T) {
+			p, err := parseHostPattern(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, p.matches(tt.host, tt.port))
+		})
+	}
+
+	_, err := parseHostPattern("~(")
+	assert.Error(t, err)
+	_, err = parseHostPattern("*.")
+	assert.Error(t, err)
+}
+
+func TestShareHost(t *testing.T) {
+	assert.True(t, ShareHost(nil, []string{}))
+	assert.False(t, ShareHost(nil, []string{"example.com"}))
+	assert.True(t, ShareHost([]string{"api.example.com"}, []string{"example.com", "API.Example.com."}))
+	assert.True(t, ShareHost([]string{"*.example.com:8080"}, []string{"*.EXAMPLE.com:8080"}))
+	assert.False(t, ShareHost([]string{"*.example.com:8080"}, []string{"*.example.com"}))
+	assert.False(t, ShareHost([]string{"*.example.com"}, []string{"example.*"}))
+	assert.True(t, ShareHost([]string{"bücher.example"}, []string{"xn--bcher-kva.example"}))
+}
+
+func TestRequestHost(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	r.Host = "BÜCHER.example.:8080"
+	host, port := requestHost(r)
+	assert.Equal(t, "xn--bcher-kva.example", host)
+	assert.Equal(t, "8080", port)
+
+	r.Host = "example.com"
+	host, port = requestHost(r)
+	assert.Equal(t, "example.com", host)
+	assert.Equal(t, "80", port)
+
+	r.TLS = &tls.ConnectionState{}
+	_, port = requestHost(r)
+	assert.Equal(t, "443", port)
+}
+
+func TestHostRouting(t *testing.T) {
+	r := router.NewChiRouter()
+	register := NewRegister(WithRouter(r))
+
+	for _, def := range []struct {
+		body       string
+		listenPath string
+		hosts      []string
+	}{
+		{body: "default", listenPath: "/users/*"},
+		{body: "any", listenPath: "/*", hosts: []string{"*.example.com"}},
+		{body: "api", listenPath: "/users/*", hosts: []string{"*.api.example.com"}},
+		{body: "exact", listenPath: "/users/*", hosts: []string{"eu.api.example.com"}},
+		{body: "regex", listenPath: "/users/*", hosts: []string{`~^v\d\.api\.example\.com$`}},
+	} {
+		d := mockDefinition(def.body, []string{"ALL"}, Match{})
+		d.ListenPath = def.listenPath
+		d.Hosts = def.hosts
+		require.NoError(t, register.Add(d))
+	}
+
+	tests := []struct {
+		host     string
+		path     string
+		code     int
+		expected string
+	}{
+		{host: "eu.api.example.com", path: "/users/1", code: http.StatusOK, expected: "exact"},
+		{host: "EU.api.example.com:8080", path: "/users/1", code: http.StatusOK, expected: "exact"},
+		{host: "us.api.example.com", path: "/users/1", code: http.StatusOK, expected: "api"},
+		{host: "v2.api.example.com", path: "/users/1", code: http.StatusOK, expected: "api"},
+		{host: "www.example.com", path: "/users/1", code: http.StatusOK, expected: "any"},
+		{host: "eu.api.example.com", path: "/recipes", code: http.StatusOK, expected: "any"},
+		{host: "example.org", path: "/users/1", code: http.StatusOK, expected: "default"},
+		{host: "example.org", path: "/recipes", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHostRoutingWithoutDefault(t *testing.T) {
+	r := router.NewChiRouter()
+	register := NewRegister(WithRouter(r))
+
+	d := mockDefinition("exact", []string{"GET"}, Match{})
+	d.Hosts = []string{"example.com"}
+	require.NoError(t, register.Add(d))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.org/recipes", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/recipes", nil))
+	assert.Equal(t, "exact", w.Body.String())
+}
@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+)
+
+// Match are the conditions the requests must meet to be routed to a definition, besides its listen path and methods.
+// Several definitions can share a listen path, the requests go to the first one they match.
+type Match struct {
+	Headers []MatchRule `bson:"headers" json:"headers,omitempty"`
+	Query   []MatchRule `bson:"query" json:"query,omitempty"`
+	Cookies []MatchRule `bson:"cookies" json:"cookies,omitempty"`
+	// Priority orders the definitions sharing a listen path, the highest is tried first.
+	// The definitions with the same priority are tried from the one with the most rules.
+	Priority int `bson:"priority" json:"priority"`
+}
+
+// MatchRule matches the values of a header, query parameter or cookie.
+// A rule without Value, Regex and Present matches when there is a value.
+type MatchRule struct {
+	Name string `bson:"name" json:"name"`
+	// Value matches one of the values exactly
+	Value string `bson:"value" json:"value,omitempty"`
+	// Regex matches one of the values against a regular expression
+	Regex string `bson:"regex" json:"regex,omitempty"`
+	// Present matches when there is a value or, when false, when there is none
+	Present *bool `bson:"present" json:"present,omitempty"`
+}
+
+// Equal checks whether the matches have the same rules, whatever their order and priority
+func (m Match) Equal(o Match) bool {
+	return sameRules(m.Headers, o.Headers, http.CanonicalHeaderKey) &&
+		sameRules(m.Query, o.Query, nil) &&
+		sameRules(m.Cookies, o.Cookies, nil)
+}
+
+func sameRules(a, b []MatchRule, canonicalName func(string) string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	keysA, keysB := ruleKeys(a, canonicalName), ruleKeys(b, canonicalName)
+	for i := range keysA {
+		if keysA[i] != keysB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleKeys returns the sorted keys identifying the rules matching the same values
+func ruleKeys(rules []MatchRule, canonicalName func(string) string) []string {
+	keys := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		name := rule.Name
+		if canonicalName != nil {
+			name = canonicalName(name)
+		}
+		present := rule.Present == nil || *rule.Present
+		keys = append(keys, fmt.Sprintf("%q %q %q %t", name, rule.Value, rule.Regex, present))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type valuesFunc func(r *http.Request, name string) []string
+
+type ruleMatcher struct {
+	name    string
+	values  valuesFunc
+	value   string
+	regex   *regexp.Regexp
+	present bool
+}
+
+// requestMatcher checks the match rules of a definition
+type requestMatcher struct {
+	rules []ruleMatcher
+}
+
+func newRequestMatcher(m Match) (*requestMatcher, error) {
+	matcher := &requestMatcher{}
+
+	for _, set := range []struct {
+		kind   string
+		rules  []MatchRule
+		values valuesFunc
+	}{
+		{kind: "header", rules: m.Headers, values: headerValues},
+		{kind: "query", rules: m.Query, values: queryValues},
+		{kind: "cookie", rules: m.Cookies, values: cookieValues},
+	} {
+		for _, rule := range set.rules {
+			compiled, err := newRuleMatcher(rule, set.values)
+			if err != nil {
+				return nil, fmt.Errorf("%s match rule %q: %w", set.kind, rule.Name, err)
+			}
+			matcher.rules = append(matcher.rules, compiled)
+		}
+	}
+
+	return matcher, nil
+}
+
+func newRuleMatcher(rule MatchRule, values valuesFunc) (ruleMatcher, error) {
+	m := ruleMatcher{name: rule.Name, values: values, value: rule.Value, present: true}
+
+	if rule.Name == "" {
+		return m, errors.New("name is required")
+	}
+	if rule.Value != "" && rule.Regex != "" {
+		return m, errors.New("only one of value and regex can be set")
+	}
+
+	if rule.Present != nil {
+		m.present = *rule.Present
+	}
+	if !m.present && (rule.Value != "" || rule.Regex != "") {
+		return m, errors.New("absent values can't be matched")
+	}
+
+	if rule.Regex != "" {
+		regex, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return m, err
+		}
+		m.regex = regex
+	}
+
+	return m, nil
+}
+
+// Matches checks whether the request matches all the rules
+func (m *requestMatcher) Matches(r *http.Request) bool {
+	for _, rule := range m.rules {
+		if !rule.matches(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m ruleMatcher) matches(r *http.Request) bool {
+	values := m.values(r, m.name)
+	if !m.present || len(values) == 0 {
+		return !m.present && len(values) == 0
+	}
+
+	if m.value == "" && m.regex == nil {
+		return true
+	}
+
+	for _, value := range values {
+		if m.regex != nil && m.regex.MatchString(value) || m.regex == nil && value == m.value {
+			return true
+		}
+	}
+	return false
+}
+
+func headerValues(r *http.Request, name string) []string {
+	return r.Header.Values(name)
+}
+
+func queryValues(r *http.Request, name string) []string {
+	return r.URL.Query()[name]
+}
+
+func cookieValues(r *http.Request, name string) []string {
+	var values []string
+	for _, cookie := range r.Cookies() {
+		if cookie.Name == name {
+			values = append(values, cookie.Value)
+		}
+	}
+	return values
+}
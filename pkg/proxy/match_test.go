@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMatcher(t *testing.T) {
+	absent := false
+
+	matcher, err := newRequestMatcher(Match{
+		Headers: []MatchRule{
+			{Name: "Accept", Regex: `^application/vnd\.v2\+json`},
+			{Name: "X-Legacy", Present: &absent},
+		},
+		Query:   []MatchRule{{Name: "lang", Value: "de"}},
+		Cookies: []MatchRule{{Name: "beta"}},
+	})
+	require.NoError(t, err)
+
+	request := func(accept string, legacy bool, query string, cookie bool) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/recipes?"+query, nil)
+		r.Header.Set("Accept", accept)
+		if legacy {
+			r.Header.Set("X-Legacy", "1")
+		}
+		if cookie {
+			r.AddCookie(&http.Cookie{Name: "beta", Value: "yes"})
+		}
+		return r
+	}
+
+	assert.True(t, matcher.Matches(request("application/vnd.v2+json", false, "lang=en&lang=de", true)))
+	assert.False(t, matcher.Matches(request("application/json", false, "lang=de", true)))
+	assert.False(t, matcher.Matches(request("application/vnd.v2+json", true, "lang=de", true)))
+	assert.False(t, matcher.Matches(request("application/vnd.v2+json", false, "lang=en", true)))
+	assert.False(t, matcher.Matches(request("application/vnd.v2+json", false, "lang=de", false)))
+
+	empty, err := newRequestMatcher(Match{})
+	require.NoError(t, err)
+	assert.True(t, empty.Matches(request("", false, "", false)))
+}
+
+func TestRequestMatcherValidation(t *testing.T) {
+	absent := false
+
+	for _, m := range []Match{
+		{Headers: []MatchRule{{Value: "v2"}}},
+		{Headers: []MatchRule{{Name: "Accept", Value: "v2", Regex: "v2"}}},
+		{Query: []MatchRule{{Name: "lang", Regex: "("}}},
+		{Cookies: []MatchRule{{Name: "beta", Value: "yes", Present: &absent}}},
+	} {
+		_, err := newRequestMatcher(m)
+		assert.Error(t, err, "%+v", m)
+	}
+}
+
+func TestMatchEqual(t *testing.T) {
+	present := true
+	absent := false
+
+	m := Match{
+		Headers:  []MatchRule{{Name: "Accept", Value: "application/json"}, {Name: "X-Beta"}},
+		Query:    []MatchRule{{Name: "lang", Value: "de"}},
+		Priority: 1,
+	}
+
+	assert.True(t, m.Equal(Match{
+		Headers:  []MatchRule{{Name: "x-beta", Present: &present}, {Name: "accept", Value: "application/json"}},
+		Query:    []MatchRule{{Name: "lang", Value: "de"}},
+		Priority: 2,
+	}))
+	assert.False(t, m.Equal(Match{
+		Headers: []MatchRule{{Name: "X-Beta", Present: &absent}, {Name: "Accept", Value: "application/json"}},
+		Query:   []MatchRule{{Name: "lang", Value: "de"}},
+	}))
+	assert.False(t, m.Equal(Match{
+		Headers: []MatchRule{{Name: "X-Beta"}, {Name: "Accept", Value: "application/json"}},
+		Query:   []MatchRule{{Name: "Lang", Value: "de"}},
+	}), "query parameter names are case sensitive")
+	assert.False(t, m.Equal(Match{Headers: m.Headers}))
+	assert.True(t, Match{}.Equal(Match{Priority: 5}))
+}
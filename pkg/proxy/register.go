@@ -30,33 +30,66 @@ type Register struct {
 	statsClient            client.Client
 	matcher                *router.ListenPathMatcher
 	isPublicEndpoint       bool
+	hosts                  *hostRouter
 }
 
 // NewRegister creates a new instance of Register
 func NewRegister(opts ...RegisterOption) *Register {
-	r := Register{
-		matcher: router.NewListenPathMatcher(),
-	}
+	r := Register{matcher: router.NewListenPathMatcher()}
 
 	for _, opt := range opts {
 		opt(&r)
 	}
 
+	if r.router != nil {
+		r.UpdateRouter(r.router)
+	}
+
 	return &r
 }
 
 // UpdateRouter updates the reference to the router. This is useful to reload the mux
 func (p *Register) UpdateRouter(router router.Router) {
 	p.router = router
+	p.hosts = newHostRouter()
+
+	// The routes are looked up by host first, every request goes through the host router
+	p.router.Any("/", p.hosts.ServeHTTP)
+	p.router.Any("/*", p.hosts.ServeHTTP)
 }
 
 // Add register a new route
 func (p *Register) Add(definition *RouterDefinition) error {
+	handler, err := p.handler(definition)
+	if err != nil {
+		return err
+	}
+
+	if p.matcher.Match(definition.ListenPath) {
+		p.doRegister(p.matcher.Extract(definition.ListenPath), definition, &ochttp.Handler{Handler: handler, IsPublicEndpoint: p.isPublicEndpoint})
+	}
+
+	p.doRegister(definition.ListenPath, definition, &ochttp.Handler{Handler: handler, IsPublicEndpoint: p.isPublicEndpoint})
+	return nil
+}
+
+// handler creates the handler serving the static response of a mock route, or the reverse proxy to the upstreams
+func (p *Register) handler(definition *RouterDefinition) (http.Handler, error) {
+	if definition.IsMock() {
+		log.WithField("listen_path", definition.ListenPath).Debug("Serving a static response")
+		handler, err := NewResponseHandler(definition.Response)
+		if err != nil {
+			log.WithError(err).Error("Could not create the static response")
+			return nil, fmt.Errorf("could not create the static response: %w", err)
+		}
+		return handler, nil
+	}
+
 	log.WithField("balancing_alg", definition.Upstreams.Balancing).Debug("Using a load balancing algorithm")
 	balancerInstance, err := balancer.New(definition.Upstreams.Balancing)
 	if err != nil {
 		log.WithError(err).Error("Could not create a balancer")
-		return fmt.Errorf("could not create a balancer: %w", err)
+		return nil, fmt.Errorf("could not create a balancer: %w", err)
 	}
 
 	handler := NewBalancedReverseProxy(definition.Definition, balancerInstance, p.statsClient)
@@ -71,12 +104,7 @@ func (p *Register) Add(definition *RouterDefinition) error {
 		),
 	}
 
-	if p.matcher.Match(definition.ListenPath) {
-		p.doRegister(p.matcher.Extract(definition.ListenPath), definition, &ochttp.Handler{Handler: handler, IsPublicEndpoint: p.isPublicEndpoint})
-	}
-
-	p.doRegister(definition.ListenPath, definition, &ochttp.Handler{Handler: handler, IsPublicEndpoint: p.isPublicEndpoint})
-	return nil
+	return withTryTimeout(handler, time.Duration(definition.ForwardingTimeouts.TryTimeout)), nil
 }
 
 func (p *Register) doRegister(listenPath string, def *RouterDefinition, handler http.Handler) {
@@ -87,13 +115,30 @@ func (p *Register) doRegister(listenPath string, def *RouterDefinition, handler
 	if strings.Index(listenPath, "/") != 0 {
 		log.WithField("listen_path", listenPath).
 			Error("Route listen path must begin with '/'. Skipping invalid route.")
-	} else {
-		for _, method := range def.Methods {
-			if strings.ToUpper(method) == methodAll {
-				p.router.Any(listenPath, handler.ServeHTTP, def.middleware...)
-			} else {
-				p.router.Handle(strings.ToUpper(method), listenPath, handler.ServeHTTP, def.middleware...)
-			}
+		return
+	}
+
+	rt, err := newRoute(def, handler)
+	if err != nil {
+		log.WithError(err).WithField("listen_path", listenPath).Error("Invalid match rules. Skipping invalid route.")
+		return
+	}
+
+	patterns := make([]*hostPattern, 0, len(def.Hosts))
+	for _, host := range def.Hosts {
+		pattern, err := parseHostPattern(host)
+		if err != nil {
+			log.WithError(err).WithField("listen_path", listenPath).Error("Invalid host. Skipping invalid route.")
+			return
 		}
+		patterns = append(patterns, pattern)
+	}
+	if len(patterns) == 0 {
+		patterns = append(patterns, nil)
+	}
+
+	// The definitions sharing a host and a listen path are registered with the same group, that picks the one to use
+	for _, pattern := range patterns {
+		p.hosts.tree(pattern).group(listenPath, def.Methods).add(rt)
 	}
 }
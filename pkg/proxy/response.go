@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"github.com/go-chi/chi"
+	log "github.com/sirupsen/logrus"
+)
+
+// Response is a canned response served by Janus itself, as a mock of an API without upstreams
+// or as the fallback of an API whose upstreams are down
+type Response struct {
+	StatusCode int               `bson:"status_code" json:"status_code"`
+	Headers    map[string]string `bson:"headers" json:"headers"`
+	Body       string            `bson:"body" json:"body"`
+	// Template executes the headers and the body as Go templates, with the request and its path params
+	Template bool `bson:"template" json:"template"`
+}
+
+// responseData is what the templates of a response have access to
+type responseData struct {
+	Request *http.Request
+	Params  map[string]string
+}
+
+// Validate checks the templates of the response
+func (r *Response) Validate() error {
+	_, err := NewResponseHandler(r)
+	return err
+}
+
+// NewResponseHandler creates a handler that serves the response, parsing its templates once
+func NewResponseHandler(resp *Response) (http.Handler, error) {
+	statusCode := resp.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	if !resp.Template {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for name, value := range resp.Headers {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(statusCode)
+			w.Write([]byte(resp.Body))
+		}), nil
+	}
+
+	headers := make(map[string]*template.Template, len(resp.Headers))
+	for name, value := range resp.Headers {
+		tpl, err := template.New(name).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the template of the %s header: %w", name, err)
+		}
+		headers[name] = tpl
+	}
+
+	body, err := template.New("body").Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the template of the body: %w", err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := responseData{Request: r, Params: urlParams(r)}
+
+		var buf bytes.Buffer
+		for name, tpl := range headers {
+			buf.Reset()
+			if err := tpl.Execute(&buf, data); err != nil {
+				log.WithError(err).WithField("header", name).Error("Could not execute the template of the response header")
+				continue
+			}
+			w.Header().Set(name, buf.String())
+		}
+
+		buf.Reset()
+		if err := body.Execute(&buf, data); err != nil {
+			log.WithError(err).Error("Could not execute the template of the response body")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(statusCode)
+		w.Write(buf.Bytes())
+	}), nil
+}
+
+func urlParams(r *http.Request) map[string]string {
+	params := make(map[string]string)
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return params
+	}
+
+	for i, key := range rctx.URLParams.Keys {
+		if key != "*" {
+			params[key] = rctx.URLParams.Values[i]
+		}
+	}
+	return params
+}
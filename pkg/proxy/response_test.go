@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/hellofresh/stats-go/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+func TestStaticResponse(t *testing.T) {
+	handler, err := NewResponseHandler(&Response{
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    `{"status":"ok"}`,
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestTemplatedResponse(t *testing.T) {
+	handler, err := NewResponseHandler(&Response{
+		StatusCode: http.StatusGone,
+		Headers:    map[string]string{"Link": `<https://example.com/v2/recipes/{{.Params.id}}>; rel="successor-version"`},
+		Body:       `{"id":"{{.Params.id}}","method":"{{.Request.Method}}","lang":"{{.Request.URL.Query.Get "lang"}}"}`,
+		Template:   true,
+	})
+	require.NoError(t, err)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "42")
+	r := httptest.NewRequest(http.MethodGet, "/recipes/42?lang=en", nil)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Equal(t, `<https://example.com/v2/recipes/42>; rel="successor-version"`, w.Header().Get("Link"))
+	assert.JSONEq(t, `{"id":"42","method":"GET","lang":"en"}`, w.Body.String())
+}
+
+func TestResponseValidation(t *testing.T) {
+	assert.NoError(t, (&Response{Body: "{{.Params"}).Validate())
+	assert.Error(t, (&Response{Body: "{{.Params", Template: true}).Validate())
+	assert.Error(t, (&Response{Headers: map[string]string{"X-Id": "{{end}}"}, Template: true}).Validate())
+
+	def := NewDefinition()
+	def.ListenPath = "/mock"
+	def.Response = &Response{Body: "{{", Template: true}
+	isValid, err := def.Validate()
+	assert.False(t, isValid)
+	assert.Error(t, err)
+}
+
+func TestUpstreamFallback(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	upstream.Close()
+
+	def := NewDefinition()
+	def.ListenPath = "/"
+	def.Upstreams.Targets = Targets{{Target: upstream.URL}}
+	def.Fallback = &Response{
+		Headers: map[string]string{"Warning": `110 janus "Response is Stale"`},
+		Body:    "[]",
+	}
+	b, err := balancer.New("roundrobin")
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	NewBalancedReverseProxy(def, b, client.NewNoop()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `110 janus "Response is Stale"`, w.Header().Get("Warning"))
+	assert.Equal(t, "[]", w.Body.String())
+}
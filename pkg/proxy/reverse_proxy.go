@@ -27,7 +27,8 @@ const (
 // NewBalancedReverseProxy creates a reverse proxy that is load balanced
 func NewBalancedReverseProxy(def *Definition, balancer balancer.Balancer, statsClient client.Client) *httputil.ReverseProxy {
 	return &httputil.ReverseProxy{
-		Director: createDirector(def, balancer, statsClient),
+		Director:     createDirector(def, balancer, statsClient),
+		ErrorHandler: newErrorHandler(def.Fallback),
 	}
 }
 
@@ -35,13 +36,33 @@ func createDirector(proxyDefinition *Definition, balancer balancer.Balancer, sta
 	paramNameExtractor := router.NewListenPathParamNameExtractor()
 	matcher := router.NewListenPathMatcher()
 
+	rewriter, err := newRewriter(proxyDefinition.Rewrite)
+	if err != nil {
+		log.WithError(err).WithField("listen_path", proxyDefinition.ListenPath).Error("Could not create the rewrite rules, the paths are not rewritten")
+		rewriter = &pathRewriter{}
+	}
+
 	return func(req *http.Request) {
-		upstream, err := balancer.Elect(proxyDefinition.Upstreams.Targets.ToBalancerTargets())
+		targets := proxyDefinition.Upstreams.Targets.ToBalancerTargets()
+		selectors := selectorsFromContext(req.Context())
+		for _, selector := range selectors {
+			targets = selector.Select(targets)
+		}
+
+		upstream, err := balancer.Elect(targets)
 		if err != nil {
 			log.WithError(err).Error("Could not elect one upstream")
 			return
 		}
 
+		for _, selector := range selectors {
+			if rejection := selector.Elected(upstream.Target); rejection != nil {
+				log.WithField("target", upstream.Target).WithError(rejection).Debug("Request rejected")
+				reject(req, rejection)
+				return
+			}
+		}
+
 		targetURL := upstream.Target
 
 		paramNames := paramNameExtractor.Extract(targetURL)
@@ -66,24 +87,23 @@ func createDirector(proxyDefinition *Definition, balancer balancer.Balancer, sta
 		req.URL.Host = target.Host
 		path := target.Path
 
-		if proxyDefinition.AppendPath {
-			log.Debug("Appending listen path to the target url")
-			path = singleJoiningSlash(target.Path, req.URL.Path)
-		}
-
-		if proxyDefinition.StripPath {
-			path = singleJoiningSlash(target.Path, req.URL.Path)
+		if rewritten, query, ok := rewriter.rewrite(req.URL.Path); ok {
+			log.WithField("rewritten_path", rewritten).Debug("Rewriting the path")
+			path = singleJoiningSlash(target.Path, rewritten)
+			if query != "" {
+				req.URL.RawQuery = joinQuery(req.URL.RawQuery, query)
+			}
+		} else if proxyDefinition.StripPath {
 			listenPath := matcher.Extract(proxyDefinition.ListenPath)
 
 			log.WithField("listen_path", listenPath).Debug("Stripping listen path")
-			if len(paramNames) > 0 {
-				path = stripPathWithParams(req, path, listenPath, paramNames)
-			} else {
-				path = strings.Replace(path, listenPath, "", 1)
-			}
+			path = singleJoiningSlash(target.Path, stripListenPath(req, req.URL.Path, listenPath))
 			if !strings.HasSuffix(target.Path, "/") && strings.HasSuffix(path, "/") {
 				path = path[:len(path)-1]
 			}
+		} else if proxyDefinition.AppendPath {
+			log.Debug("Appending listen path to the target url")
+			path = singleJoiningSlash(target.Path, req.URL.Path)
 		}
 
 		log.WithField("path", path).Debug("Upstream Path")
@@ -96,11 +116,7 @@ func createDirector(proxyDefinition *Definition, balancer balancer.Balancer, sta
 			req.Host = target.Host
 		}
 
-		if targetQuery == "" || req.URL.RawQuery == "" {
-			req.URL.RawQuery = targetQuery + req.URL.RawQuery
-		} else {
-			req.URL.RawQuery = targetQuery + "&" + req.URL.RawQuery
-		}
+		req.URL.RawQuery = joinQuery(targetQuery, req.URL.RawQuery)
 
 		// Since director modifies cloned request there is no way (or I just did not find one)
 		// to get upstream from logger middleware, so we're logging original request and upstream here
@@ -115,6 +131,8 @@ func createDirector(proxyDefinition *Definition, balancer balancer.Balancer, sta
 
 		statsClient.TrackMetric(statsSection, bucket.MetricOperation{req.Host})
 
+		propagateDeadline(req)
+
 		// Add additional trace attributes
 		addTraceAttributes(req)
 
@@ -188,28 +206,58 @@ func cleanSlashes(a string) string {
 	startSlash := strings.HasPrefix(a, "//")
 
 	if startSlash {
-		a = "/" + strings.TrimPrefix(a, "//")
+		a = "/" + strings.TrimLeft(a, "/")
 	}
 
 	if endSlash {
-		a = strings.TrimSuffix(a, "//") + "/"
+		a = strings.TrimRight(a, "/") + "/"
 	}
 
 	return a
 }
 
+func joinQuery(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "&" + b
+}
+
 // chiURLParam is created to allow for mocking of the chi.URLParam function.
 // This allowed for writing a quick unit test to check that the logic of the function works without having to deal with chi's context requirements.
 var chiURLParam = chi.URLParam
-// stripPathWithParams is intended to properly strip the listen path from the requested path when named parameters are used.
-// From left to right, it removes the first instance of each section of the listenPath and each paramName from the path.
-func stripPathWithParams(req *http.Request, path string, listenPath string, paramNames []string) string {
-	remove := strings.Split(listenPath, "/")
-	for i := 0; i < len(paramNames); i ++ {
-		remove = append(remove, chiURLParam(req, paramNames[i]))
+
+// stripListenPath removes the segments of the listen path from the beginning of the requested path.
+// Named parameters of the listen path match the segment holding their value, the stripping stops
+// at the first segment of the path that doesn't match the listen path.
+func stripListenPath(req *http.Request, path string, listenPath string) string {
+	listenPath = strings.Trim(listenPath, "/")
+	if listenPath == "" {
+		return path
+	}
+
+	listenSegments := strings.Split(listenPath, "/")
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	n := 0
+	for n < len(listenSegments) && n < len(segments) && segmentMatches(req, listenSegments[n], segments[n]) {
+		n++
+	}
+
+	if n == len(segments) {
+		return ""
 	}
-	for i := 1; i < len(remove); i++ {
-		path = strings.Replace(path, "/" + remove[i], "", 1)
+	return "/" + strings.Join(segments[n:], "/")
+}
+
+func segmentMatches(req *http.Request, listenSegment string, segment string) bool {
+	if !strings.HasPrefix(listenSegment, "{") || !strings.HasSuffix(listenSegment, "}") {
+		return listenSegment == segment
+	}
+
+	name := strings.Trim(listenSegment, "{}")
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[:i]
 	}
-	return path
+	return chiURLParam(req, name) == segment
 }
@@ -1,9 +1,15 @@
 package proxy
 
 import (
-	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/hellofresh/stats-go/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
 )
 
 func newTestRequest() *http.Request {
@@ -31,20 +37,19 @@ func newTestRequest() *http.Request {
 		Response:         nil,
 	}
 }
-func TestStripPathWithParams(t *testing.T) {
+func TestStripListenPath(t *testing.T) {
 	t.Run("properly strips path - params and listenPath", func(t *testing.T) {
 		req := newTestRequest()
 		path := "/prepath/my-service/endpoint"
 		listenPath := "/prepath/{service}/*"
-		paramNames := []string{"service"}
 
 		old := chiURLParam
-		defer func() {chiURLParam = old}()
+		defer func() { chiURLParam = old }()
 
 		chiURLParam = func(r *http.Request, key string) string {
 			return "my-service"
 		}
-		returnPath := stripPathWithParams(req, path, listenPath, paramNames)
+		returnPath := stripListenPath(req, path, listenPath)
 
 		assert.Equal(t, "/endpoint", returnPath)
 	})
@@ -53,16 +58,157 @@ func TestStripPathWithParams(t *testing.T) {
 		req := newTestRequest()
 		path := "/prepath/my-service/endpoint"
 		listenPath := "/prepath/{service}/*"
-		paramNames := []string{"service"}
 
 		old := chiURLParam
-		defer func() {chiURLParam = old}()
+		defer func() { chiURLParam = old }()
 
 		chiURLParam = func(r *http.Request, key string) string {
 			return "other-value"
 		}
-		returnPath := stripPathWithParams(req, path, listenPath, paramNames)
+		returnPath := stripListenPath(req, path, listenPath)
 
 		assert.Equal(t, "/my-service/endpoint", returnPath)
 	})
+
+	t.Run("only strips whole segments", func(t *testing.T) {
+		req := newTestRequest()
+
+		old := chiURLParam
+		defer func() { chiURLParam = old }()
+
+		chiURLParam = func(r *http.Request, key string) string {
+			return "users"
+		}
+
+		assert.Equal(t, "/users/1", stripListenPath(req, "/users/users/1", "/{service}"))
+		assert.Equal(t, "/my-service/endpoint", stripListenPath(req, "/prepath/my-service/endpoint", "/prepath/my"))
+		assert.Equal(t, "/users/1", stripListenPath(req, "/api/users/users/1", "/api/{service:[a-z]+}/*"))
+		assert.Equal(t, "", stripListenPath(req, "/api", "/api"))
+		assert.Equal(t, "/api/users", stripListenPath(req, "/api/users", "/"))
+	})
+}
+
+func TestSingleJoiningSlash(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{a: "", b: "", expected: ""},
+		{a: "", b: "/b", expected: "/b"},
+		{a: "", b: "b", expected: "/b"},
+		{a: "/a", b: "", expected: "/a"},
+		{a: "/a/", b: "", expected: "/a/"},
+		{a: "/a", b: "b", expected: "/a/b"},
+		{a: "/a", b: "/b", expected: "/a/b"},
+		{a: "/a/", b: "b", expected: "/a/b"},
+		{a: "/a/", b: "/b", expected: "/a/b"},
+		{a: "//a//", b: "//b", expected: "/a/b"},
+		{a: "/a", b: "/b/", expected: "/a/b/"},
+		{a: "/a", b: "/b//", expected: "/a/b/"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, singleJoiningSlash(tt.a, tt.b), "%q + %q", tt.a, tt.b)
+	}
+}
+
+func TestCleanSlashes(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "", expected: ""},
+		{path: "/", expected: "/"},
+		{path: "//", expected: "/"},
+		{path: "///", expected: "/"},
+		{path: "//a", expected: "/a"},
+		{path: "///a", expected: "/a"},
+		{path: "a//", expected: "a/"},
+		{path: "a///", expected: "a/"},
+		{path: "/a//b/", expected: "/a//b/"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, cleanSlashes(tt.path), tt.path)
+	}
+}
+
+func TestDirectorPaths(t *testing.T) {
+	tests := []struct {
+		scenario   string
+		def        func(def *Definition)
+		target     string
+		path       string
+		expected   string
+		expectedRQ string
+	}{
+		{
+			scenario: "target path only",
+			target:   "http://upstream/api",
+			path:     "/service/users",
+			expected: "/api",
+		},
+		{
+			scenario: "append path",
+			def:      func(def *Definition) { def.AppendPath = true },
+			target:   "http://upstream/api",
+			path:     "/service/users",
+			expected: "/api/service/users",
+		},
+		{
+			scenario: "strip path",
+			def:      func(def *Definition) { def.StripPath = true },
+			target:   "http://upstream/api",
+			path:     "/service/users",
+			expected: "/api/users",
+		},
+		{
+			scenario: "strip path keeps the listen path in the rest of the path",
+			def:      func(def *Definition) { def.StripPath = true },
+			target:   "http://upstream/service",
+			path:     "/service/service/users",
+			expected: "/service/service/users",
+		},
+		{
+			scenario: "rewrite takes precedence over strip path",
+			def: func(def *Definition) {
+				def.StripPath = true
+				def.Rewrite = []RewriteRule{{Regex: `^/service/users/(\d+)/orders`, To: "/orders?user=$1"}}
+			},
+			target:     "http://upstream/api?version=2",
+			path:       "/service/users/42/orders/7?page=1",
+			expected:   "/api/orders/7",
+			expectedRQ: "version=2&page=1&user=42",
+		},
+		{
+			scenario: "strip path when no rewrite rule matches",
+			def: func(def *Definition) {
+				def.StripPath = true
+				def.Rewrite = []RewriteRule{{Exact: "/service/health", To: "/status"}}
+			},
+			target:   "http://upstream/",
+			path:     "/service/users",
+			expected: "/users",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			def := NewDefinition()
+			def.ListenPath = "/service/*"
+			def.Upstreams.Targets = Targets{{Target: tt.target}}
+			if tt.def != nil {
+				tt.def(def)
+			}
+
+			b, err := balancer.New("roundrobin")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			createDirector(def, b, client.NewNoop())(req)
+
+			assert.Equal(t, tt.expected, req.URL.Path)
+			assert.Equal(t, tt.expectedRQ, req.URL.RawQuery)
+		})
+	}
 }
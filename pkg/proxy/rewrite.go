@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RewriteRule rewrites the path of the requests matching it, before it is joined to the path of the upstream target.
+// A rule matches the path with one of Regex, Exact or Prefix.
+type RewriteRule struct {
+	// Regex matches a part of the path, the rest of the path is kept around the replacement
+	Regex string `bson:"regex" json:"regex,omitempty"`
+	// Exact matches the whole path
+	Exact string `bson:"exact" json:"exact,omitempty"`
+	// Prefix matches the beginning of the path, the rest of the path is kept after the replacement
+	Prefix string `bson:"prefix" json:"prefix,omitempty"`
+	// To is the replacement of the matched path. It can have a query string,
+	// and refer to the capture groups of the regex with $1 or ${name}.
+	To string `bson:"to" json:"to"`
+}
+
+type rewriteRule struct {
+	RewriteRule
+	regex *regexp.Regexp
+}
+
+// pathRewriter applies the first rewrite rule that matches a path
+type pathRewriter struct {
+	rules []rewriteRule
+}
+
+func newRewriter(rules []RewriteRule) (*pathRewriter, error) {
+	r := &pathRewriter{rules: make([]rewriteRule, 0, len(rules))}
+
+	for i, rule := range rules {
+		matchers := 0
+		for _, m := range []string{rule.Regex, rule.Exact, rule.Prefix} {
+			if m != "" {
+				matchers++
+			}
+		}
+		if matchers != 1 {
+			return nil, fmt.Errorf("rewrite rule %d must have one of regex, exact or prefix", i)
+		}
+		if rule.Regex == "" && !strings.HasPrefix(rule.To, "/") {
+			return nil, fmt.Errorf("rewrite rule %d must rewrite to a path beginning with '/'", i)
+		}
+
+		compiled := rewriteRule{RewriteRule: rule}
+		if rule.Regex != "" {
+			regex, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("rewrite rule %d: %w", i, err)
+			}
+			compiled.regex = regex
+		}
+
+		r.rules = append(r.rules, compiled)
+	}
+
+	return r, nil
+}
+
+// rewrite returns the rewritten path and the query string of the replacement,
+// ok is false when none of the rules match the path
+func (r *pathRewriter) rewrite(path string) (rewritten string, query string, ok bool) {
+	for _, rule := range r.rules {
+		var before, replacement, rest string
+
+		switch {
+		case rule.regex != nil:
+			match := rule.regex.FindStringSubmatchIndex(path)
+			if match == nil {
+				continue
+			}
+			replacement = string(rule.regex.ExpandString(nil, rule.To, path, match))
+			before, rest = path[:match[0]], path[match[1]:]
+		case rule.Exact != "":
+			if path != rule.Exact {
+				continue
+			}
+			replacement = rule.To
+		default:
+			if !strings.HasPrefix(path, rule.Prefix) {
+				continue
+			}
+			replacement = rule.To
+			rest = path[len(rule.Prefix):]
+		}
+
+		if i := strings.IndexByte(replacement, '?'); i >= 0 {
+			replacement, query = replacement[:i], replacement[i+1:]
+		}
+
+		return singleJoiningSlash(before+replacement, rest), query, true
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewrite(t *testing.T) {
+	rewriter, err := newRewriter([]RewriteRule{
+		{Exact: "/health", To: "/status"},
+		{Regex: `^/v1/users/(\d+)/orders`, To: "/orders?user=$1"},
+		{Regex: `^/v1/(?P<resource>[a-z]+)/(?P<id>\d+)$`, To: "/${resource}?id=${id}"},
+		{Prefix: "/v1/", To: "/api/"},
+		{Regex: `/legacy-`, To: "/"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		path     string
+		expected string
+		query    string
+		ok       bool
+	}{
+		{path: "/health", expected: "/status", ok: true},
+		{path: "/health/deep", ok: false},
+		{path: "/v1/users/42/orders", expected: "/orders", query: "user=42", ok: true},
+		{path: "/v1/users/42/orders/7", expected: "/orders/7", query: "user=42", ok: true},
+		{path: "/v1/recipes/5", expected: "/recipes", query: "id=5", ok: true},
+		{path: "/v1/recipes/5/steps", expected: "/api/recipes/5/steps", ok: true},
+		{path: "/menus/legacy-weeks", expected: "/menus/weeks", ok: true},
+		{path: "/v2/users", ok: false},
+	}
+
+	for _, tt := range tests {
+		rewritten, query, ok := rewriter.rewrite(tt.path)
+		assert.Equal(t, tt.ok, ok, tt.path)
+		if tt.ok {
+			assert.Equal(t, tt.expected, rewritten, tt.path)
+			assert.Equal(t, tt.query, query, tt.path)
+		}
+	}
+}
+
+func TestRewriteValidation(t *testing.T) {
+	for _, rules := range [][]RewriteRule{
+		{{To: "/status"}},
+		{{Exact: "/health", Prefix: "/health", To: "/status"}},
+		{{Regex: "^/v1/(", To: "/"}},
+		{{Prefix: "/v1", To: "v2"}},
+	} {
+		_, err := newRewriter(rules)
+		assert.Error(t, err, "%+v", rules)
+	}
+
+	def := NewDefinition()
+	def.ListenPath = "/v1/*"
+	def.Rewrite = []RewriteRule{{Regex: "(", To: "/"}}
+	isValid, err := def.Validate()
+	assert.False(t, isValid)
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hellofresh/janus/pkg/errors"
+)
+
+// route is a definition registered on a listen path, with its middleware chain
+type route struct {
+	methods  map[string]bool
+	matcher  *requestMatcher
+	priority int
+	handler  http.Handler
+}
+
+func newRoute(def *RouterDefinition, handler http.Handler) (*route, error) {
+	matcher, err := newRequestMatcher(def.Match)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(def.middleware) - 1; i >= 0; i-- {
+		handler = def.middleware[i](handler)
+	}
+
+	methods := make(map[string]bool, len(def.Methods))
+	for _, method := range def.Methods {
+		methods[strings.ToUpper(method)] = true
+	}
+
+	return &route{methods: methods, matcher: matcher, priority: def.Match.Priority, handler: handler}, nil
+}
+
+func (rt *route) accepts(r *http.Request) bool {
+	return (rt.methods[methodAll] || rt.methods[r.Method]) && rt.matcher.Matches(r)
+}
+
+// routeGroup dispatches the requests of a listen path to the first of its routes they match
+type routeGroup struct {
+	sync.RWMutex
+	routes []*route
+}
+
+// add inserts the route after the ones with a higher priority or more match rules
+func (g *routeGroup) add(rt *route) {
+	g.Lock()
+	defer g.Unlock()
+
+	routes := append(append(make([]*route, 0, len(g.routes)+1), g.routes...), rt)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].priority != routes[j].priority {
+			return routes[i].priority > routes[j].priority
+		}
+		return len(routes[i].matcher.rules) > len(routes[j].matcher.rules)
+	})
+	g.routes = routes
+}
+
+func (g *routeGroup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.RLock()
+	routes := g.routes
+	g.RUnlock()
+
+	for _, rt := range routes {
+		if rt.accepts(r) {
+			rt.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	errors.Handler(w, r, errors.ErrRouteNotFound)
+}
@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/router"
+)
+
+func mockDefinition(body string, methods []string, match Match) *RouterDefinition {
+	def := NewDefinition()
+	def.ListenPath = "/recipes"
+	def.Methods = methods
+	def.Match = match
+	def.Response = &Response{Body: body}
+	return NewRouterDefinition(def)
+}
+
+func TestSharedListenPath(t *testing.T) {
+	r := router.NewChiRouter()
+	register := NewRegister(WithRouter(r))
+
+	v2 := Match{Headers: []MatchRule{{Name: "Accept", Value: "application/vnd.v2+json"}}}
+	beta := Match{Cookies: []MatchRule{{Name: "beta"}}, Priority: 10}
+
+	require.NoError(t, register.Add(mockDefinition("v1", []string{"GET", "POST"}, Match{})))
+	require.NoError(t, register.Add(mockDefinition("v2", []string{"GET"}, v2)))
+	require.NoError(t, register.Add(mockDefinition("beta", []string{"ALL"}, beta)))
+
+	tests := []struct {
+		scenario string
+		method   string
+		accept   string
+		beta     bool
+		expected string
+	}{
+		{scenario: "no match rules", method: http.MethodGet, expected: "v1"},
+		{scenario: "header match", method: http.MethodGet, accept: "application/vnd.v2+json", expected: "v2"},
+		{scenario: "method of the matched route only", method: http.MethodPost, accept: "application/vnd.v2+json", expected: "v1"},
+		{scenario: "priority", method: http.MethodGet, accept: "application/vnd.v2+json", beta: true, expected: "beta"},
+		{scenario: "methods of all the routes", method: http.MethodDelete, beta: true, expected: "beta"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scenario, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/recipes", nil)
+			req.Header.Set("Accept", tt.accept)
+			if tt.beta {
+				req.AddCookie(&http.Cookie{Name: "beta", Value: "1"})
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/recipes", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	r := router.NewChiRouter()
+	register := NewRegister(WithRouter(r))
+
+	def := mockDefinition("v1", []string{"GET"}, Match{})
+	def.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-First", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	def.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Second", w.Header().Get("X-First")+"2")
+			next.ServeHTTP(w, r)
+		})
+	})
+	require.NoError(t, register.Add(def))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recipes", nil))
+	assert.Equal(t, "12", w.Header().Get("X-Second"))
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+type selectorsKeyType int
+
+const (
+	selectorsKey selectorsKeyType = iota
+	rejectionKey
+)
+
+// TargetSelector lets the middlewares of a request narrow down the upstream targets the balancer elects from,
+// and tells them which target was elected
+type TargetSelector interface {
+	// Select returns the targets the request can be sent to
+	Select(targets []*balancer.Target) []*balancer.Target
+	// Elected is called with the target the request is sent to, the request is rejected when it returns a Rejection
+	Elected(target string) *Rejection
+}
+
+// Rejection keeps a request from being sent to the upstream target, Handler responds to it instead
+type Rejection struct {
+	Reason  string
+	Handler http.Handler
+}
+
+func (r *Rejection) Error() string {
+	return r.Reason
+}
+
+// reject marks the request as rejected, the reverse proxy can't send it without a host and responds with the rejection
+func reject(req *http.Request, rejection *Rejection) {
+	*req = *req.WithContext(context.WithValue(req.Context(), rejectionKey, rejection))
+	req.URL.Host = ""
+}
+
+func rejectionFromContext(ctx context.Context) (*Rejection, bool) {
+	rejection, ok := ctx.Value(rejectionKey).(*Rejection)
+	return rejection, ok
+}
+
+// WithTargetSelector adds a TargetSelector to the context, the selectors are applied in the order they were added
+func WithTargetSelector(ctx context.Context, selector TargetSelector) context.Context {
+	selectors, _ := ctx.Value(selectorsKey).([]TargetSelector)
+	return context.WithValue(ctx, selectorsKey, append(selectors[:len(selectors):len(selectors)], selector))
+}
+
+func selectorsFromContext(ctx context.Context) []TargetSelector {
+	selectors, _ := ctx.Value(selectorsKey).([]TargetSelector)
+	return selectors
+}
+
+// Attempts records the upstream targets a request was sent to,
+// so that the next attempts of the request are sent to the other targets
+type Attempts struct {
+	sync.Mutex
+	targets []string
+}
+
+// WithAttempts puts a new Attempts to the context, the reverse proxy records the targets it elects in it
+func WithAttempts(ctx context.Context) (context.Context, *Attempts) {
+	attempts := &Attempts{}
+	return WithTargetSelector(ctx, attempts), attempts
+}
+
+// Targets returns the targets of the previous attempts
+func (a *Attempts) Targets() []string {
+	a.Lock()
+	defer a.Unlock()
+
+	return append([]string(nil), a.targets...)
+}
+
+// Elected records the target of an attempt
+func (a *Attempts) Elected(target string) *Rejection {
+	a.Lock()
+	defer a.Unlock()
+
+	a.targets = append(a.targets, target)
+	return nil
+}
+
+// Select filters out the targets of the previous attempts, all the targets being returned once all were tried
+func (a *Attempts) Select(targets []*balancer.Target) []*balancer.Target {
+	a.Lock()
+	defer a.Unlock()
+
+	tried := make(map[string]bool, len(a.targets))
+	for _, target := range a.targets {
+		tried[target] = true
+	}
+
+	result := make([]*balancer.Target, 0, len(targets))
+	for _, target := range targets {
+		if !tried[target.Target] {
+			result = append(result, target)
+		}
+	}
+
+	if len(result) == 0 {
+		return targets
+	}
+	return result
+}
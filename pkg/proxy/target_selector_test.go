@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hellofresh/stats-go/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hellofresh/janus/pkg/proxy/balancer"
+)
+
+func TestAttemptsUntried(t *testing.T) {
+	targets := []*balancer.Target{{Target: "http://a"}, {Target: "http://b"}}
+
+	ctx, attempts := WithAttempts(context.Background())
+	assert.Equal(t, []TargetSelector{attempts}, selectorsFromContext(ctx))
+
+	assert.Equal(t, targets, attempts.Select(targets))
+
+	attempts.Elected("http://a")
+	assert.Equal(t, targets[1:], attempts.Select(targets))
+
+	// all the targets are available again once they were all tried
+	attempts.Elected("http://b")
+	assert.Equal(t, targets, attempts.Select(targets))
+	assert.Equal(t, []string{"http://a", "http://b"}, attempts.Targets())
+}
+
+// rejectingSelector rejects the requests sent to its target
+type rejectingSelector struct {
+	target string
+}
+
+func (s rejectingSelector) Select(targets []*balancer.Target) []*balancer.Target {
+	return targets
+}
+
+func (s rejectingSelector) Elected(target string) *Rejection {
+	if target != s.target {
+		return nil
+	}
+	return &Rejection{Reason: "rejected", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})}
+}
+
+func TestRejectedRequest(t *testing.T) {
+	hits := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer upstream.Close()
+
+	def := NewDefinition()
+	def.Upstreams.Targets = Targets{{Target: upstream.URL}}
+	b, err := balancer.New("roundrobin")
+	require.NoError(t, err)
+	handler := NewBalancedReverseProxy(def, b, client.NewNoop())
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(w, r.WithContext(WithTargetSelector(r.Context(), rejectingSelector{target: upstream.URL})))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, 0, hits)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r.WithContext(WithTargetSelector(r.Context(), rejectingSelector{target: "http://other"})))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, hits)
+}
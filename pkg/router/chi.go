@@ -118,6 +118,11 @@ func (r *ChiRouter) RoutesCount() int {
 	return r.routesCount(r.mux)
 }
 
+// Match checks whether a route is registered for the method and path, without serving the request
+func (r *ChiRouter) Match(method string, path string) bool {
+	return r.mux.Match(chi.NewRouteContext(), method, path)
+}
+
 func (r *ChiRouter) routesCount(routes chi.Routes) int {
 	count := len(routes.Routes())
 	for _, route := range routes.Routes() {
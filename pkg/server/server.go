@@ -12,6 +12,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"go.opencensus.io/plugin/ochttp/propagation/b3"
 
+	"github.com/hellofresh/janus/pkg/admission"
 	"github.com/hellofresh/janus/pkg/api"
 	"github.com/hellofresh/janus/pkg/config"
 	"github.com/hellofresh/janus/pkg/errors"
@@ -37,6 +38,7 @@ type Server struct {
 	webServer             *web.Server
 	profilingEnabled      bool
 	profilingPublic       bool
+	admission             *admission.Controller
 }
 
 // New creates a new instance of Server
@@ -72,6 +74,14 @@ func (s *Server) StartWithContext(ctx context.Context) error {
 		log.Info("Stopping server gracefully")
 	}()
 
+	if s.globalConfig.Admission.Enabled {
+		controller, err := admission.NewController(s.globalConfig.Admission)
+		if err != nil {
+			return fmt.Errorf("could not create the admission controller: %w", err)
+		}
+		s.admission = controller
+	}
+
 	// Register must be initialised synchronously to avoid race condition
 	r := s.createRouter()
 	s.register = proxy.NewRegister(
@@ -85,7 +95,7 @@ func (s *Server) StartWithContext(ctx context.Context) error {
 	)
 
 	// API Loader must be initialised synchronously as well to avoid race condition
-	s.apiLoader = loader.NewAPILoader(s.register)
+	s.apiLoader = loader.NewAPILoader(s.register, s.admission)
 
 	go func() {
 		if err := s.startHTTPServers(ctx, r); err != nil {
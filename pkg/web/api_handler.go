@@ -3,16 +3,24 @@ package web
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 
 	"github.com/hellofresh/janus/pkg/api"
 	"github.com/hellofresh/janus/pkg/errors"
+	"github.com/hellofresh/janus/pkg/openapi"
 	"github.com/hellofresh/janus/pkg/plugin"
 	"github.com/hellofresh/janus/pkg/render"
 	"github.com/hellofresh/janus/pkg/router"
 	"go.opencensus.io/trace"
 )
 
+// maxOpenAPISize is the size of the largest OpenAPI document the import endpoint reads
+const maxOpenAPISize = 10 << 20
+
+// ErrOpenAPITooLarge is returned when the OpenAPI document to import is larger than maxOpenAPISize
+var ErrOpenAPITooLarge = errors.New(http.StatusRequestEntityTooLarge, "the OpenAPI document is too large")
+
 // APIHandler is the api rest controller
 type APIHandler struct {
 	configurationChan chan<- api.ConfigurationMessage
@@ -98,7 +106,7 @@ func (c *APIHandler) PutBy() http.HandlerFunc {
 		// avoid situation when trying to update existing definition with new path
 		// that is already registered with another name
 		_, span = trace.StartSpan(r.Context(), "repo.FindByListenPath")
-		existingCfg := c.findByListenPath(cfg.Proxy.ListenPath)
+		existingCfg := c.findByRoute(cfg)
 		span.End()
 
 		if existingCfg != nil && existingCfg.Name != cfg.Name {
@@ -164,6 +172,89 @@ func (c *APIHandler) Post() http.HandlerFunc {
 	}
 }
 
+// ImportOpenAPI is the handler creating the definitions of the paths of the OpenAPI document in the body
+func (c *APIHandler) ImportOpenAPI() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxOpenAPISize {
+			errors.Handler(w, r, ErrOpenAPITooLarge)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxOpenAPISize))
+		if err != nil {
+			// MaxBytesReader fails once it has given all the bytes it allows
+			if len(body) == maxOpenAPISize {
+				err = ErrOpenAPITooLarge
+			}
+			errors.Handler(w, r, err)
+			return
+		}
+
+		swagger, err := openapi.Load(body)
+		if err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		query := r.URL.Query()
+		defs, warnings, err := openapi.Import(swagger, openapi.Options{
+			Name:             query.Get("name"),
+			ListenPathPrefix: query.Get("listen_path_prefix"),
+			Upstream:         query.Get("upstream"),
+			Validate:         query.Get("validate") == "true",
+			Auth:             query.Get("auth") == "true",
+			OAuthServer:      query.Get("oauth_server"),
+		})
+		if err != nil {
+			errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+			return
+		}
+
+		// None of the definitions are added when one of them can't be
+		_, span := trace.StartSpan(r.Context(), "definitions.Exists")
+		for _, cfg := range defs {
+			for _, plg := range cfg.Plugins {
+				isValid, err := plugin.ValidateConfig(plg.Name, plg.Config)
+				if !isValid || err != nil {
+					span.End()
+					errors.Handler(w, r, errors.New(http.StatusBadRequest, err.Error()))
+					return
+				}
+			}
+
+			if exists, err := c.exists(cfg); err != nil || exists {
+				span.End()
+				errors.Handler(w, r, err)
+				return
+			}
+		}
+		span.End()
+
+		result := importResult{Definitions: defs, Warnings: warnings}
+		if query.Get("dry_run") == "true" {
+			render.JSON(w, http.StatusOK, result)
+			return
+		}
+
+		_, span = trace.StartSpan(r.Context(), "repo.Add")
+		for _, cfg := range defs {
+			c.configurationChan <- api.ConfigurationMessage{
+				Operation:     api.AddedOperation,
+				Configuration: cfg,
+			}
+		}
+		span.End()
+
+		render.JSON(w, http.StatusCreated, result)
+	}
+}
+
+// importResult lists the definitions generated from an OpenAPI document and what could not be converted
+type importResult struct {
+	Definitions []*api.Definition `json:"definitions"`
+	Warnings    []string          `json:"warnings"`
+}
+
 // DeleteBy is the delete handler
 func (c *APIHandler) DeleteBy() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -187,14 +278,8 @@ func (c *APIHandler) DeleteBy() http.HandlerFunc {
 }
 
 func (c *APIHandler) exists(cfg *api.Definition) (bool, error) {
-	for _, storedCfg := range c.Cfgs.Definitions {
-		if storedCfg.Name == cfg.Name {
-			return true, api.ErrAPINameExists
-		}
-
-		if storedCfg.Proxy.ListenPath == cfg.Proxy.ListenPath {
-			return true, api.ErrAPIListenPathExists
-		}
+	if err := c.Cfgs.Conflict(cfg); err != nil {
+		return true, err
 	}
 
 	return false, nil
@@ -210,9 +295,10 @@ func (c *APIHandler) findByName(name string) *api.Definition {
 	return nil
 }
 
-func (c *APIHandler) findByListenPath(listenPath string) *api.Definition {
+// findByRoute finds the definition the requests of the given definition would be routed to
+func (c *APIHandler) findByRoute(def *api.Definition) *api.Definition {
 	for _, cfg := range c.Cfgs.Definitions {
-		if cfg.Proxy.ListenPath == listenPath {
+		if api.SameRoute(cfg, def) {
 			return cfg
 		}
 	}
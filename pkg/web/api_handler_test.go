@@ -0,0 +1,29 @@
+package web
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hellofresh/janus/pkg/api"
+)
+
+func TestImportOpenAPITooLarge(t *testing.T) {
+	handler := NewAPIHandler(make(chan api.ConfigurationMessage, 1)).ImportOpenAPI()
+	doc := bytes.Repeat([]byte(" "), maxOpenAPISize+1)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/apis/import/openapi", bytes.NewReader(doc)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// without a content length, the body is cut once it goes over the limit
+	req := httptest.NewRequest(http.MethodPost, "/apis/import/openapi", ioutil.NopCloser(bytes.NewReader(doc)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	handler(w, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
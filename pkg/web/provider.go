@@ -112,6 +112,7 @@ func (s *Server) addInternalRoutes(r router.Router, guard jwt.Guard) {
 		groupAPI.GET("/", s.apiHandler.Get())
 		groupAPI.GET("/{name}", s.apiHandler.GetBy())
 		groupAPI.POST("/", s.apiHandler.Post())
+		groupAPI.POST("/import/openapi", s.apiHandler.ImportOpenAPI())
 		groupAPI.PUT("/{name}", s.apiHandler.PutBy())
 		groupAPI.DELETE("/{name}", s.apiHandler.DeleteBy())
 	}